@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jsutcodes/chip8-goemu/internal/coverage"
+	"github.com/jsutcodes/chip8-goemu/internal/emulator"
+	"github.com/jsutcodes/chip8-goemu/internal/platform"
+)
+
+// coverageReport implements "chip8 coverage rom.ch8...", which runs each ROM
+// without a window for a number of frames and reports how much of its
+// reachable code ran.
+func coverageReport(args []string) int {
+	fs := flag.NewFlagSet("coverage", flag.ExitOnError)
+	frames := fs.Int("frames", 600, "frames to run each ROM for")
+	profile := fs.String("platform", "", "platform to run the ROMs as (default: detected from each ROM)")
+	heatmaps := fs.String("heatmaps", "", "directory to write a heatmap PNG of each ROM's memory use to")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: chip8 coverage [flags] rom.ch8...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	status, reachable, executed := 0, 0, 0
+	for _, path := range fs.Args() {
+		emu := emulator.NewEmulator()
+		emu.Dump.Path = ""
+		if *profile != "" {
+			p, ok := platform.Lookup(*profile)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "unknown platform %q\n", *profile)
+				return 2
+			}
+			emu.SetProfile(p)
+			emu.DetectPlatform = false
+		}
+		if err := emu.LoadROMFile(path); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			status = 1
+			continue
+		}
+		counters := coverage.Attach(emu.CPU)
+		for i := 0; i < *frames; i++ {
+			emu.Frame()
+		}
+
+		r := counters.Coverage(emu.ROM(), emu.RAM.Map().Load)
+		fmt.Printf("%s: ", path)
+		r.WriteTo(os.Stdout)
+		reachable += len(r.Reachable)
+		executed += r.Executed()
+		if *heatmaps != "" {
+			name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + ".png"
+			if err := writeCounters(filepath.Join(*heatmaps, name), counters); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				status = 1
+			}
+		}
+	}
+	if fs.NArg() > 1 && reachable > 0 {
+		fmt.Printf("Total: %.1f%% covered: %d of %d reachable instructions executed\n", 100*float64(executed)/float64(reachable), executed, reachable)
+	}
+	return status
+}
+
+// writeCounters saves memory use counts in the format the file extension
+// names: a PNG heatmap, CSV or JSON.
+func writeCounters(path string, counters *coverage.Counters) error {
+	write := map[string]func(io.Writer) error{
+		".png":  func(w io.Writer) error { return counters.WritePNG(w, 8) },
+		".csv":  counters.WriteCSV,
+		".json": counters.WriteJSON,
+	}[strings.ToLower(filepath.Ext(path))]
+	if write == nil {
+		return fmt.Errorf("%s: want a .png, .csv or .json file", path)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
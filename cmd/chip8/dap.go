@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jsutcodes/chip8-goemu/internal/dap"
+	"github.com/jsutcodes/chip8-goemu/internal/debugger"
+	"github.com/jsutcodes/chip8-goemu/internal/emulator"
+)
+
+// debugAdapter implements "chip8 dap", which speaks the Debug Adapter
+// Protocol on standard input and output so editors can launch and debug
+// ROMs. The ROM and its symbols are given by the client's launch request.
+func debugAdapter(args []string) int {
+	fs := flag.NewFlagSet("dap", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: chip8 dap (run by an editor, which talks to it on standard input and output)")
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	// Standard output carries the protocol.
+	protocol, err := silenceStdout()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	emu := emulator.NewEmulator()
+	emu.Dump.Path = ""
+	s := dap.NewServer(debugger.New(emu), os.Stdin, protocol)
+	served := make(chan error, 1)
+	go func() { served <- s.Serve() }()
+	select {
+	case <-s.Launched():
+	case err := <-served:
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	emu.Run()
+	s.Exited()
+	if err := <-served; err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
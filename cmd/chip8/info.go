@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jsutcodes/chip8-goemu/internal/platform"
+	"github.com/jsutcodes/chip8-goemu/internal/romdb"
+	"github.com/jsutcodes/chip8-goemu/internal/romfile"
+)
+
+// info implements "chip8 info rom.ch8", which prints what the ROM database
+// knows about a ROM and how it would be run.
+func info(args []string) int {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: chip8 info rom.ch8 (or - for standard input)")
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	roms, err := romfile.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for i, rom := range roms {
+		if i > 0 {
+			fmt.Println()
+		}
+		describe(rom)
+	}
+	return 0
+}
+
+// describe prints everything known about one ROM.
+func describe(r romfile.ROM) {
+	data := r.Data
+	sha1 := romdb.Hash(data)
+	fmt.Printf("File:      %s (%d bytes)\n", r.Name, len(data))
+	if r.Format != romfile.Raw {
+		fmt.Printf("Format:    %s\n", r.Format)
+	}
+	fmt.Printf("SHA-1:     %s\n", sha1)
+
+	entry, ok := romdb.Lookup(sha1)
+	if !ok {
+		fmt.Println("Not in the ROM database")
+		printGuess(romdb.GuessPlatform(data))
+		return
+	}
+	prog, rom := entry.Program, entry.ROM
+	fmt.Printf("Title:     %s\n", prog.Title)
+	if len(prog.Authors) > 0 {
+		fmt.Printf("Authors:   %s\n", strings.Join(prog.Authors, ", "))
+	}
+	if prog.Release != "" {
+		fmt.Printf("Released:  %s\n", prog.Release)
+	}
+	desc := rom.Description
+	if desc == "" {
+		desc = prog.Description
+	}
+	if desc != "" {
+		fmt.Printf("About:     %s\n", desc)
+	}
+	fmt.Printf("Platforms: %s\n", strings.Join(rom.Platforms, ", "))
+	if p, ok := entry.Profile(); ok {
+		fmt.Printf("Runs as:   %s (-platform %s), %d instructions per frame\n", p.Name, p.ID, p.InstructionsPerFrame)
+		fmt.Printf("Quirks:    %s\n", quirkList(p))
+		if rom.StartAddress > 0 {
+			fmt.Printf("Starts at: 0x%03X\n", p.Memory.Entry)
+		}
+	} else {
+		fmt.Println("Runs as:   no supported platform")
+	}
+	if hints := entry.KeyHints(); len(hints) > 0 {
+		fmt.Println("Keys:")
+		for _, hint := range hints {
+			fmt.Println("  " + hint)
+		}
+	}
+	if c := rom.Colors; c != nil {
+		fmt.Printf("Colors:    pixels %s", strings.Join(c.Pixels, " "))
+		if c.Buzzer != "" {
+			fmt.Printf(", buzzer %s, silence %s", c.Buzzer, c.Silence)
+		}
+		fmt.Println()
+	}
+}
+
+// quirkList names a profile's quirks, or says there are none.
+func quirkList(p platform.Profile) string {
+	if quirks := p.QuirkNames(); len(quirks) > 0 {
+		return strings.Join(quirks, ", ")
+	}
+	return "none"
+}
+
+// printGuess shows the platform detected from the ROM's instructions and
+// why.
+func printGuess(g romdb.Guess) {
+	fmt.Printf("Guess:     %s (-platform %s), %.0f%% sure\n", g.Profile.Name, g.Profile.ID, g.Confidence*100)
+	for _, reason := range g.Reasons {
+		fmt.Printf("  %s\n", reason)
+	}
+}
@@ -1,14 +1,408 @@
 package main
 
 import (
+	"bufio"
+	"errors"
+	"flag"
 	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
 
+	"github.com/jsutcodes/chip8-goemu/internal/cheat"
+	"github.com/jsutcodes/chip8-goemu/internal/coverage"
+	"github.com/jsutcodes/chip8-goemu/internal/cpu"
+	"github.com/jsutcodes/chip8-goemu/internal/debugger"
+	"github.com/jsutcodes/chip8-goemu/internal/display"
 	"github.com/jsutcodes/chip8-goemu/internal/emulator"
+	"github.com/jsutcodes/chip8-goemu/internal/font"
+	"github.com/jsutcodes/chip8-goemu/internal/gdbstub"
+	"github.com/jsutcodes/chip8-goemu/internal/input"
+	"github.com/jsutcodes/chip8-goemu/internal/memory"
+	"github.com/jsutcodes/chip8-goemu/internal/platform"
+	"github.com/jsutcodes/chip8-goemu/internal/profiler"
+	"github.com/jsutcodes/chip8-goemu/internal/romfile"
+	"github.com/jsutcodes/chip8-goemu/internal/symbols"
+	"github.com/jsutcodes/chip8-goemu/internal/term"
+	"github.com/jsutcodes/chip8-goemu/internal/tui"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "info" {
+		os.Exit(info(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "coverage" {
+		os.Exit(coverageReport(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "dap" {
+		os.Exit(debugAdapter(os.Args[2:]))
+	}
+
+	profile := flag.String("platform", platform.Modern.ID, "platform to emulate: "+strings.Join(platform.IDs(), ", ")+" (default: detected from the ROM database)")
+	ipf := flag.Int("ipf", 0, "instructions executed per 60 Hz frame (default: the platform's usual speed)")
+	uncapped := flag.Bool("uncapped", false, "run as fast as possible instead of in real time")
+	cycleAccurate := flag.Bool("cycle-accurate", false, "time instructions like the original hardware (vip platform only)")
+	translate := flag.Bool("translate", false, "execute with the block translator instead of the interpreter")
+	stats := flag.Bool("stats", false, "show performance stats under the screen")
+	statsInterval := flag.Duration("stats-interval", 0, "print performance stats to stderr this often (e.g. 5s)")
+	interpreter := flag.String("interpreter", "", "boot this COSMAC VIP interpreter image and run the ROM under it on an emulated 1802")
+	flagsDir := flag.String("flags-dir", "", "where to keep SUPER-CHIP user flags such as high scores (default: chip8/flags under $XDG_DATA_HOME, ~/.local/share or the platform's equivalent)")
+	entry := flag.String("entry", "", "which ROM to run from a zip archive that holds several")
+	fontName := flag.String("font", "", "digit font: "+strings.Join(font.IDs(), ", ")+", or a file of 80 bytes of small digits and optionally 100 or 160 of large ones (default: the platform's)")
+	dumpPath := flag.String("dump", "memory.dump", "file to keep a dump of memory in for external viewers (empty for none)")
+	dumpFormat := flag.String("dump-format", "xxd", "memory dump format: bin, ihex, xxd or json")
+	dumpRange := flag.String("dump-range", "", "addresses to dump, as hex start-end such as 200-3ff (default: all of memory)")
+	dumpEvery := flag.Int("dump-every", 0, "rewrite the memory dump every this many frames (default: only at start)")
+	dumpAt := flag.String("dump-at", "", "comma-separated hex addresses at which to rewrite the memory dump as they execute")
+	cheats := flag.String("cheats", "", "cheat file to apply to the ROM")
+	heatmap := flag.String("heatmap", "", "on exit, write how often each address was executed, read and written to this file: .png for a heatmap, .csv or .json for the counts")
+	profilePath := flag.String("profile", "", "on exit, write where the program spent its time to this file: .pb.gz for go tool pprof, anything else for a text report")
+	symbolPath := flag.String("symbols", "", "symbol file naming the ROM's addresses, as Octo label JSON or ADDR NAME lines (default: the ROM's name with .sym or .labels.json, if there is one)")
+	tracePath := flag.String("trace", "", "write every instruction run to this file")
+	gdb := flag.String("gdb", "", "listen for GDB on this local address, such as :1234, and wait for it before running")
+	debug := flag.Bool("debug", false, "read debugger commands from standard input while running (type help for a list)")
+	tuiMode := flag.Bool("tui", false, "run in a full-screen terminal debugger, with the keypad on the keyboard, instead of printing the screen")
+	screenMode := flag.String("screen", "half", "how to draw the screen on a terminal: full (two blocks per pixel), half (two pixel rows per line) or braille (2x4 pixels per character); smaller terminals fall back to denser modes")
+	colors := flag.String("colors", "auto", "terminal colours: none, 256, truecolor or auto to go by $COLORTERM and $TERM")
+	palette := flag.String("palette", "white", "screen colours on a terminal: "+strings.Join(display.PaletteNames(), ", ")+", or lit and unlit colours such as ffb000,1a1000 (default: the ROM's colours from the ROM database, if it has them)")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: chip8 [flags] [rom | -]")
+		fmt.Fprintln(flag.CommandLine.Output(), "The ROM may be a raw binary, a zip of ROMs or a hex dump.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	rom := "roms/IBMLogo.ch8"
+	if flag.NArg() > 0 {
+		rom = flag.Arg(0)
+	}
+
 	fmt.Println("Starting CHIP-8 Emulator")
 	emu := emulator.NewEmulator()
-	emu.LoadROM("roms/IBMLogo.ch8")
+	p, ok := platform.Lookup(*profile)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown platform %q\n", *profile)
+		os.Exit(2)
+	}
+	emu.SetProfile(p)
+	// Choosing a platform overrides detection, and choosing a palette the
+	// ROM's own colours.
+	emu.ROMColors = true
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "platform":
+			emu.DetectPlatform = false
+		case "palette":
+			emu.ROMColors = false
+		}
+	})
+	emu.Speed.Uncapped = *uncapped
+	emu.CycleAccurate = *cycleAccurate
+	emu.ShowStats = *stats
+	emu.StatsInterval = *statsInterval
+	emu.FlagsDir = emulator.DefaultFlagsDir()
+	if *flagsDir != "" {
+		emu.FlagsDir = *flagsDir
+	}
+	if *translate {
+		emu.CPU.Engine = cpu.Translator
+	}
+	if err := setDump(emu, *dumpPath, *dumpFormat, *dumpRange, *dumpEvery, *dumpAt); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	// -tui draws the screen itself, and -debug's console shares the
+	// terminal, which drawing in place would write over.
+	if err := setTerminal(emu, *screenMode, *colors, *palette, !*tuiMode && !*debug); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *cheats != "" {
+		s, err := cheat.Open(*cheats)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load cheats: %v\n", err)
+			os.Exit(1)
+		}
+		emu.SetCheats(s)
+	}
+	emu.ChooseROM = func(roms []romfile.ROM) (int, error) {
+		return chooseROM(roms, *entry, rom != "-")
+	}
+	if err := emu.LoadROMFile(rom); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load ROM: %v\n", err)
+		os.Exit(1)
+	}
+	if err := loadSymbols(emu, *symbolPath, rom); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load symbols: %v\n", err)
+		os.Exit(1)
+	}
+	if *tracePath != "" {
+		f, err := os.Create(*tracePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to start trace: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		trace := bufio.NewWriter(f)
+		defer trace.Flush()
+		emu.TraceTo(trace)
+	}
+	if *ipf > 0 {
+		emu.SetInstructionsPerFrame(*ipf)
+	}
+	if *fontName != "" {
+		f, ok := font.Lookup(*fontName)
+		if !ok {
+			var err error
+			if f, err = font.Open(*fontName); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to load font: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		emu.SetFont(f)
+	}
+	if *interpreter != "" {
+		image, err := os.ReadFile(*interpreter)
+		if err == nil {
+			err = emu.BootInterpreter(image)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to boot interpreter: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var d *debugger.Debugger
+	if *debug || *gdb != "" || *tuiMode {
+		d = debugger.New(emu)
+	}
+	if (*debug || *tuiMode) && rom == "-" {
+		fmt.Fprintln(os.Stderr, "-debug and -tui need standard input for commands, so the ROM cannot come from it")
+		os.Exit(2)
+	}
+	if *debug && *tuiMode {
+		fmt.Fprintln(os.Stderr, "-debug and -tui both read standard input, so use one or the other")
+		os.Exit(2)
+	}
+	if *debug {
+		d.Attach(os.Stdin, os.Stderr)
+	}
+	if *gdb != "" {
+		if err := listenForGDB(d, *gdb); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to listen for GDB: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var counters *coverage.Counters
+	if *heatmap != "" {
+		counters = coverage.Attach(emu.CPU)
+	}
+	var prof *profiler.Profiler
+	if *profilePath != "" {
+		var timing cpu.TimingModel
+		if emu.CycleAccurate {
+			timing = emu.Profile.Timing
+		}
+		prof = profiler.Attach(emu.CPU, timing)
+		prof.Symbols = emu.Symbols
+	}
+	var ui *tui.UI
+	if *tuiMode {
+		terminal, err := silenceStdout()
+		if err == nil {
+			ui = tui.New(d, os.Stdin, terminal)
+			err = ui.Start()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to start the terminal debugger: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	// Read the keypad and speed hotkeys from SDL, if it is there.
+	if next, stop, err := input.StartEvents(); err == nil {
+		defer stop()
+		emu.Events = next
+	}
+	// Stop cleanly on Ctrl-C so the ROM's user flags are saved.
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		emu.Stop()
+	}()
 	emu.Run()
+	if ui != nil {
+		ui.Close()
+	}
+	if counters != nil {
+		if err := writeCounters(*heatmap, counters); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write heatmap: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if prof != nil {
+		if err := writeProfile(*profilePath, prof, rom); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write profile: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// silenceStdout points os.Stdout, where the emulator prints its text screen
+// and messages, at the null device and returns the real standard output, for
+// front ends that need it to themselves.
+func silenceStdout() (*os.File, error) {
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	stdout := os.Stdout
+	os.Stdout = null
+	return stdout, nil
+}
+
+// listenForGDB serves the GDB remote protocol on address, which is taken to
+// be on localhost if it gives no host, and pauses the program until a client
+// connects and lets it run.
+func listenForGDB(d *debugger.Debugger, address string) error {
+	if strings.HasPrefix(address, ":") {
+		address = "localhost" + address
+	}
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	s := gdbstub.New(d)
+	d.Emulator().Pause()
+	fmt.Fprintf(os.Stderr, "Waiting for GDB on %s (target remote %s)\n", l.Addr(), l.Addr())
+	go s.Serve(l)
+	return nil
+}
+
+// loadSymbols loads the symbol file at path or, if path is empty, one named
+// after the ROM if it exists.
+func loadSymbols(emu *emulator.Emulator, path, rom string) error {
+	if path == "" {
+		base := strings.TrimSuffix(rom, filepath.Ext(rom))
+		for _, ext := range []string{".sym", ".labels.json"} {
+			if _, err := os.Stat(base + ext); err == nil && rom != "-" {
+				path = base + ext
+				break
+			}
+		}
+		if path == "" {
+			return nil
+		}
+	}
+	table, err := symbols.Open(path)
+	if err != nil {
+		return err
+	}
+	emu.Symbols = table
+	fmt.Printf("Loaded %d symbols from %s\n", table.Len(), path)
+	return nil
+}
+
+// writeProfile saves the profile as pprof data if path ends in .pb.gz, and
+// as a text report otherwise.
+func writeProfile(path string, prof *profiler.Profiler, rom string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(path, ".pb.gz") {
+		err = prof.WritePprof(f, filepath.Base(rom))
+	} else {
+		prof.WriteReport(f, 20)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// setDump configures the memory dump from the command line flags.
+func setDump(emu *emulator.Emulator, path, format, span string, every int, at string) error {
+	f, err := memory.ParseFormat(format)
+	if err != nil {
+		return err
+	}
+	emu.Dump = emulator.MemoryDump{Path: path, Format: f, Every: every}
+	if span != "" {
+		if emu.Dump.Range, err = memory.ParseRange(span); err != nil {
+			return err
+		}
+	}
+	if at == "" {
+		return nil
+	}
+	emu.Dump.OnBreakpoint = true
+	for _, field := range strings.Split(at, ",") {
+		address, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(field), "0x"), 16, 16)
+		if err != nil {
+			return fmt.Errorf("bad -dump-at address %q", field)
+		}
+		emu.CPU.SetBreakpoint(uint16(address))
+	}
+	return nil
+}
+
+// setTerminal reads the -screen, -colors and -palette flags and, if use is
+// set and standard output is a terminal, draws the screen on it in place.
+func setTerminal(emu *emulator.Emulator, mode, colors, palette string, use bool) error {
+	m, err := display.ParseMode(mode)
+	if err != nil {
+		return err
+	}
+	depth := display.DetectColorDepth(os.Getenv)
+	if colors != "auto" {
+		if depth, err = display.ParseColorDepth(colors); err != nil {
+			return err
+		}
+	}
+	p, err := display.ParsePalette(palette)
+	if err != nil {
+		return err
+	}
+	stdout := os.Stdout
+	if _, _, err := term.Size(stdout.Fd()); err != nil || !use {
+		return nil
+	}
+	t := display.NewTerminal(stdout)
+	t.Mode, t.Colors, t.Palette = m, depth, p
+	t.Size = func() (int, int, error) { return term.Size(stdout.Fd()) }
+	if emu.ShowStats {
+		t.Reserve = 1
+	}
+	emu.Terminal = t
+	return nil
+}
+
+// chooseROM picks a ROM from an archive: the one named by -entry, or else
+// the one the user chooses from a list, if standard input is free to ask.
+func chooseROM(roms []romfile.ROM, entry string, ask bool) (int, error) {
+	if entry != "" {
+		for i, r := range roms {
+			if r.Name == entry || path.Base(r.Name) == entry {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("no ROM named %q in the archive", entry)
+	}
+	fmt.Fprintln(os.Stderr, "The archive holds several ROMs:")
+	for i, r := range roms {
+		fmt.Fprintf(os.Stderr, "%3d  %s (%d bytes)\n", i+1, r.Name, len(r.Data))
+	}
+	if !ask {
+		return 0, errors.New("choose one with -entry")
+	}
+	fmt.Fprint(os.Stderr, "Run which? ")
+	var n int
+	if _, err := fmt.Scan(&n); err != nil || n < 1 || n > len(roms) {
+		return 0, errors.New("no ROM chosen")
+	}
+	return n - 1, nil
 }
@@ -0,0 +1,348 @@
+// Package cdp1802 emulates the RCA CDP1802 microprocessor used in the COSMAC
+// VIP, and the parts of the VIP around it that the CHIP-8 interpreter relies
+// on: its memory map, the CDP1861 video chip's DMA display and the keypad.
+package cdp1802
+
+// Bus connects the CPU to memory and I/O.
+type Bus interface {
+	Read(address uint16) byte
+	Write(address uint16, value byte)
+	// Output is called by OUT 1-7 with the byte read from M(R(X)).
+	Output(port byte, value byte)
+	// Input is called by INP 1-7; the result is stored in M(R(X)) and D.
+	Input(port byte) byte
+	// EF reports the state of flag line EF1-EF4.
+	EF(n int) bool
+}
+
+// CPU is the register state of a CDP1802.
+type CPU struct {
+	R  [16]uint16 // Scratchpad registers
+	D  byte       // Data register (accumulator)
+	DF bool       // Data flag (carry/not borrow)
+	P  byte       // Selects the program counter register
+	X  byte       // Selects the data pointer register
+	T  byte       // X and P saved by an interrupt or MARK
+	IE bool       // Interrupts enabled
+	Q  bool       // Q output flip-flop
+	// Idle is set by IDL and cleared by the next interrupt or DMA cycle.
+	Idle   bool
+	Cycles uint64 // Machine cycles executed, 8 clock pulses each
+	Bus    Bus
+}
+
+func New(bus Bus) *CPU {
+	c := &CPU{Bus: bus}
+	c.Reset()
+	return c
+}
+
+// Reset puts the CPU in its power-on state: P, X and R0 cleared, interrupts
+// enabled and Q off. Execution starts at address 0 with R0 as program counter.
+func (c *CPU) Reset() {
+	c.P, c.X, c.R[0] = 0, 0, 0
+	c.IE = true
+	c.Q = false
+	c.Idle = false
+}
+
+// Interrupt takes an interrupt if they are enabled, saving X and P in T and
+// continuing with R1 as program counter and R2 as data pointer. It reports
+// whether the interrupt was taken.
+func (c *CPU) Interrupt() bool {
+	if !c.IE {
+		return false
+	}
+	c.T = c.X<<4 | c.P
+	c.P, c.X = 1, 2
+	c.IE = false
+	c.Idle = false
+	c.Cycles++
+	return true
+}
+
+// DMAOut performs one output DMA cycle, returning M(R0) and advancing R0.
+func (c *CPU) DMAOut() byte {
+	v := c.Bus.Read(c.R[0])
+	c.R[0]++
+	c.Idle = false
+	c.Cycles++
+	return v
+}
+
+// DMAIn performs one input DMA cycle, storing v at M(R0) and advancing R0.
+func (c *CPU) DMAIn(v byte) {
+	c.Bus.Write(c.R[0], v)
+	c.R[0]++
+	c.Idle = false
+	c.Cycles++
+}
+
+// fetch reads the byte at the program counter and advances it.
+func (c *CPU) fetch() byte {
+	v := c.Bus.Read(c.R[c.P])
+	c.R[c.P]++
+	return v
+}
+
+// Step executes one instruction and returns how many machine cycles it took:
+// two for most instructions, three for long branches and skips. An idle CPU
+// just burns one cycle.
+func (c *CPU) Step() int {
+	if c.Idle {
+		c.Cycles++
+		return 1
+	}
+
+	opcode := c.fetch()
+	n := opcode & 0x0F
+	cycles := 2
+
+	switch opcode >> 4 {
+	case 0x0:
+		if n == 0 {
+			c.Idle = true // IDL
+		} else {
+			c.D = c.Bus.Read(c.R[n]) // LDN
+		}
+	case 0x1:
+		c.R[n]++ // INC
+	case 0x2:
+		c.R[n]-- // DEC
+	case 0x3:
+		c.shortBranch(c.condition(n))
+	case 0x4:
+		c.D = c.Bus.Read(c.R[n]) // LDA
+		c.R[n]++
+	case 0x5:
+		c.Bus.Write(c.R[n], c.D) // STR
+	case 0x6:
+		switch {
+		case n == 0:
+			c.R[c.X]++ // IRX
+		case n < 8:
+			c.Bus.Output(n, c.Bus.Read(c.R[c.X])) // OUT
+			c.R[c.X]++
+		case n > 8:
+			v := c.Bus.Input(n - 8) // INP
+			c.Bus.Write(c.R[c.X], v)
+			c.D = v
+		}
+		// 0x68 is not a 1802 instruction; it does nothing here.
+	case 0x7:
+		c.execute7(n)
+	case 0x8:
+		c.D = byte(c.R[n]) // GLO
+	case 0x9:
+		c.D = byte(c.R[n] >> 8) // GHI
+	case 0xA:
+		c.R[n] = c.R[n]&0xFF00 | uint16(c.D) // PLO
+	case 0xB:
+		c.R[n] = c.R[n]&0x00FF | uint16(c.D)<<8 // PHI
+	case 0xC:
+		cycles = 3
+		c.longBranchOrSkip(n)
+	case 0xD:
+		c.P = n // SEP
+	case 0xE:
+		c.X = n // SEX
+	case 0xF:
+		c.executeF(n)
+	}
+
+	c.Cycles += uint64(cycles)
+	return cycles
+}
+
+// condition evaluates the test of short branch 3N. SKP (38) never branches,
+// which makes it skip the branch address.
+func (c *CPU) condition(n byte) bool {
+	var cond bool
+	switch n & 0x7 {
+	case 0:
+		cond = true // BR
+	case 1:
+		cond = c.Q // BQ
+	case 2:
+		cond = c.D == 0 // BZ
+	case 3:
+		cond = c.DF // BDF
+	default:
+		cond = c.Bus.EF(int(n&0x7) - 3) // B1-B4
+	}
+	if n&0x8 != 0 {
+		return !cond // SKP, BNQ, BNZ, BNF, BN1-BN4
+	}
+	return cond
+}
+
+// shortBranch jumps within the current page to the address byte following
+// the opcode, or steps over it.
+func (c *CPU) shortBranch(taken bool) {
+	pc := c.R[c.P]
+	if taken {
+		c.R[c.P] = pc&0xFF00 | uint16(c.Bus.Read(pc))
+	} else {
+		c.R[c.P] = pc + 1
+	}
+}
+
+func (c *CPU) longBranchOrSkip(n byte) {
+	pc := c.R[c.P]
+	branch := func(taken bool) {
+		if taken {
+			c.R[c.P] = uint16(c.Bus.Read(pc))<<8 | uint16(c.Bus.Read(pc+1))
+		} else {
+			c.R[c.P] = pc + 2
+		}
+	}
+	skip := func(taken bool) {
+		if taken {
+			c.R[c.P] = pc + 2
+		}
+	}
+
+	switch n {
+	case 0x0:
+		branch(true) // LBR
+	case 0x1:
+		branch(c.Q) // LBQ
+	case 0x2:
+		branch(c.D == 0) // LBZ
+	case 0x3:
+		branch(c.DF) // LBDF
+	case 0x4:
+		// NOP
+	case 0x5:
+		skip(!c.Q) // LSNQ
+	case 0x6:
+		skip(c.D != 0) // LSNZ
+	case 0x7:
+		skip(!c.DF) // LSNF
+	case 0x8:
+		skip(true) // LSKP
+	case 0x9:
+		branch(!c.Q) // LBNQ
+	case 0xA:
+		branch(c.D != 0) // LBNZ
+	case 0xB:
+		branch(!c.DF) // LBNF
+	case 0xC:
+		skip(c.IE) // LSIE
+	case 0xD:
+		skip(c.Q) // LSQ
+	case 0xE:
+		skip(c.D == 0) // LSZ
+	case 0xF:
+		skip(c.DF) // LSDF
+	}
+}
+
+// add sets D to a + b + carry and DF to the carry out.
+func (c *CPU) add(a, b byte, carry bool) {
+	sum := uint16(a) + uint16(b)
+	if carry {
+		sum++
+	}
+	c.D = byte(sum)
+	c.DF = sum > 0xFF
+}
+
+// subtract sets D to a - b, minus one more if borrowIn, and DF to 1 when no
+// borrow was needed, as the 1802 does.
+func (c *CPU) subtract(a, b byte, borrowIn bool) {
+	diff := int(a) - int(b)
+	if borrowIn {
+		diff--
+	}
+	c.D = byte(diff)
+	c.DF = diff >= 0
+}
+
+func (c *CPU) execute7(n byte) {
+	switch n {
+	case 0x0, 0x1: // RET, DIS
+		v := c.Bus.Read(c.R[c.X])
+		c.R[c.X]++
+		c.X, c.P = v>>4, v&0x0F
+		c.IE = n == 0x0
+	case 0x2: // LDXA
+		c.D = c.Bus.Read(c.R[c.X])
+		c.R[c.X]++
+	case 0x3: // STXD
+		c.Bus.Write(c.R[c.X], c.D)
+		c.R[c.X]--
+	case 0x4: // ADC
+		c.add(c.Bus.Read(c.R[c.X]), c.D, c.DF)
+	case 0x5: // SDB
+		c.subtract(c.Bus.Read(c.R[c.X]), c.D, !c.DF)
+	case 0x6: // SHRC
+		carry := c.D&0x01 != 0
+		c.D >>= 1
+		if c.DF {
+			c.D |= 0x80
+		}
+		c.DF = carry
+	case 0x7: // SMB
+		c.subtract(c.D, c.Bus.Read(c.R[c.X]), !c.DF)
+	case 0x8: // SAV
+		c.Bus.Write(c.R[c.X], c.T)
+	case 0x9: // MARK
+		c.T = c.X<<4 | c.P
+		c.Bus.Write(c.R[2], c.T)
+		c.X = c.P
+		c.R[2]--
+	case 0xA: // REQ
+		c.Q = false
+	case 0xB: // SEQ
+		c.Q = true
+	case 0xC: // ADCI
+		c.add(c.fetch(), c.D, c.DF)
+	case 0xD: // SDBI
+		c.subtract(c.fetch(), c.D, !c.DF)
+	case 0xE: // SHLC
+		carry := c.D&0x80 != 0
+		c.D <<= 1
+		if c.DF {
+			c.D |= 0x01
+		}
+		c.DF = carry
+	case 0xF: // SMBI
+		c.subtract(c.D, c.fetch(), !c.DF)
+	}
+}
+
+func (c *CPU) executeF(n byte) {
+	// F8-FF take their operand from the program instead of M(R(X)).
+	operand := func() byte {
+		if n&0x8 != 0 {
+			return c.fetch()
+		}
+		return c.Bus.Read(c.R[c.X])
+	}
+
+	switch n & 0x7 {
+	case 0x0: // LDX, LDI
+		c.D = operand()
+	case 0x1: // OR, ORI
+		c.D |= operand()
+	case 0x2: // AND, ANI
+		c.D &= operand()
+	case 0x3: // XOR, XRI
+		c.D ^= operand()
+	case 0x4: // ADD, ADI
+		c.add(operand(), c.D, false)
+	case 0x5: // SD, SDI
+		c.subtract(operand(), c.D, false)
+	case 0x6: // SHR, SHL
+		if n == 0x6 {
+			c.DF = c.D&0x01 != 0
+			c.D >>= 1
+		} else {
+			c.DF = c.D&0x80 != 0
+			c.D <<= 1
+		}
+	case 0x7: // SM, SMI
+		c.subtract(c.D, operand(), false)
+	}
+}
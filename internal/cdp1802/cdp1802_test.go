@@ -0,0 +1,208 @@
+package cdp1802
+
+import "testing"
+
+// testBus is 64 KB of RAM with recorded I/O.
+type testBus struct {
+	mem [0x10000]byte
+	out [8]byte
+	in  [8]byte
+	ef  [5]bool
+}
+
+func (b *testBus) Read(address uint16) byte         { return b.mem[address] }
+func (b *testBus) Write(address uint16, value byte) { b.mem[address] = value }
+func (b *testBus) Output(port byte, value byte)     { b.out[port] = value }
+func (b *testBus) Input(port byte) byte             { return b.in[port] }
+func (b *testBus) EF(n int) bool                    { return b.ef[n] }
+
+// run loads program at 0 and steps until the CPU idles.
+func run(t *testing.T, program ...byte) (*CPU, *testBus) {
+	t.Helper()
+	bus := &testBus{}
+	copy(bus.mem[:], program)
+	c := New(bus)
+	for i := 0; !c.Idle; i++ {
+		if i == 1000 {
+			t.Fatalf("program did not reach IDL")
+		}
+		c.Step()
+	}
+	return c, bus
+}
+
+func TestRegisterTransfers(t *testing.T) {
+	c, _ := run(t,
+		0xF8, 0x12, // LDI 12
+		0xB5,       // PHI R5
+		0xF8, 0x34, // LDI 34
+		0xA5, // PLO R5
+		0x15, // INC R5
+		0x95, // GHI R5
+		0x00, // IDL
+	)
+	if c.R[5] != 0x1235 || c.D != 0x12 {
+		t.Errorf("Expected R5 0x1235 and D 0x12, got R5 0x%04X and D 0x%02X", c.R[5], c.D)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		op   byte // immediate opcode
+		d    byte
+		df   bool
+		imm  byte
+		want byte
+		wdf  bool
+	}{
+		{"ADI carry", 0xFC, 0xF0, false, 0x20, 0x10, true},
+		{"ADCI carry in", 0x7C, 0x01, true, 0x01, 0x03, false},
+		{"SMI no borrow", 0xFF, 0x05, false, 0x03, 0x02, true},
+		{"SMI borrow", 0xFF, 0x03, false, 0x05, 0xFE, false},
+		{"SDI", 0xFD, 0x03, false, 0x05, 0x02, true},
+		{"SMBI borrow in", 0x7F, 0x05, false, 0x03, 0x01, true},
+		{"SDBI borrow out", 0x7D, 0x05, true, 0x03, 0xFE, false},
+		{"ANI", 0xFA, 0xF0, false, 0x3C, 0x30, false},
+		{"ORI", 0xF9, 0xF0, false, 0x0F, 0xFF, false},
+		{"XRI", 0xFB, 0xFF, false, 0x0F, 0xF0, false},
+	}
+	for _, tt := range tests {
+		bus := &testBus{}
+		bus.mem[0], bus.mem[1] = tt.op, tt.imm
+		c := New(bus)
+		c.D, c.DF = tt.d, tt.df
+		c.Step()
+		if c.D != tt.want || c.DF != tt.wdf {
+			t.Errorf("%s: expected D 0x%02X DF %v, got 0x%02X %v", tt.name, tt.want, tt.wdf, c.D, c.DF)
+		}
+	}
+}
+
+func TestShifts(t *testing.T) {
+	c, _ := run(t,
+		0xF8, 0x81, // LDI 81
+		0xF6, // SHR: D 40, DF 1
+		0x76, // SHRC: D A0, DF 0
+		0xFE, // SHL: D 40, DF 1
+		0x7E, // SHLC: D 81, DF 0
+		0x00,
+	)
+	if c.D != 0x81 || c.DF {
+		t.Errorf("Expected D 0x81 and DF clear, got 0x%02X %v", c.D, c.DF)
+	}
+}
+
+func TestBranches(t *testing.T) {
+	c, _ := run(t,
+		0xF8, 0x00, // 00 LDI 00
+		0x32, 0x06, // 02 BZ 06
+		0xF8, 0xEE, // 04 LDI EE (skipped)
+		0x3A, 0x0A, // 06 BNZ 0A (not taken)
+		0xC2, 0x00, 0x10, // 08 LBZ 0010
+		0x00, // 0B
+		0x00, 0x00, 0x00, 0x00,
+		0xCE,       // 10 LSZ
+		0xF8, 0xEE, // 11 LDI EE (skipped)
+		0x38,       // 13 SKP
+		0xF8,       // 14 (skipped)
+		0xF8, 0x42, // 15 LDI 42
+		0x00,
+	)
+	if c.D != 0x42 || c.R[0] != 0x18 {
+		t.Errorf("Expected D 0x42 and R0 0x18, got 0x%02X 0x%04X", c.D, c.R[0])
+	}
+	if c.Cycles != 2*6+3*2 {
+		t.Errorf("Expected 18 machine cycles, got %d", c.Cycles)
+	}
+}
+
+func TestSubroutine(t *testing.T) {
+	// The classic SEP call: R3 is switched in as program counter, and the
+	// routine returns with SEP R0.
+	c, _ := run(t,
+		0xF8, 0x20, // LDI 20
+		0xA3, // PLO R3
+		0xD3, // SEP R3
+		0x00, // IDL after the return
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00,
+		0xF8, 0x77, // 20 LDI 77
+		0xD0, // SEP R0
+	)
+	if c.D != 0x77 || c.P != 0 || c.R[0] != 0x05 {
+		t.Errorf("Expected to return to 0x05 with D 0x77, got P %d R0 0x%04X D 0x%02X", c.P, c.R[0], c.D)
+	}
+}
+
+func TestStackAndIO(t *testing.T) {
+	bus := &testBus{}
+	copy(bus.mem[:], []byte{
+		0xF8, 0x80, // LDI 80
+		0xA2,       // PLO R2
+		0xE2,       // SEX 2
+		0xF8, 0x5A, // LDI 5A
+		0x73, // STXD: M(80) = 5A
+		0x60, // IRX
+		0x62, // OUT 2: port 2 <- 5A, R2 = 81
+		0x6B, // INP 3: M(81) = D = in[3]
+		0x00,
+	})
+	bus.in[3] = 0xC3
+	c := New(bus)
+	for !c.Idle {
+		c.Step()
+	}
+	if bus.out[2] != 0x5A {
+		t.Errorf("Expected OUT 2 to send 0x5A, got 0x%02X", bus.out[2])
+	}
+	if c.D != 0xC3 || bus.mem[0x81] != 0xC3 {
+		t.Errorf("Expected INP 3 to store 0xC3, got D 0x%02X M 0x%02X", c.D, bus.mem[0x81])
+	}
+}
+
+func TestInterruptAndReturn(t *testing.T) {
+	bus := &testBus{}
+	copy(bus.mem[:], []byte{
+		0xF8, 0x80, 0xA2, // R2 = 80
+		0xF8, 0x20, 0xA1, // R1 = 20
+		0xE5, // SEX 5
+		0x00, // IDL
+	})
+	copy(bus.mem[0x20:], []byte{
+		0x22, 0x78, // DEC R2, SAV
+		0x7B, // SEQ
+		0x70, // RET
+	})
+	c := New(bus)
+	for !c.Idle {
+		c.Step()
+	}
+	if !c.Interrupt() || c.P != 1 || c.X != 2 || c.IE {
+		t.Fatalf("Expected interrupt to switch to R1/R2 with interrupts off")
+	}
+	for c.P == 1 {
+		c.Step()
+	}
+	if !c.Q || c.P != 0 || c.X != 5 || !c.IE || c.R[2] != 0x80 {
+		t.Errorf("Expected RET to restore X 5 and P 0, got X %d P %d IE %v R2 0x%04X", c.X, c.P, c.IE, c.R[2])
+	}
+	if c.R[0] != 0x08 {
+		t.Errorf("Expected to resume after the IDL, got R0 0x%04X", c.R[0])
+	}
+}
+
+func TestDMA(t *testing.T) {
+	bus := &testBus{}
+	bus.mem[0x100], bus.mem[0x101] = 0xAA, 0x55
+	c := New(bus)
+	c.R[0] = 0x100
+	c.Idle = true
+	if a, b := c.DMAOut(), c.DMAOut(); a != 0xAA || b != 0x55 {
+		t.Errorf("Expected DMA to read 0xAA 0x55, got 0x%02X 0x%02X", a, b)
+	}
+	if c.R[0] != 0x102 || c.Idle || c.Cycles != 2 {
+		t.Errorf("Expected DMA to advance R0, wake the CPU and take a cycle each")
+	}
+}
@@ -0,0 +1,264 @@
+package cdp1802
+
+import (
+	"errors"
+
+	"github.com/jsutcodes/chip8-goemu/internal/display"
+)
+
+// COSMAC VIP memory map and video timing. RAM starts at 0 and is mirrored up
+// to 0x7FFF; the 512 byte monitor ROM sits at 0x8000 and is mirrored above.
+// The CDP1861 draws 262 lines of 14 machine cycles per frame. On each of the
+// 128 display lines it takes 8 bytes by DMA, so a line leaves the CPU 6.
+const (
+	RAMSize        = 4096
+	MonitorBase    = 0x8000
+	MonitorSize    = 512
+	ProgramStart   = 0x200
+	LinesPerFrame  = 262
+	CyclesPerLine  = 14
+	CyclesPerFrame = LinesPerFrame * CyclesPerLine
+
+	firstDisplayLine = 80
+	displayLines     = 128
+	dmaPerLine       = 8
+)
+
+var (
+	ErrInterpreterTooLarge = errors.New("interpreter image does not fit below 0x200")
+	ErrProgramTooLarge     = errors.New("program does not fit in VIP memory")
+	ErrMonitorTooLarge     = errors.New("monitor image is larger than 512 bytes")
+)
+
+// Keypad is the hex keypad as the VIP sees it.
+type Keypad interface {
+	IsKeyPressed(key uint8) bool
+}
+
+// VIP is a COSMAC VIP with 4 KB of RAM. It boots whatever is at address 0,
+// normally the CHIP-8 interpreter, with a program at 0x200.
+type VIP struct {
+	CPU     *CPU
+	RAM     [RAMSize]byte
+	Monitor [MonitorSize]byte
+	Keypad  Keypad
+	// Screen holds the 128 lines of 64 pixels that the 1861 drew in the
+	// last frame. The CHIP-8 interpreter repeats each row over four lines.
+	Screen [displayLines][dmaPerLine]byte
+
+	displayOn bool
+	key       byte // keypad latch, set by OUT 2
+	ef1       bool // 1861 display status
+}
+
+// NewVIP returns a VIP that has been reset. Its monitor ROM holds only an
+// interrupt routine, at the address the CHIP-8 interpreter expects, that
+// drives the display and ticks the timers. LoadMonitor replaces it with a
+// dump of the real ROM.
+func NewVIP(keypad Keypad) *VIP {
+	v := &VIP{Keypad: keypad}
+	copy(v.Monitor[monitorInterruptExit&0x1FF:], monitorInterrupt)
+	v.CPU = New(v)
+	v.Reset()
+	return v
+}
+
+// Reset resets the CPU and leaves R1.1 holding the page of the top of RAM, as
+// the monitor does before it starts the program at address 0.
+func (v *VIP) Reset() {
+	v.CPU.Reset()
+	v.CPU.R[1] = RAMSize - 0x100
+	v.displayOn = false
+}
+
+// LoadInterpreter copies an interpreter image, such as the original CHIP-8
+// interpreter, to address 0.
+func (v *VIP) LoadInterpreter(image []byte) error {
+	if len(image) > ProgramStart {
+		return ErrInterpreterTooLarge
+	}
+	copy(v.RAM[:], image)
+	return nil
+}
+
+// LoadProgram copies a CHIP-8 program to 0x200.
+func (v *VIP) LoadProgram(program []byte) error {
+	if len(program) > RAMSize-ProgramStart {
+		return ErrProgramTooLarge
+	}
+	copy(v.RAM[ProgramStart:], program)
+	return nil
+}
+
+// LoadMonitor replaces the built-in interrupt routine with a monitor ROM
+// image.
+func (v *VIP) LoadMonitor(image []byte) error {
+	if len(image) > MonitorSize {
+		return ErrMonitorTooLarge
+	}
+	v.Monitor = [MonitorSize]byte{}
+	copy(v.Monitor[:], image)
+	return nil
+}
+
+func (v *VIP) Read(address uint16) byte {
+	if address >= MonitorBase {
+		return v.Monitor[address%MonitorSize]
+	}
+	return v.RAM[address%RAMSize]
+}
+
+func (v *VIP) Write(address uint16, value byte) {
+	if address < MonitorBase {
+		v.RAM[address%RAMSize] = value
+	}
+}
+
+// Output handles OUT 1, which turns the display off, and OUT 2, which
+// latches the key to test on EF3.
+func (v *VIP) Output(port byte, value byte) {
+	switch port {
+	case 1:
+		v.displayOn = false
+	case 2:
+		v.key = value & 0x0F
+	}
+}
+
+// Input handles INP 1, which turns the display on.
+func (v *VIP) Input(port byte) byte {
+	if port == 1 {
+		v.displayOn = true
+	}
+	return 0
+}
+
+// EF reports the 1861 display status on EF1 and whether the latched key is
+// held on EF3.
+func (v *VIP) EF(n int) bool {
+	switch n {
+	case 1:
+		return v.ef1
+	case 3:
+		return v.Keypad != nil && v.Keypad.IsKeyPressed(v.key)
+	}
+	return false
+}
+
+// Tone reports whether the speaker is sounding, which the VIP drives from Q.
+func (v *VIP) Tone() bool {
+	return v.CPU.Q
+}
+
+// RunFrame runs one 60 Hz frame of 3668 machine cycles. While the display is
+// on, the 1861 raises an interrupt two lines before the display area, flags
+// EF1 for the four lines before it and the last four lines in it, and takes 8
+// bytes by DMA on each display line once the CPU has had its 6 cycles. DMA
+// and interrupts are taken between instructions, as on the real chip.
+func (v *VIP) RunFrame() {
+	c := v.CPU
+	frameStart := c.Cycles
+	for line := 0; line < LinesPerFrame; line++ {
+		lineEnd := frameStart + uint64((line+1)*CyclesPerLine)
+		dmaAt := lineEnd - dmaPerLine
+		row := line - firstDisplayLine
+		dma := v.displayOn && row >= 0 && row < displayLines
+		interrupt := v.displayOn && (line == firstDisplayLine-2 || line == firstDisplayLine-1)
+		v.ef1 = v.displayOn && (row >= -4 && row < 0 || row >= displayLines-4 && row < displayLines)
+
+		for c.Cycles < lineEnd {
+			if dma && c.Cycles >= dmaAt {
+				for i := range v.Screen[row] {
+					v.Screen[row][i] = c.DMAOut()
+				}
+				dma = false
+				continue
+			}
+			if interrupt && c.Interrupt() {
+				continue
+			}
+			c.Step()
+		}
+		if dma {
+			// The CPU overran the whole line with a long instruction; the
+			// 1861 still gets its bytes.
+			for i := range v.Screen[row] {
+				v.Screen[row][i] = c.DMAOut()
+			}
+		}
+	}
+	if !v.displayOn {
+		v.Screen = [displayLines][dmaPerLine]byte{}
+	}
+}
+
+// DrawTo copies the last frame to a 64x32 CHIP-8 display, taking the first of
+// every four lines.
+func (v *VIP) DrawTo(d *display.Display) {
+	for y := 0; y < displayLines/4; y++ {
+		for x := 0; x < dmaPerLine*8; x++ {
+			d.SetPixel(x, y, v.Screen[y*4][x/8]&(0x80>>(x%8)) != 0)
+		}
+	}
+}
+
+// monitorInterruptExit is where the built-in interrupt routine returns from;
+// it is laid out so the RET leaves R1 pointing at the entry point, 0x8146,
+// which is where the CHIP-8 interpreter points R1.
+const monitorInterruptExit = 0x8144
+
+// monitorInterrupt is the built-in interrupt routine. It saves T and D,
+// points R0 at the display page in RB.1, waits for the display area, then
+// has the 1861 read each 8 byte row four times. After the display it counts
+// down the delay timer in R8.1 and the sound timer in R8.0, holding Q on to
+// sound the tone while the sound timer runs. The counts go through R0, once
+// DMA has stopped using it, so that DF is left alone.
+var monitorInterrupt = []byte{
+	// 0x8144: exit
+	0x72, // LDXA      restore D
+	0x70, // RET       restore X and P, enable interrupts
+	// 0x8146: entry
+	0x22,       // DEC R2
+	0x78,       // SAV       save T
+	0x22,       // DEC R2
+	0x52,       // STR R2    save D
+	0x9B,       // GHI RB
+	0xB0,       // PHI R0
+	0xF8, 0x00, // LDI 00
+	0xA0,       // PLO R0    R0 = display page
+	0x34, 0x4F, // B1 814F   wait out the EF1 lines before the display
+	// 0x8151: one row, four display lines of 6 cycles each
+	0x80,       // GLO R0
+	0xE2,       // SEX 2
+	0xE2,       // SEX 2     -- line 1 DMA
+	0x20,       // DEC R0
+	0xA0,       // PLO R0    back to the start of the row
+	0xE2,       // SEX 2     -- line 2 DMA
+	0x20,       // DEC R0
+	0xA0,       // PLO R0
+	0xE2,       // SEX 2     -- line 3 DMA
+	0x20,       // DEC R0
+	0xA0,       // PLO R0
+	0x3C, 0x51, // BN1 8151  -- line 4 DMA moves on to the next row
+	// 0x815E: the last row is being drawn
+	0x34, 0x5E, // B1 815E   wait for the end of the display
+	0x98,       // GHI R8
+	0x32, 0x67, // BZ 8167
+	0xA0, // PLO R0
+	0x20, // DEC R0
+	0x80, // GLO R0
+	0xB8, // PHI R8    delay timer
+	// 0x8167:
+	0x88,       // GLO R8
+	0x32, 0x71, // BZ 8171
+	0x7B,       // SEQ
+	0xA0,       // PLO R0
+	0x20,       // DEC R0
+	0x80,       // GLO R0
+	0xA8,       // PLO R8    sound timer
+	0x30, 0x72, // BR 8172
+	// 0x8171:
+	0x7A, // REQ
+	// 0x8172:
+	0x30, 0x44, // BR 8144
+}
@@ -0,0 +1,98 @@
+package cdp1802
+
+import (
+	"testing"
+
+	"github.com/jsutcodes/chip8-goemu/internal/display"
+)
+
+type keys map[uint8]bool
+
+func (k keys) IsKeyPressed(key uint8) bool { return k[key] }
+
+// bootImage sets up registers the way the CHIP-8 interpreter does, starts
+// the timers, turns the display on and loops with R3 as program counter,
+// since the display takes over R0.
+var bootImage = []byte{
+	0xF8, 0x0F, 0xBB, // RB.1 = 0F, the display page
+	0xF8, 0x0E, 0xB2, 0xF8, 0xCF, 0xA2, // R2 = 0ECF
+	0xF8, 0x81, 0xB1, 0xF8, 0x46, 0xA1, // R1 = 8146
+	0xF8, 0x03, 0xB8, 0xF8, 0x02, 0xA8, // R8 = 0302
+	0xE2,             // SEX 2
+	0x69,             // INP 1
+	0xF8, 0x1B, 0xA3, // R3 = 1B
+	0xD3,       // SEP R3
+	0x30, 0x1B, // 1B: BR 1B
+}
+
+func TestVIPDisplay(t *testing.T) {
+	v := NewVIP(keys{})
+	if err := v.LoadInterpreter(bootImage); err != nil {
+		t.Fatal(err)
+	}
+	v.RAM[0xF00] = 0x80 // top left
+	v.RAM[0xF08] = 0xFF // second row
+	v.RAM[0xFFF] = 0x01 // bottom right
+
+	start := v.CPU.Cycles
+	v.RunFrame()
+	if v.CPU.Cycles-start < CyclesPerFrame {
+		t.Errorf("Expected a frame to take %d cycles, took %d", CyclesPerFrame, v.CPU.Cycles-start)
+	}
+	for line := 0; line < 4; line++ {
+		if v.Screen[line][0] != 0x80 || v.Screen[line+4][0] != 0xFF {
+			t.Errorf("Expected rows to repeat over four lines, line %d has 0x%02X", line, v.Screen[line][0])
+		}
+	}
+
+	d := display.NewDisplay()
+	v.DrawTo(d)
+	if !d.IsPixelOn(0, 0) || d.IsPixelOn(1, 0) || !d.IsPixelOn(7, 1) || !d.IsPixelOn(63, 31) {
+		t.Errorf("Expected the display page to be drawn to the screen")
+	}
+}
+
+func TestVIPTimers(t *testing.T) {
+	v := NewVIP(keys{})
+	v.LoadInterpreter(bootImage)
+
+	v.RunFrame()
+	if v.CPU.R[8] != 0x0201 || !v.Tone() {
+		t.Errorf("Expected timers 02/01 with the tone on, got 0x%04X tone %v", v.CPU.R[8], v.Tone())
+	}
+	v.RunFrame()
+	v.RunFrame()
+	if v.CPU.R[8] != 0 || v.Tone() {
+		t.Errorf("Expected timers to stop at zero with the tone off, got 0x%04X tone %v", v.CPU.R[8], v.Tone())
+	}
+}
+
+func TestVIPKeypad(t *testing.T) {
+	v := NewVIP(keys{0xA: true})
+	v.Output(2, 0x0A)
+	if !v.EF(3) {
+		t.Errorf("Expected EF3 for the latched key A")
+	}
+	v.Output(2, 0x0B)
+	if v.EF(3) {
+		t.Errorf("Expected no EF3 for key B")
+	}
+}
+
+func TestVIPMemoryMap(t *testing.T) {
+	v := NewVIP(nil)
+	v.Write(0x1234, 0x99) // mirrors 0x0234
+	if v.RAM[0x234] != 0x99 || v.Read(0x0234) != 0x99 {
+		t.Errorf("Expected RAM to be mirrored above 4 KB")
+	}
+	if v.Read(0x8144) != 0x72 || v.Read(0x8146) != 0x22 {
+		t.Errorf("Expected the interrupt routine at 0x8146")
+	}
+	v.Write(0x8146, 0)
+	if v.Read(0x8146) != 0x22 {
+		t.Errorf("Expected the monitor to be read-only")
+	}
+	if err := v.LoadInterpreter(make([]byte, 513)); err != ErrInterpreterTooLarge {
+		t.Errorf("Expected ErrInterpreterTooLarge, got %v", err)
+	}
+}
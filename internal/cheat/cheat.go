@@ -0,0 +1,289 @@
+// Package cheat reads and applies cheat codes. A cheat file holds one
+// cheat per line, a name and the codes it is made of:
+//
+//	# PONG
+//	Left player always wins: W2F0=09
+//	Fast ball: P2A4?01=02 R2B6:V3=04
+//
+// Addresses and values are hex. The codes are:
+//
+//	WAAA=VV       write VV to AAA every frame
+//	PAAA=VV       patch the byte at AAA to VV once the ROM is loaded
+//	PAAA?OO=VV    the same, but only if the ROM has OO there
+//	RAAA:VX=VV    set VX to VV each time the instruction at AAA has run
+//
+// Blank lines and lines starting with # are ignored.
+package cheat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jsutcodes/chip8-goemu/internal/cpu"
+	"github.com/jsutcodes/chip8-goemu/internal/memory"
+)
+
+// Kind is what a code does.
+type Kind int
+
+const (
+	// Write stores a value at an address every frame.
+	Write Kind = iota
+	// Patch changes a byte of the ROM once it is loaded.
+	Patch
+	// Register sets a register after the instruction at an address runs.
+	Register
+)
+
+// Code is one cheat code.
+type Code struct {
+	Kind     Kind
+	Address  uint16
+	Value    byte
+	Register byte // the X of VX, for Register codes
+	// Compare, for Patch codes with HasCompare set, is the byte the ROM
+	// must have at Address for the patch to apply.
+	Compare    byte
+	HasCompare bool
+}
+
+// ParseCode reads one code in the compact text form.
+func ParseCode(s string) (Code, error) {
+	bad := func() (Code, error) {
+		return Code{}, fmt.Errorf("bad cheat code %q", s)
+	}
+	if len(s) < 2 {
+		return bad()
+	}
+	target, v, ok := strings.Cut(s[1:], "=")
+	if !ok {
+		return bad()
+	}
+	value, err := strconv.ParseUint(v, 16, 8)
+	if err != nil {
+		return bad()
+	}
+	c := Code{Value: byte(value)}
+	switch strings.ToUpper(s[:1]) {
+	case "W":
+		c.Kind = Write
+	case "P":
+		c.Kind = Patch
+		if address, compare, ok := strings.Cut(target, "?"); ok {
+			old, err := strconv.ParseUint(compare, 16, 8)
+			if err != nil {
+				return bad()
+			}
+			target, c.Compare, c.HasCompare = address, byte(old), true
+		}
+	case "R":
+		c.Kind = Register
+		address, reg, ok := strings.Cut(target, ":")
+		if !ok || len(reg) != 2 || strings.ToUpper(reg[:1]) != "V" {
+			return bad()
+		}
+		x, err := strconv.ParseUint(reg[1:], 16, 4)
+		if err != nil {
+			return bad()
+		}
+		target, c.Register = address, byte(x)
+	default:
+		return bad()
+	}
+	address, err := strconv.ParseUint(target, 16, 16)
+	if err != nil {
+		return bad()
+	}
+	c.Address = uint16(address)
+	return c, nil
+}
+
+// String writes the code in the compact text form ParseCode reads.
+func (c Code) String() string {
+	switch c.Kind {
+	case Patch:
+		if c.HasCompare {
+			return fmt.Sprintf("P%03X?%02X=%02X", c.Address, c.Compare, c.Value)
+		}
+		return fmt.Sprintf("P%03X=%02X", c.Address, c.Value)
+	case Register:
+		return fmt.Sprintf("R%03X:V%X=%02X", c.Address, c.Register, c.Value)
+	}
+	return fmt.Sprintf("W%03X=%02X", c.Address, c.Value)
+}
+
+// Cheat is a named group of codes that are turned on and off together.
+type Cheat struct {
+	Name    string
+	Codes   []Code
+	Enabled bool
+}
+
+// String writes the cheat as a line of a cheat file. The name is left out
+// if it is the one ParseCheat makes up for unnamed cheats.
+func (c *Cheat) String() string {
+	codes := make([]string, len(c.Codes))
+	for i, code := range c.Codes {
+		codes[i] = code.String()
+	}
+	if len(codes) > 0 && (c.Name == "" || c.Name == codes[0]) {
+		return strings.Join(codes, " ")
+	}
+	return c.Name + ": " + strings.Join(codes, " ")
+}
+
+// ParseCheat reads a line of a cheat file. The cheat starts enabled.
+func ParseCheat(line string) (*Cheat, error) {
+	name, list, ok := strings.Cut(line, ":")
+	// A name is optional, but "R2B6:V3=04" alone must not lose its address.
+	if !ok || strings.Contains(name, "=") || strings.HasPrefix(strings.TrimSpace(list), "V") {
+		name, list = "", line
+	}
+	c := &Cheat{Name: strings.TrimSpace(name), Enabled: true}
+	for _, field := range strings.Fields(list) {
+		code, err := ParseCode(field)
+		if err != nil {
+			return nil, err
+		}
+		c.Codes = append(c.Codes, code)
+	}
+	if len(c.Codes) == 0 {
+		return nil, fmt.Errorf("cheat %q has no codes", c.Name)
+	}
+	if c.Name == "" {
+		c.Name = c.Codes[0].String()
+	}
+	return c, nil
+}
+
+// Set is the cheats for a ROM, applied to a machine as it runs.
+type Set struct {
+	Cheats  []*Cheat
+	patched map[uint16]byte // bytes changed by Patch codes, with their originals
+}
+
+// Read parses a cheat file. name is only used in messages.
+func Read(r io.Reader, name string) (*Set, error) {
+	s := &Set{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		c, err := ParseCheat(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, n, err)
+		}
+		s.Cheats = append(s.Cheats, c)
+	}
+	return s, scanner.Err()
+}
+
+// Open parses the cheat file at path.
+func Open(path string) (*Set, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f, path)
+}
+
+// WriteTo writes the set as a cheat file.
+func (s *Set) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, c := range s.Cheats {
+		n, err := fmt.Fprintln(w, c)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Loaded forgets the patches applied so far, because a ROM has just been
+// loaded over them.
+func (s *Set) Loaded() {
+	s.patched = nil
+}
+
+// Patch applies the Patch codes of enabled cheats that are not applied yet
+// and puts back the original bytes under those of disabled cheats.
+func (s *Set) Patch(mem *memory.Memory) {
+	want := make(map[uint16]Code)
+	for _, c := range s.Cheats {
+		for _, code := range c.Codes {
+			if code.Kind == Patch && c.Enabled && int(code.Address) < mem.Size() {
+				want[code.Address] = code
+			}
+		}
+	}
+	for address, original := range s.patched {
+		if _, ok := want[address]; !ok {
+			mem.WriteByte(address, original)
+			delete(s.patched, address)
+		}
+	}
+	for address, code := range want {
+		if _, ok := s.patched[address]; ok {
+			continue
+		}
+		original, _ := mem.ReadByte(address)
+		if code.HasCompare && original != code.Compare {
+			continue
+		}
+		if s.patched == nil {
+			s.patched = make(map[uint16]byte)
+		}
+		s.patched[address] = original
+		mem.WriteByte(address, code.Value)
+	}
+}
+
+// Frame applies the Write codes of enabled cheats. Call it once a frame.
+func (s *Set) Frame(mem *memory.Memory) {
+	for _, c := range s.Cheats {
+		if !c.Enabled {
+			continue
+		}
+		for _, code := range c.Codes {
+			if code.Kind == Write && int(code.Address) < mem.Size() {
+				mem.WriteByte(code.Address, code.Value)
+			}
+		}
+	}
+}
+
+// HasRegisterCodes reports whether any cheat sets registers, which needs
+// Executed to be called after every instruction.
+func (s *Set) HasRegisterCodes() bool {
+	for _, c := range s.Cheats {
+		for _, code := range c.Codes {
+			if code.Kind == Register {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Executed applies the Register codes of enabled cheats for the instruction
+// that just ran at pc. It fits cpu.CPU's OnExecute.
+func (s *Set) Executed(c *cpu.CPU, pc uint16) {
+	for _, cheat := range s.Cheats {
+		if !cheat.Enabled {
+			continue
+		}
+		for _, code := range cheat.Codes {
+			if code.Kind == Register && code.Address == pc {
+				c.V[code.Register] = code.Value
+			}
+		}
+	}
+}
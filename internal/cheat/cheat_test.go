@@ -0,0 +1,126 @@
+package cheat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jsutcodes/chip8-goemu/internal/cpu"
+	"github.com/jsutcodes/chip8-goemu/internal/memory"
+)
+
+func TestParseCode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Code
+	}{
+		{"W2F0=09", Code{Kind: Write, Address: 0x2F0, Value: 0x09}},
+		{"p2a4=02", Code{Kind: Patch, Address: 0x2A4, Value: 0x02}},
+		{"P2A4?01=02", Code{Kind: Patch, Address: 0x2A4, Value: 0x02, Compare: 0x01, HasCompare: true}},
+		{"R2B6:VA=04", Code{Kind: Register, Address: 0x2B6, Value: 0x04, Register: 0xA}},
+	}
+	for _, tt := range tests {
+		got, err := ParseCode(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseCode(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+			continue
+		}
+		if again, _ := ParseCode(got.String()); again != got {
+			t.Errorf("%q did not survive a round trip through %q", tt.in, got.String())
+		}
+	}
+	for _, bad := range []string{"", "W", "W200", "X200=01", "W200=100", "R200=01", "R200:I=01", "P200?ZZ=01"} {
+		if _, err := ParseCode(bad); err == nil {
+			t.Errorf("Expected ParseCode(%q) to fail", bad)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	file := `# PONG
+Left player always wins: W2F0=09
+Fast ball: P2A4?01=02 R2B6:V3=04
+
+R300:V0=01
+`
+	s, err := Read(strings.NewReader(file), "pong.cht")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Cheats) != 3 {
+		t.Fatalf("Expected 3 cheats, got %d", len(s.Cheats))
+	}
+	if c := s.Cheats[1]; c.Name != "Fast ball" || len(c.Codes) != 2 || !c.Enabled {
+		t.Errorf("Unexpected cheat %+v", c)
+	}
+	if c := s.Cheats[2]; c.Name != "R300:V0=01" || c.Codes[0].Address != 0x300 {
+		t.Errorf("Expected an unnamed register code to keep its address, got %+v", c)
+	}
+	if !s.HasRegisterCodes() {
+		t.Errorf("Expected register codes to be found")
+	}
+
+	var out bytes.Buffer
+	s.WriteTo(&out)
+	again, err := Read(&out, "again")
+	if err != nil || len(again.Cheats) != 3 || again.Cheats[1].String() != s.Cheats[1].String() {
+		t.Errorf("Expected the set to survive a round trip, got %v", err)
+	}
+
+	if _, err := Read(strings.NewReader("ok: W200=01\nbroken: Q1\n"), "bad.cht"); err == nil || !strings.HasPrefix(err.Error(), "bad.cht:2:") {
+		t.Errorf("Expected an error naming the line, got %v", err)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	mem := memory.NewMemory()
+	mem.LoadROM([]byte{0x01, 0x02, 0x03})
+	s := &Set{Cheats: []*Cheat{
+		{Name: "a", Enabled: true, Codes: []Code{{Kind: Patch, Address: 0x200, Value: 0xAA}}},
+		{Name: "b", Enabled: true, Codes: []Code{{Kind: Patch, Address: 0x201, Value: 0xBB, Compare: 0x99, HasCompare: true}}},
+		{Name: "c", Enabled: true, Codes: []Code{{Kind: Patch, Address: 0x202, Value: 0xCC, Compare: 0x03, HasCompare: true}}},
+	}}
+	s.Patch(mem)
+	got := mem.Snapshot()[0x200:0x203]
+	if !bytes.Equal(got, []byte{0xAA, 0x02, 0xCC}) {
+		t.Errorf("Expected patches to apply where the compare byte matches, got % X", got)
+	}
+
+	s.Cheats[0].Enabled = false
+	s.Patch(mem)
+	if b, _ := mem.ReadByte(0x200); b != 0x01 {
+		t.Errorf("Expected disabling a cheat to restore the original byte, got %02X", b)
+	}
+
+	mem.LoadROM([]byte{0x01, 0x02, 0x03})
+	s.Loaded()
+	s.Patch(mem)
+	if b, _ := mem.ReadByte(0x202); b != 0xCC {
+		t.Errorf("Expected patches to apply again after a reload, got %02X", b)
+	}
+}
+
+func TestFrameAndExecuted(t *testing.T) {
+	mem := memory.NewMemory()
+	s := &Set{Cheats: []*Cheat{
+		{Enabled: true, Codes: []Code{{Kind: Write, Address: 0x300, Value: 9}, {Kind: Register, Address: 0x204, Register: 3, Value: 4}}},
+		{Enabled: false, Codes: []Code{{Kind: Write, Address: 0x301, Value: 9}}},
+	}}
+	s.Frame(mem)
+	if a, _ := mem.ReadByte(0x300); a != 9 {
+		t.Errorf("Expected the write code to apply, got %d", a)
+	}
+	if b, _ := mem.ReadByte(0x301); b != 0 {
+		t.Errorf("Expected a disabled write code not to apply, got %d", b)
+	}
+
+	c := cpu.NewCPU(mem, nil, nil)
+	s.Executed(c, 0x202)
+	if c.V[3] != 0 {
+		t.Errorf("Expected no change after another instruction")
+	}
+	s.Executed(c, 0x204)
+	if c.V[3] != 4 {
+		t.Errorf("Expected V3 to be set after the instruction at 0x204, got %d", c.V[3])
+	}
+}
@@ -0,0 +1,196 @@
+// Package coverage counts how a program uses memory as it runs: which
+// addresses are executed, read as data and written. The counts can be saved
+// as JSON or CSV, drawn as a heatmap, and compared with the instructions a
+// ROM can reach to see how much of it a run exercised.
+package coverage
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/jsutcodes/chip8-goemu/internal/cpu"
+	"github.com/jsutcodes/chip8-goemu/internal/disasm"
+)
+
+// Counters holds per-address counts, indexed by address.
+type Counters struct {
+	Execute []uint64
+	Read    []uint64
+	Write   []uint64
+}
+
+// New returns empty counters for size bytes of memory.
+func New(size int) *Counters {
+	return &Counters{
+		Execute: make([]uint64, size),
+		Read:    make([]uint64, size),
+		Write:   make([]uint64, size),
+	}
+}
+
+// Attach returns counters that c fills in from now on: an execute count for
+// the address of every instruction it runs, and read and write counts for
+// the data its instructions use. Counting makes Run interpret.
+func Attach(c *cpu.CPU) *Counters {
+	counters := New(c.RAM.Size())
+	c.OnExecute(func(c *cpu.CPU, pc uint16) {
+		counters.count(&counters.Execute, pc)
+	})
+	c.OnAccess(func(c *cpu.CPU, a cpu.Access, address uint16) {
+		if a == cpu.Store {
+			counters.count(&counters.Write, address)
+		} else {
+			counters.count(&counters.Read, address)
+		}
+	})
+	return counters
+}
+
+// count adds one to address in list, growing the counters if memory has
+// grown since they were made.
+func (c *Counters) count(list *[]uint64, address uint16) {
+	if int(address) >= len(*list) {
+		c.grow(int(address) + 1)
+	}
+	(*list)[address]++
+}
+
+func (c *Counters) grow(size int) {
+	for _, list := range []*[]uint64{&c.Execute, &c.Read, &c.Write} {
+		if len(*list) < size {
+			*list = append(*list, make([]uint64, size-len(*list))...)
+		}
+	}
+}
+
+// Size returns how many addresses the counters cover.
+func (c *Counters) Size() int {
+	return len(c.Execute)
+}
+
+// Reset sets every count back to zero.
+func (c *Counters) Reset() {
+	clear(c.Execute)
+	clear(c.Read)
+	clear(c.Write)
+}
+
+// Entry is the counts for one address.
+type Entry struct {
+	Address uint16 `json:"address"`
+	Execute uint64 `json:"execute"`
+	Read    uint64 `json:"read"`
+	Write   uint64 `json:"write"`
+}
+
+// Entries lists the addresses that were used at all, in ascending order.
+func (c *Counters) Entries() []Entry {
+	var list []Entry
+	for i := range c.Execute {
+		if c.Execute[i] != 0 || c.Read[i] != 0 || c.Write[i] != 0 {
+			list = append(list, Entry{uint16(i), c.Execute[i], c.Read[i], c.Write[i]})
+		}
+	}
+	return list
+}
+
+// WriteJSON writes the addresses that were used, with their counts, as a
+// JSON array.
+func (c *Counters) WriteJSON(w io.Writer) error {
+	entries := c.Entries()
+	if entries == nil {
+		entries = []Entry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// WriteCSV writes the addresses that were used, with their counts, as CSV
+// with a header row. Addresses are hex.
+func (c *Counters) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"address", "execute", "read", "write"})
+	for _, e := range c.Entries() {
+		cw.Write([]string{
+			fmt.Sprintf("%03X", e.Address),
+			strconv.FormatUint(e.Execute, 10),
+			strconv.FormatUint(e.Read, 10),
+			strconv.FormatUint(e.Write, 10),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Report is how much of a ROM's code a run executed.
+type Report struct {
+	// Reachable is the instructions a trace of the ROM can reach.
+	Reachable []uint16
+	// Missed is the reachable instructions that never ran.
+	Missed []uint16
+	// Unseen is instructions that ran but the trace did not find, typically
+	// the targets of computed jumps.
+	Unseen []uint16
+}
+
+// Coverage compares the instructions executed with those reachable in rom,
+// loaded at base.
+func (c *Counters) Coverage(rom []byte, base uint16) Report {
+	r := Report{Reachable: disasm.Trace(rom, base)}
+	reachable := make(map[uint16]bool, len(r.Reachable))
+	for _, address := range r.Reachable {
+		reachable[address] = true
+		if int(address) >= len(c.Execute) || c.Execute[address] == 0 {
+			r.Missed = append(r.Missed, address)
+		}
+	}
+	for i := int(base); i < int(base)+len(rom) && i < len(c.Execute); i++ {
+		if c.Execute[i] != 0 && !reachable[uint16(i)] {
+			r.Unseen = append(r.Unseen, uint16(i))
+		}
+	}
+	return r
+}
+
+// Executed returns how many reachable instructions ran.
+func (r Report) Executed() int {
+	return len(r.Reachable) - len(r.Missed)
+}
+
+// Percent returns the share of reachable instructions that ran. A ROM with
+// none counts as fully covered.
+func (r Report) Percent() float64 {
+	if len(r.Reachable) == 0 {
+		return 100
+	}
+	return 100 * float64(r.Executed()) / float64(len(r.Reachable))
+}
+
+// WriteTo writes the report as text: a summary line, then the addresses of
+// the instructions that never ran.
+func (r Report) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	write := func(format string, args ...any) error {
+		n, err := fmt.Fprintf(w, format, args...)
+		total += int64(n)
+		return err
+	}
+	if err := write("%.1f%% covered: %d of %d reachable instructions executed\n", r.Percent(), r.Executed(), len(r.Reachable)); err != nil {
+		return total, err
+	}
+	if len(r.Unseen) > 0 {
+		if err := write("%d executed instructions were not found by tracing\n", len(r.Unseen)); err != nil {
+			return total, err
+		}
+	}
+	for _, address := range r.Missed {
+		if err := write("  missed 0x%03X\n", address); err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,109 @@
+package coverage
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/jsutcodes/chip8-goemu/internal/cpu"
+	"github.com/jsutcodes/chip8-goemu/internal/display"
+	"github.com/jsutcodes/chip8-goemu/internal/input"
+	"github.com/jsutcodes/chip8-goemu/internal/memory"
+)
+
+var rom = []byte{
+	0xA3, 0x00, // 200: I = 0x300
+	0x30, 0x01, // 202: skip if V0 == 1
+	0x12, 0x08, // 204: jump 0x208
+	0xF0, 0x55, // 206: save V0 at 0x300, never runs
+	0xF0, 0x65, // 208: load V0 from I, which moves on each time
+	0x12, 0x08, // 20A: jump 0x208
+}
+
+func run(t *testing.T, n int) *Counters {
+	t.Helper()
+	c := cpu.NewCPU(memory.NewMemory(), display.NewDisplay(), input.NewKeypad())
+	c.RAM.LoadROM(rom)
+	counters := Attach(c)
+	c.Run(n)
+	return counters
+}
+
+func TestAttach(t *testing.T) {
+	c := run(t, 7)
+	if c.Execute[0x200] != 1 || c.Execute[0x208] != 2 || c.Execute[0x206] != 0 {
+		t.Errorf("Unexpected execute counts %d %d %d", c.Execute[0x200], c.Execute[0x208], c.Execute[0x206])
+	}
+	if c.Read[0x300] != 1 || c.Read[0x301] != 1 || c.Write[0x300] != 0 {
+		t.Errorf("Expected a read each of 0x300 and 0x301 and no writes, got %d, %d and %d", c.Read[0x300], c.Read[0x301], c.Write[0x300])
+	}
+	if c.Execute[0x201] != 0 || c.Read[0x200] != 0 {
+		t.Errorf("Expected fetches not to count as anything but execution")
+	}
+}
+
+func TestExport(t *testing.T) {
+	c := run(t, 7)
+	var out bytes.Buffer
+	if err := c.WriteCSV(&out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if lines[0] != "address,execute,read,write" || lines[1] != "200,1,0,0" || lines[len(lines)-1] != "301,0,1,0" {
+		t.Errorf("Unexpected CSV %q", out.String())
+	}
+
+	out.Reset()
+	if err := c.WriteJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	var entries []Entry
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(lines)-1 || entries[0] != (Entry{Address: 0x200, Execute: 1}) {
+		t.Errorf("Unexpected JSON entries %+v", entries)
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	c := run(t, 7)
+	var out bytes.Buffer
+	if err := c.WritePNG(&out, 2); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 128 || b.Dy() != 128 {
+		t.Fatalf("Expected a 128x128 image of 4 KB at scale 2, got %v", b)
+	}
+	// 0x208 is row 8, column 8; 0x300 is row 12, column 0.
+	if r, g, b, _ := img.At(16, 16).RGBA(); r != 0 || g != 0xFFFF || b != 0 {
+		t.Errorf("Expected the most executed address to be bright green, got %X %X %X", r, g, b)
+	}
+	if r, g, b, _ := img.At(0, 24).RGBA(); r != 0 || g != 0 || b == 0 {
+		t.Errorf("Expected a read address to be blue, got %X %X %X", r, g, b)
+	}
+	if r, g, b, _ := img.At(126, 126).RGBA(); r|g|b != 0 {
+		t.Errorf("Expected unused memory to be black")
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	r := run(t, 7).Coverage(rom, 0x200)
+	if len(r.Reachable) != 6 || len(r.Missed) != 1 || r.Missed[0] != 0x206 {
+		t.Fatalf("Expected 0x206 missed of 6 reachable, got %X of %X", r.Missed, r.Reachable)
+	}
+	if r.Executed() != 5 || r.Percent() < 83 || r.Percent() > 84 {
+		t.Errorf("Expected 5 of 6 executed, got %d (%.1f%%)", r.Executed(), r.Percent())
+	}
+	var out bytes.Buffer
+	r.WriteTo(&out)
+	if out.String() != "83.3% covered: 5 of 6 reachable instructions executed\n  missed 0x206\n" {
+		t.Errorf("Unexpected report %q", out.String())
+	}
+}
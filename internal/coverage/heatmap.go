@@ -0,0 +1,59 @@
+package coverage
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// HeatmapWidth is how many addresses make up a row of the heatmap, so 4 KB
+// of memory is drawn as a 64x64 square.
+const HeatmapWidth = 64
+
+// Heatmap draws the counters as an image with one pixel per address, in rows
+// of HeatmapWidth from address 0 at the top left. Writes show as red,
+// execution as green and reads as blue, each brighter the more often it
+// happened on a log scale. Addresses never used are black.
+func (c *Counters) Heatmap() *image.RGBA {
+	height := (c.Size() + HeatmapWidth - 1) / HeatmapWidth
+	img := image.NewRGBA(image.Rect(0, 0, HeatmapWidth, height))
+	red, green, blue := shade(c.Write), shade(c.Execute), shade(c.Read)
+	for i := 0; i < c.Size(); i++ {
+		img.Set(i%HeatmapWidth, i/HeatmapWidth, color.RGBA{red(i), green(i), blue(i), 0xFF})
+	}
+	return img
+}
+
+// shade returns the brightness of each address in counts, scaled so the most
+// used address is fully bright and any use at all is visible.
+func shade(counts []uint64) func(i int) uint8 {
+	var most uint64
+	for _, n := range counts {
+		most = max(most, n)
+	}
+	return func(i int) uint8 {
+		if counts[i] == 0 {
+			return 0
+		}
+		return uint8(64 + 191*math.Log1p(float64(counts[i]))/math.Log1p(float64(most)))
+	}
+}
+
+// WritePNG writes the heatmap as a PNG with each address drawn as a square
+// of scale by scale pixels.
+func (c *Counters) WritePNG(w io.Writer, scale int) error {
+	img := c.Heatmap()
+	if scale <= 1 {
+		return png.Encode(w, img)
+	}
+	b := img.Bounds()
+	big := image.NewRGBA(image.Rect(0, 0, b.Dx()*scale, b.Dy()*scale))
+	for y := 0; y < big.Bounds().Dy(); y++ {
+		for x := 0; x < big.Bounds().Dx(); x++ {
+			big.Set(x, y, img.At(x/scale, y/scale))
+		}
+	}
+	return png.Encode(w, big)
+}
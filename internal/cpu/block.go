@@ -0,0 +1,163 @@
+package cpu
+
+import (
+	"github.com/jsutcodes/chip8-goemu/internal/memory"
+)
+
+// Engine selects how Run executes instructions.
+type Engine int
+
+const (
+	// Interpreter fetches and dispatches one instruction at a time.
+	Interpreter Engine = iota
+	// Translator turns straight-line runs of instructions into cached chains
+	// of closures and runs a whole chain per lookup.
+	Translator
+)
+
+// maxBlockLength caps how many instructions are translated into one block.
+const maxBlockLength = 64
+
+// block is a translated run of instructions starting at start. Only the last
+// op may jump, skip, call, return, draw or wait for a key.
+type block struct {
+	start uint16
+	ops   []func(c *CPU)
+	valid bool
+}
+
+// blockCache maps start addresses to translated blocks. A write to any
+// address covered by a block throws the whole cache away, which keeps
+// self-modifying programs correct at little cost for the ones that are not.
+type blockCache struct {
+	blocks     []*block
+	translated []bool
+}
+
+func newBlockCache(ram *memory.Memory) *blockCache {
+	bc := &blockCache{}
+	bc.fit(ram.Size())
+	ram.OnWrite(bc.invalidate)
+	return bc
+}
+
+// fit resizes the cache to cover size bytes of memory, emptying it if the
+// size has changed since it was built.
+func (bc *blockCache) fit(size int) {
+	if len(bc.blocks) != size {
+		bc.blocks = make([]*block, size)
+		bc.translated = make([]bool, size)
+	}
+}
+
+func (bc *blockCache) invalidate(address uint16) {
+	if int(address) >= len(bc.translated) || !bc.translated[address] {
+		return
+	}
+	for i, b := range bc.blocks {
+		if b != nil {
+			b.valid = false
+			bc.blocks[i] = nil
+		}
+	}
+	for i := range bc.translated {
+		bc.translated[i] = false
+	}
+}
+
+// translate builds the block starting at pc.
+func (bc *blockCache) translate(ram *memory.Memory, pc uint16) *block {
+	b := &block{start: pc, valid: true}
+	for addr := pc; len(b.ops) < maxBlockLength; addr += 2 {
+		hi, _ := ram.ReadByte(addr)
+		lo, _ := ram.ReadByte((addr + 1) & ram.Mask())
+		in := &dispatch[uint16(hi)<<8|uint16(lo)]
+		b.ops = append(b.ops, compile(in))
+		bc.translated[addr] = true
+		bc.translated[(addr+1)&ram.Mask()] = true
+		if in.branch || int(addr)+2 > int(ram.Mask()) { // the next instruction would wrap
+			break
+		}
+	}
+	bc.blocks[pc] = b
+	return b
+}
+
+// compile returns a closure for one instruction. The commonest straight-line
+// instructions get closures with their operands baked in; everything else goes
+// through its dispatch handler.
+func compile(in *instruction) func(c *CPU) {
+	x, y, nn, nnn := in.x, in.y, in.nn, in.nnn
+	switch {
+	case in.opcode&0xF000 == 0x6000:
+		return func(c *CPU) {
+			c.CycleCount++
+			c.V[x] = nn
+			c.PC += 2
+		}
+	case in.opcode&0xF000 == 0x7000:
+		return func(c *CPU) {
+			c.CycleCount++
+			c.V[x] += nn
+			c.PC += 2
+		}
+	case in.opcode&0xF000 == 0xA000:
+		return func(c *CPU) {
+			c.CycleCount++
+			c.I = nnn
+			c.PC += 2
+		}
+	case in.opcode&0xF00F == 0x8000:
+		return func(c *CPU) {
+			c.CycleCount++
+			c.V[x] = c.V[y]
+			c.PC += 2
+		}
+	}
+	return func(c *CPU) {
+		c.CycleCount++
+		in.exec(c, in)
+		c.PC &= c.addrMask()
+	}
+}
+
+// Run executes up to n instructions with the selected engine and returns how
+// many were executed. It stops early if a draw has to wait for the next
+// VBlank or PC reaches a breakpoint. Both engines leave the machine in the
+// same state.
+func (c *CPU) Run(n int) int {
+	if c.stopping() || c.Engine == Interpreter || len(c.executeHooks) > 0 {
+		for i := 0; i < n; i++ {
+			c.Cycle(false, c.RAM)
+			if c.waitingForVBlank || c.checkBreakpoint() {
+				return i + 1
+			}
+		}
+		return n
+	}
+
+	if c.blocks == nil {
+		c.blocks = newBlockCache(c.RAM)
+	}
+	c.blocks.fit(c.RAM.Size())
+	done := 0
+	for done < n {
+		c.PC &= c.addrMask()
+		b := c.blocks.blocks[c.PC]
+		if b == nil {
+			b = c.blocks.translate(c.RAM, c.PC)
+		}
+		for _, op := range b.ops {
+			op(c)
+			done++
+			// Stop early if the budget is spent or the op rewrote this block.
+			if done == n || !b.valid {
+				break
+			}
+		}
+		if c.waitingForVBlank {
+			break
+		}
+	}
+	return done
+}
@@ -0,0 +1,89 @@
+package cpu
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+// selfModifyingROM rewrites the instruction at 0x20A, inside the block that
+// is executing, from "V1 = 5" to "V1 = 7".
+var selfModifyingROM = []byte{
+	0xA2, 0x0A, // I = 0x20A
+	0x60, 0x61, // V0 = 0x61
+	0x61, 0x07, // V1 = 0x07
+	0xF1, 0x55, // store V0..V1 at 0x20A
+	0x62, 0x01, // V2 = 1
+	0x61, 0x05, // V1 = 5, rewritten to V1 = 7
+	0x12, 0x0C, // jump 0x20C
+}
+
+// differentialRun runs rom on both engines in uneven slices and fails as soon
+// as their states diverge.
+func differentialRun(t *testing.T, name string, rom []byte) {
+	interp, trans := setup(), setup()
+	trans.Engine = Translator
+	for _, c := range []*CPU{interp, trans} {
+		c.Seed(1)
+		if err := c.RAM.LoadROM(rom); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	for frame := 0; frame < 300; frame++ {
+		n := 1 + frame%17
+		if got, want := trans.Run(n), interp.Run(n); got != want {
+			t.Fatalf("%s: frame %d: translator ran %d instructions, interpreter %d", name, frame, got, want)
+		}
+		var a, b bytes.Buffer
+		interp.SaveState(&a)
+		trans.SaveState(&b)
+		if !bytes.Equal(a.Bytes(), b.Bytes()) || interp.CycleCount != trans.CycleCount {
+			t.Fatalf("%s: engines diverged at frame %d (PC 0x%X vs 0x%X)", name, frame, interp.PC, trans.PC)
+		}
+		for _, c := range []*CPU{interp, trans} {
+			if c.DT > 0 {
+				c.DT--
+			}
+		}
+	}
+}
+
+func TestTranslatorMatchesInterpreter(t *testing.T) {
+	for _, name := range []string{"IBMLogo.ch8", "PONG.ch8"} {
+		rom, err := os.ReadFile("../../roms/" + name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		differentialRun(t, name, rom)
+	}
+	differentialRun(t, "bench", benchROM)
+	differentialRun(t, "self-modifying", selfModifyingROM)
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		rom := make([]byte, 64)
+		rng.Read(rom)
+		differentialRun(t, "random", rom)
+	}
+}
+
+func TestTranslatorSelfModifyingCode(t *testing.T) {
+	cpu := setup()
+	cpu.Engine = Translator
+	cpu.RAM.LoadROM(selfModifyingROM)
+	cpu.Run(20)
+	if cpu.V[1] != 7 {
+		t.Errorf("Expected V1 to be 7 after the rewrite, got %d", cpu.V[1])
+	}
+}
+
+func BenchmarkRunTranslator(b *testing.B) {
+	cpu := setup()
+	cpu.Engine = Translator
+	cpu.RAM.LoadROM(benchROM)
+	b.ResetTimer()
+	cpu.Run(b.N)
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "instr/s")
+}
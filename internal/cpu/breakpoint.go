@@ -0,0 +1,91 @@
+package cpu
+
+import "sort"
+
+// SetBreakpoint makes Run and RunCycles stop as soon as PC reaches address.
+// The instruction there runs when execution carries on. While any
+// breakpoint is set, Run interprets even if the translator is selected.
+func (c *CPU) SetBreakpoint(address uint16) {
+	if c.breakpoints == nil {
+		c.breakpoints = make(map[uint16]bool)
+	}
+	c.breakpoints[address] = true
+}
+
+// ClearBreakpoint removes the breakpoint at address, if there is one.
+func (c *CPU) ClearBreakpoint(address uint16) {
+	delete(c.breakpoints, address)
+}
+
+// Breakpoints lists the addresses of all breakpoints in ascending order.
+func (c *CPU) Breakpoints() []uint16 {
+	addresses := make([]uint16, 0, len(c.breakpoints))
+	for address := range c.breakpoints {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i] < addresses[j] })
+	return addresses
+}
+
+// AtBreakpoint reports whether the last Run or RunCycles stopped because PC
+// reached a breakpoint or a watchpoint was hit.
+func (c *CPU) AtBreakpoint() bool {
+	return c.atBreakpoint
+}
+
+// SetWatchpoint makes Run and RunCycles stop after any instruction that
+// accesses address as data in one of the given ways, replacing any
+// watchpoint already there. Like breakpoints, watchpoints make Run
+// interpret.
+func (c *CPU) SetWatchpoint(address uint16, accesses ...Access) {
+	if c.watchpoints == nil {
+		c.watchpoints = make(map[uint16]uint8)
+	}
+	var mask uint8
+	for _, a := range accesses {
+		mask |= 1 << a
+	}
+	c.watchpoints[address] = mask
+}
+
+// ClearWatchpoint removes the watchpoint at address, if there is one.
+func (c *CPU) ClearWatchpoint(address uint16) {
+	delete(c.watchpoints, address)
+}
+
+// Watchpoint reports the access that made the last Run or RunCycles stop
+// at a watchpoint, if one did.
+func (c *CPU) Watchpoint() (address uint16, a Access, ok bool) {
+	if c.watchHit == nil {
+		return 0, 0, false
+	}
+	return c.watchHit.address, c.watchHit.access, true
+}
+
+// watchHit is an access that triggered a watchpoint.
+type watchHit struct {
+	address uint16
+	access  Access
+}
+
+// watch records an access if it triggers a watchpoint.
+func (c *CPU) watch(a Access, address uint16) {
+	if c.watchpoints[address]&(1<<a) != 0 && c.watchHit == nil {
+		c.watchHit = &watchHit{address, a}
+	}
+}
+
+// checkBreakpoint records whether PC is at a breakpoint or a watchpoint was
+// hit, and reports it.
+func (c *CPU) checkBreakpoint() bool {
+	c.atBreakpoint = c.watchHit != nil || len(c.breakpoints) > 0 && c.breakpoints[c.PC&c.addrMask()]
+	return c.atBreakpoint
+}
+
+// stopping reports whether Run has to interpret to notice breakpoints and
+// watchpoints, and forgets any watchpoint hit earlier.
+func (c *CPU) stopping() bool {
+	c.atBreakpoint = false
+	c.watchHit = nil
+	return len(c.breakpoints) > 0 || len(c.watchpoints) > 0
+}
@@ -0,0 +1,77 @@
+package cpu
+
+import "testing"
+
+func TestBreakpoint(t *testing.T) {
+	for _, engine := range []Engine{Interpreter, Translator} {
+		cpu := setup()
+		cpu.Engine = engine
+		cpu.RAM.LoadROM([]byte{
+			0x60, 0x01, // V0 = 1
+			0x61, 0x02, // V1 = 2
+			0x62, 0x03, // V2 = 3
+			0x12, 0x00, // jump 0x200
+		})
+		cpu.SetBreakpoint(0x204)
+
+		if n := cpu.Run(10); n != 2 || !cpu.AtBreakpoint() || cpu.PC != 0x204 {
+			t.Errorf("engine %d: expected to stop at 0x204 after 2 instructions, got %d at 0x%X", engine, n, cpu.PC)
+		}
+		if cpu.V[2] != 0 {
+			t.Errorf("engine %d: expected the instruction at the breakpoint not to run yet", engine)
+		}
+		if n := cpu.Run(10); n != 4 || cpu.V[2] != 3 {
+			t.Errorf("engine %d: expected to carry on and stop again after a loop, got %d", engine, n)
+		}
+
+		cpu.ClearBreakpoint(0x204)
+		if n := cpu.Run(10); n != 10 || cpu.AtBreakpoint() {
+			t.Errorf("engine %d: expected no stop after clearing the breakpoint, got %d", engine, n)
+		}
+	}
+}
+
+func TestBreakpoints(t *testing.T) {
+	cpu := setup()
+	cpu.SetBreakpoint(0x300)
+	cpu.SetBreakpoint(0x200)
+	cpu.SetBreakpoint(0x300)
+	if got := cpu.Breakpoints(); len(got) != 2 || got[0] != 0x200 || got[1] != 0x300 {
+		t.Errorf("Expected breakpoints [0x200 0x300], got %v", got)
+	}
+}
+
+func TestWatchpoint(t *testing.T) {
+	cpu := setup()
+	cpu.RAM.LoadROM([]byte{
+		0xA3, 0x00, // I = 0x300
+		0xF0, 0x55, // save V0 at 0x300
+		0xA3, 0x00, // I = 0x300
+		0xF0, 0x65, // load V0 from 0x300
+		0x12, 0x00, // jump 0x200
+	})
+	cpu.SetWatchpoint(0x300, Load)
+
+	if n := cpu.Run(10); n != 4 || !cpu.AtBreakpoint() || cpu.PC != 0x208 {
+		t.Errorf("Expected to stop after the load, got %d instructions at 0x%X", n, cpu.PC)
+	}
+	if address, a, ok := cpu.Watchpoint(); !ok || address != 0x300 || a != Load {
+		t.Errorf("Expected a load of 0x300 to be reported, got 0x%X %d %v", address, a, ok)
+	}
+
+	cpu.SetWatchpoint(0x300, Load, Store)
+	if n := cpu.Run(10); n != 3 || cpu.PC != 0x204 {
+		t.Errorf("Expected to stop after the store, got %d instructions at 0x%X", n, cpu.PC)
+	}
+	if _, a, _ := cpu.Watchpoint(); a != Store {
+		t.Errorf("Expected a store to be reported")
+	}
+
+	cpu.ClearWatchpoint(0x300)
+	if n := cpu.Run(10); n != 10 || cpu.AtBreakpoint() {
+		t.Errorf("Expected no stop after clearing the watchpoint, got %d", n)
+	}
+	if _, _, ok := cpu.Watchpoint(); ok {
+		t.Errorf("Expected no watchpoint to be reported")
+	}
+}
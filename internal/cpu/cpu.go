@@ -3,8 +3,8 @@ package cpu
 import (
 	"fmt"
 	"math/rand"
-
 	"os"
+	"time"
 
 	"github.com/jsutcodes/chip8-goemu/internal/display"
 	"github.com/jsutcodes/chip8-goemu/internal/input"
@@ -13,20 +13,21 @@ import (
 
 var logFile *os.File
 
-func init() {
-	var err error
-	logFile, err = os.Create("cpu.out")
-	if err != nil {
-		panic(err)
-	}
-}
-
+// log appends to cpu.out, which is only created once something is logged.
 func log(format string, v ...interface{}) {
+	if logFile == nil {
+		var err error
+		logFile, err = os.Create("cpu.out")
+		if err != nil {
+			panic(err)
+		}
+	}
 	fmt.Fprintf(logFile, format, v...)
 }
 
 type CPU struct {
 	CycleCount int
+	DrawCount  int        // Number of DXYN instructions executed
 	V          [16]byte   // General purpose registers (V0 to VF)
 	I          uint16     // Index register
 	PC         uint16     // Program counter
@@ -34,9 +35,28 @@ type CPU struct {
 	Stack      [16]uint16 // Stack
 	DT         byte       // Delay timer
 	ST         byte       // Sound timer
+	Flags      [16]byte   // RPL user flags, saved by FX75 and read by FX85
 	RAM        *memory.Memory
 	Display    *display.Display
 	Input      *input.Keypad
+	Engine     Engine // How Run executes instructions
+	Quirks     Quirks // Platform-specific behaviour
+	// MachineCode, if set, runs the machine code routine at address for
+	// 0NNN. Without it 0NNN is treated as an unknown opcode.
+	MachineCode func(c *CPU, address uint16)
+	// FlagsChanged, if set, is called after FX75 saves to the user flags.
+	FlagsChanged func(c *CPU)
+	rng          *rand.Rand
+	blocks       *blockCache
+	breakpoints  map[uint16]bool
+	watchpoints  map[uint16]uint8 // the accesses watched, as bits 1<<Access
+	watchHit     *watchHit        // the watchpoint the current Run stopped at
+	executeHooks []func(c *CPU, pc uint16)
+	accessHooks  []func(c *CPU, a Access, address uint16)
+	atBreakpoint bool // the last Run stopped at a breakpoint
+
+	vblank           bool // a frame has started since the last draw
+	waitingForVBlank bool // a draw is held back until the next frame
 }
 
 // Opcode Table:
@@ -74,26 +94,56 @@ type CPU struct {
 // | 0xFX18 | Set the sound timer to VX                                                   |
 // | 0xFX1E | Add VX to I                                                                 |
 // | 0xFX29 | Set I to the location of the sprite for the character in VX                 |
+// | 0xFX30 | Set I to the location of the large sprite for the digit in VX (SUPER-CHIP)  |
 // | 0xFX33 | Store the binary-coded decimal representation of VX at the addresses I, I+1, and I+2 |
 // | 0xFX55 | Store V0 to VX in memory starting at address I                              |
 // | 0xFX65 | Fill V0 to VX with values from memory starting at address I                 |
+// | 0xFX75 | Store V0 to VX in the RPL user flags (SUPER-CHIP)                           |
+// | 0xFX85 | Fill V0 to VX from the RPL user flags (SUPER-CHIP)                          |
 
 func NewCPU(RAM *memory.Memory, Display *display.Display, Input *input.Keypad) *CPU {
 	return &CPU{
-		PC:      0x200, // Program counter starts at 0x200
+		PC:      RAM.Map().Entry,
 		RAM:     RAM,
 		Display: Display,
 		Input:   Input,
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
+// addrMask wraps computed addresses into the address space, the way the
+// 12-bit address bus of the original machine did for its 4kb.
+func (c *CPU) addrMask() uint16 {
+	return c.RAM.Mask()
+}
+
+// Reset puts the program counter back at the entry point of the memory map
+// and clears the registers, stack and timers. Memory is left alone.
+func (c *CPU) Reset() {
+	c.V = [16]byte{}
+	c.I = 0
+	c.PC = c.RAM.Map().Entry
+	c.SP = 0
+	c.Stack = [16]uint16{}
+	c.DT = 0
+	c.ST = 0
+	c.vblank = false
+	c.waitingForVBlank = false
+}
+
+// Seed makes CXNN produce a repeatable sequence of random numbers.
+func (c *CPU) Seed(seed int64) {
+	c.rng.Seed(seed)
+}
+
 func (c *CPU) fetch() uint16 {
+	c.PC &= c.addrMask()
 	byte1, _ := c.RAM.ReadByte(c.PC)
 	// if err1 != nil {
 	// 	// handle error
 	// 	return 0
 	// }
-	byte2, _ := c.RAM.ReadByte(c.PC + 1)
+	byte2, _ := c.RAM.ReadByte((c.PC + 1) & c.addrMask())
 	// if err2 != nil {
 	// 	// handle error
 	// 	return 0
@@ -102,304 +152,9 @@ func (c *CPU) fetch() uint16 {
 }
 
 func (c *CPU) decodeAndExecute(opcode uint16) {
-	// Decode opcode
-	log(">>>>>>>>>>>>>>>>>>>>>>>>>>>>>>")
-	nibble := opcode & 0xF000 // get the top 4 bits
-
-	//log("nip: 0x%X\n", opcode)
-	switch nibble {
-	case 0x0000:
-		// log("Entering 0x0000")
-		switch opcode & 0x000F {
-		case 0x0000:
-			log("Clearing display\n")
-			c.Display.Clear()
-			c.PC += 2
-		case 0x000E:
-			log("Returning from subroutine")
-			// Return from a subroutine
-			c.SP--
-			c.PC = c.Stack[c.SP]
-			c.PC += 2
-		default:
-			log("Unknown opcode: 0x%X\n", opcode)
-		}
-	case 0x1000:
-		// Jump to address NNN
-		c.PC = opcode & 0x0FFF
-	case 0x2000:
-		// Call subroutine at NNN
-		c.Stack[c.SP] = c.PC
-		c.SP++
-		c.PC = opcode & 0x0FFF
-	case 0x3000:
-		// Skip next instruction if VX equals NN
-		val := opcode & 0x00FF        // get lower 8 bits
-		reg := (opcode & 0x0F00) >> 8 // get X
-		c.PC += 2                     // next instruction
-		if c.V[reg] == byte(val) {
-			c.PC += 2 // skip this instruction
-		}
-	case 0x4000:
-		// Skip next instruction if VX doesn't equal NN
-		val := opcode & 0x00FF        // get lower 8 bits
-		reg := (opcode & 0x0F00) >> 8 // get X
-		c.PC += 2                     // next instruction
-		if c.V[reg] != byte(val) {
-			c.PC += 2 // skip this instruction
-		}
-	case 0x5000:
-		// Skip next instruction if VX equals VY
-		reg1 := (opcode & 0x0F00) >> 8 // get X
-		reg2 := (opcode & 0x00F0) >> 4 // get Y
-		c.PC += 2                      // next instruction
-		if c.V[reg1] == c.V[reg2] {
-			c.PC += 2 // skip this instruction
-		}
-	case 0x6000:
-		// Set VX to NN
-		val := opcode & 0x00FF        // get lower 8 bits
-		reg := (opcode & 0x0F00) >> 8 // get X
-		c.V[reg] = byte(val)
-		c.PC += 2 // next instruction
-	case 0x7000:
-		// Add NN to VX
-		val := opcode & 0x00FF        // get lower 8 bits
-		reg := (opcode & 0x0F00) >> 8 // get X
-		c.V[reg] += byte(val)
-		c.PC += 2 // next instruction
-	case 0x8000:
-		switch opcode & 0x000F {
-		case 0x0000:
-			// Set VX to the value of VY
-			reg1 := (opcode & 0x0F00) >> 8 // get X
-			reg2 := (opcode & 0x00F0) >> 4 // get Y
-			c.V[reg1] = c.V[reg2]
-			c.PC += 2 // next instruction
-		case 0x0001:
-			// Set VX to VX OR VY
-			reg1 := (opcode & 0x0F00) >> 8 // get X
-			reg2 := (opcode & 0x00F0) >> 4 // get Y
-			c.V[reg1] |= c.V[reg2]
-			c.PC += 2 // next instruction
-
-		case 0x0002:
-			// Set VX to VX AND VY
-			reg1 := (opcode & 0x0F00) >> 8 // get X
-			reg2 := (opcode & 0x00F0) >> 4 // get Y
-			c.V[reg1] &= c.V[reg2]
-			c.PC += 2 // next instruction
-
-		case 0x0003:
-			// Set VX to VX XOR VY
-			reg1 := (opcode & 0x0F00) >> 8 // get X
-			reg2 := (opcode & 0x00F0) >> 4 // get Y
-			c.V[reg1] ^= c.V[reg2]
-			c.PC += 2 // next instruction
-
-		case 0x0004:
-			// Add VY to VX
-			reg1 := (opcode & 0x0F00) >> 8 // get X
-			reg2 := (opcode & 0x00F0) >> 4 // get Y
-			sum := uint16(c.V[reg1]) + uint16(c.V[reg2])
-			c.V[0xF] = 0 // reset carry flag
-			if sum > 0xFF {
-				c.V[0xF] = 1 // set carry flag
-			}
-			c.V[reg1] = byte(sum)
-			c.PC += 2 // next instruction
-
-		case 0x0005:
-			// Subtract VY from VX
-			reg1 := (opcode & 0x0F00) >> 8 // get X
-			reg2 := (opcode & 0x00F0) >> 4 // get Y
-			c.V[0xF] = 0                   // reset borrow flag
-			if c.V[reg1] > c.V[reg2] {
-				c.V[0xF] = 1 // set borrow flag
-			}
-			c.V[reg1] -= c.V[reg2]
-			c.PC += 2 // next instruction
-
-		case 0x0006:
-			// Shift VX right by one
-			reg := (opcode & 0x0F00) >> 8 // get X
-			c.V[0xF] = c.V[reg] & 0x1     // store least significant bit in VF
-			c.V[reg] >>= 1
-			c.PC += 2 // next instruction
-
-		case 0x0007:
-			// Set VX to VY minus VX
-			reg1 := (opcode & 0x0F00) >> 8 // get X
-			reg2 := (opcode & 0x00F0) >> 4 // get Y
-			c.V[0xF] = 0                   // reset borrow flag
-			if c.V[reg2] > c.V[reg1] {
-				c.V[0xF] = 1 // set borrow flag
-			}
-			c.V[reg1] = c.V[reg2] - c.V[reg1]
-			c.PC += 2 // next instruction
-
-		case 0x000E:
-			// Shift VX left by one
-			reg := (opcode & 0x0F00) >> 8     // get X
-			c.V[0xF] = (c.V[reg] & 0x80) >> 7 // store most significant bit in VF
-			c.V[reg] <<= 1
-			c.PC += 2 // next instruction
-
-		default:
-			log("Unknown opcode: 0x%X\n", opcode)
-
-		}
-	case 0x9000:
-		// Skip next instruction if VX doesn't equal VY
-		reg1 := (opcode & 0x0F00) >> 8 // get X
-		reg2 := (opcode & 0x00F0) >> 4 // get Y
-		c.PC += 2                      // next instruction
-		if c.V[reg1] != c.V[reg2] {
-			c.PC += 2 // skip this instruction
-		}
-
-	case 0xA000:
-		// Set I to the address NNN
-		c.I = opcode & 0x0FFF
-		c.PC += 2 // next instruction
-
-	case 0xB000:
-		// Jump to the address NNN plus V0
-		c.PC = (opcode & 0x0FFF) + uint16(c.V[0])
-
-	case 0xC000:
-		// Set VX to a random number and NN
-		val := opcode & 0x00FF        // get lower 8 bits
-		reg := (opcode & 0x0F00) >> 8 // get X
-		c.V[reg] = byte(rand.Intn(256)) & byte(val)
-		c.PC += 2 // next instruction
-
-	case 0xD000:
-		log("Entering Draw")
-		// Draw a sprite at coordinate (VX, VY) that has a width of 8 pixels and a height of N pixels
-		x := c.V[(opcode&0x0F00)>>8]
-		y := c.V[(opcode&0x00F0)>>4]
-		height := opcode & 0x000F
-		c.V[0xF] = 0
-		for yline := uint16(0); yline < height; yline++ {
-			pixel, _ := c.RAM.ReadByte(c.I + yline)
-			for xline := uint16(0); xline < 8; xline++ {
-				if (pixel & (0x80 >> xline)) != 0 {
-					if c.Display.IsPixelOn(int(uint16(x)+xline), int(uint16(y)+yline)) { // there is a check for 1 here, not sure if this is correct
-						c.V[0xF] = 1
-					}
-					c.Display.SetPixel(int(uint16(x)+xline), int(uint16(y)+yline), true)
-				}
-			}
-		}
-		c.PC += 2 // next instruction
-
-	case 0xE000:
-		log("Entering E0")
-		switch opcode & 0x00FF {
-		case 0x009E:
-			// Skip next instruction if the key stored in VX is pressed
-			reg := (opcode & 0x0F00) >> 8 // get X
-			if c.Input.IsKeyPressed(c.V[reg]) {
-				c.PC += 4 // skip next instruction
-			} else {
-				c.PC += 2 // next instruction
-			}
-
-		case 0x00A1:
-			// Skip next instruction if the key stored in VX isn't pressed
-			reg := (opcode & 0x0F00) >> 8 // get X
-			if !c.Input.IsKeyPressed(c.V[reg]) {
-				c.PC += 4 // skip next instruction
-			} else {
-				c.PC += 2 // next instruction
-			}
-
-		default:
-			log("Unknown opcode: 0x%X\n", opcode)
-
-		}
-
-	case 0xF000:
-		switch opcode & 0x00FF {
-		case 0x0007:
-			// Set VX to the value of the delay timer
-			reg := (opcode & 0x0F00) >> 8 // get X
-			c.V[reg] = c.DT
-			c.PC += 2 // next instruction
-
-		case 0x000A:
-			// Wait for a key press and store the result in VX
-			reg := (opcode & 0x0F00) >> 8 // get X
-			key := c.Input.WaitForKeyPress()
-			c.V[reg] = key
-			c.PC += 2 // next instruction
-
-		case 0x0015:
-			// Set the delay timer to VX
-			reg := (opcode & 0x0F00) >> 8 // get X
-			c.DT = c.V[reg]
-			c.PC += 2 // next instruction
-
-		case 0x0018:
-			// Set the sound timer to VX
-			reg := (opcode & 0x0F00) >> 8 // get X
-			c.ST = c.V[reg]
-			c.PC += 2 // next instruction
-
-		case 0x001E:
-			// Add VX to I
-			reg := (opcode & 0x0F00) >> 8 // get X
-			c.I += uint16(c.V[reg])
-			c.PC += 2 // next instruction
-
-		case 0x0029:
-			// Set I to the location of the sprite for the character in VX
-			reg := (opcode & 0x0F00) >> 8 // get X
-			c.I = uint16(c.V[reg]) * 5    // each character is 5 bytes long
-			c.PC += 2                     // next instruction
-
-		case 0x0033:
-			// Store the binary-coded decimal representation of VX at the addresses I, I+1, and I+2
-			reg := (opcode & 0x0F00) >> 8 // get X
-			value := c.V[reg]
-			c.RAM.WriteByte(c.I, value/100)
-			c.RAM.WriteByte(c.I+1, (value/10)%10)
-			c.RAM.WriteByte(c.I+2, (value%100)%10)
-			c.PC += 2 // next instruction
-
-		case 0x0055:
-			// Store V0 to VX in memory starting at address I
-			reg := (opcode & 0x0F00) >> 8 // get X
-			for i := uint16(0); i <= reg; i++ {
-				c.RAM.WriteByte(c.I+i, c.V[i])
-			}
-			c.PC += 2 // next instruction
-
-		case 0x0065:
-			// Fill V0 to VX with values from memory starting at address I
-			reg := (opcode & 0x0F00) >> 8 // get X
-			for i := uint16(0); i <= reg; i++ {
-				value, _ := c.RAM.ReadByte(c.I + i)
-				c.V[i] = value
-			}
-			c.I = c.I + reg + 1
-			c.PC += 2 // next instruction
-
-		default:
-			log("Unknown opcode: 0x%X\n", opcode)
-
-		}
-
-	default:
-		log("Unknown opcode: 0x%X\n", opcode)
-
-	}
-	//log("Reach end of decode and execute. Exiting...")
-	log("Opcode: 0x%X\n", opcode)
-	printState(c)
-	// os.Exit(1)
-	// timer update
+	in := &dispatch[opcode]
+	in.exec(c, in)
+	c.PC &= c.addrMask()
 }
 
 func printState(c *CPU) {
@@ -410,15 +165,21 @@ func printState(c *CPU) {
 	log("ST: 0x%X\n", c.ST)
 }
 
+// Cycle fetches and executes one instruction. With verbose set, the
+// instruction and the resulting state are traced to cpu.out.
 func (c *CPU) Cycle(verbose bool, RAM *memory.Memory) {
 	c.CycleCount++
-	log("Cycle: %d\n", c.CycleCount)
-	log("<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<")
 	opcode := c.fetch()
-	log("Opcode: 0x%X\n", opcode)
+	pc := c.PC
 	if verbose {
-		printState(c)
+		log("Cycle: %d\n", c.CycleCount)
+		log("Opcode: 0x%X\n", opcode)
 	}
 	c.decodeAndExecute(opcode)
-	log("Exiting Decode and Execute")
+	if len(c.executeHooks) > 0 {
+		c.executed(pc)
+	}
+	if verbose {
+		printState(c)
+	}
 }
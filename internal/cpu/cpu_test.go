@@ -1,6 +1,7 @@
 package cpu
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/jsutcodes/chip8-goemu/internal/display"
@@ -57,7 +58,6 @@ func TestOpcodeANNN(t *testing.T) {
 	}
 }
 
-// TODO: Fix this, test fails
 func TestOpcodeDXYN(t *testing.T) {
 	cpu := setup()
 	cpu.V[0] = 0
@@ -73,6 +73,20 @@ func TestOpcodeDXYN(t *testing.T) {
 	}
 }
 
+func TestOpcodeDXYNErasesAndSetsVF(t *testing.T) {
+	cpu := setup()
+	cpu.I = 0x300
+	cpu.RAM.WriteByte(0x300, 0xC0)
+	cpu.decodeAndExecute(0xD011)
+	cpu.decodeAndExecute(0xD011)
+	if cpu.Display.IsPixelOn(0, 0) || cpu.Display.IsPixelOn(1, 0) {
+		t.Errorf("Expected drawing the same sprite twice to erase it")
+	}
+	if cpu.V[0xF] != 1 {
+		t.Errorf("Expected VF to be 1 after erasing, got 0x%X", cpu.V[0xF])
+	}
+}
+
 func TestOpcodeFX1E(t *testing.T) {
 	cpu := setup()
 	cpu.I = 0x100
@@ -94,3 +108,159 @@ func TestOpcodeFX65(t *testing.T) {
 		t.Errorf("Expected V0, V1, V2 to be 0x01, 0x02, 0x03, got 0x%X, 0x%X, 0x%X", cpu.V[0], cpu.V[1], cpu.V[2])
 	}
 }
+
+func TestOpcodeFX29UsesFontAddress(t *testing.T) {
+	layout := memory.DefaultMap
+	layout.Font = 0x100
+	cpu := NewCPU(memory.NewMemoryWithMap(layout), display.NewDisplay(), input.NewKeypad())
+	cpu.V[2] = 0xA
+	cpu.decodeAndExecute(0xF229)
+	if cpu.I != 0x100+0xA*5 {
+		t.Errorf("Expected I to be 0x%X, got 0x%X", 0x100+0xA*5, cpu.I)
+	}
+}
+
+func TestOpcodeFX30(t *testing.T) {
+	cpu := setup()
+	cpu.V[1] = 3
+	cpu.decodeAndExecute(0xF130)
+	if cpu.I != 0x50+3*10 || cpu.PC != 0x202 {
+		t.Errorf("Expected I at large digit 3, got I 0x%X, PC 0x%X", cpu.I, cpu.PC)
+	}
+
+	vip := NewCPU(memory.NewMemoryWithMap(memory.VIPMap), display.NewDisplay(), input.NewKeypad())
+	vip.decodeAndExecute(0xF130)
+	if vip.PC != 0x200 {
+		t.Errorf("Expected FX30 to be unknown without a large font, got PC 0x%X", vip.PC)
+	}
+}
+
+func TestEntryPoint(t *testing.T) {
+	cpu := NewCPU(memory.NewMemoryWithMap(memory.ETI660Map), display.NewDisplay(), input.NewKeypad())
+	if cpu.PC != 0x600 {
+		t.Errorf("Expected PC to start at 0x600, got 0x%X", cpu.PC)
+	}
+	cpu.PC = 0x700
+	cpu.V[1] = 3
+	cpu.Reset()
+	if cpu.PC != 0x600 || cpu.V[1] != 0 {
+		t.Errorf("Expected Reset to return to 0x600 with clear registers, got PC 0x%X, V1 %d", cpu.PC, cpu.V[1])
+	}
+}
+
+func TestAddressesWrapAt64K(t *testing.T) {
+	cpu := NewCPU(memory.NewMemoryWithMap(memory.XOCHIPMap), display.NewDisplay(), input.NewKeypad())
+	cpu.I = 0xFFFF
+	cpu.V[0] = 2
+	cpu.decodeAndExecute(0xF01E)
+	if cpu.I != 0x0001 {
+		t.Errorf("Expected I to wrap to 0x0001, got 0x%X", cpu.I)
+	}
+	cpu.I = 0x1234
+	cpu.decodeAndExecute(0xF01E)
+	if cpu.I != 0x1236 {
+		t.Errorf("Expected I above 4kb to be kept, got 0x%X", cpu.I)
+	}
+}
+
+func TestOpcodeFX33WrapsAddress(t *testing.T) {
+	cpu := setup()
+	cpu.I = 0xFFF
+	cpu.V[0] = 123
+	cpu.decodeAndExecute(0xF033)
+	for i, want := range []byte{1, 2, 3} {
+		addr := (uint16(0xFFF) + uint16(i)) & 0xFFF
+		if got, _ := cpu.RAM.ReadByte(addr); got != want {
+			t.Errorf("Expected 0x%X at 0x%X, got 0x%X", want, addr, got)
+		}
+	}
+}
+
+func TestOpcode0NNN(t *testing.T) {
+	cpu := setup()
+	cpu.decodeAndExecute(0x0123)
+	if cpu.PC != 0x200 {
+		t.Errorf("Expected 0NNN to be ignored without machine code, got PC 0x%X", cpu.PC)
+	}
+
+	var called uint16
+	cpu.MachineCode = func(c *CPU, address uint16) {
+		called = address
+		c.V[0] = 1
+	}
+	cpu.decodeAndExecute(0x0123)
+	if called != 0x123 || cpu.V[0] != 1 || cpu.PC != 0x202 {
+		t.Errorf("Expected the machine code at 0x123 to run, got 0x%X with PC 0x%X", called, cpu.PC)
+	}
+}
+
+func TestOpcode00EEStackUnderflow(t *testing.T) {
+	cpu := setup()
+	cpu.decodeAndExecute(0x00EE)
+	if cpu.SP != 0 || cpu.PC != 0x202 {
+		t.Errorf("Expected underflow to be skipped, got SP %d PC 0x%X", cpu.SP, cpu.PC)
+	}
+}
+
+func TestOpcode2NNNStackOverflow(t *testing.T) {
+	cpu := setup()
+	for i := 0; i < 17; i++ {
+		cpu.decodeAndExecute(0x2200)
+	}
+	if int(cpu.SP) != len(cpu.Stack) {
+		t.Errorf("Expected SP to stop at %d, got %d", len(cpu.Stack), cpu.SP)
+	}
+}
+
+func TestOpcodeFX0AWaitsForKey(t *testing.T) {
+	cpu := setup()
+	cpu.decodeAndExecute(0xF30A)
+	if cpu.PC != 0x200 {
+		t.Errorf("Expected PC to stay at 0x200 while waiting, got 0x%X", cpu.PC)
+	}
+	cpu.Input.SetKeyPressed(0x7, true)
+	cpu.decodeAndExecute(0xF30A)
+	if cpu.PC != 0x202 || cpu.V[3] != 0x7 {
+		t.Errorf("Expected V3 to be 0x7 and PC 0x202, got 0x%X and 0x%X", cpu.V[3], cpu.PC)
+	}
+}
+
+// FuzzCycle runs arbitrary programs for a bounded number of cycles. Crashers
+// found by `go test -fuzz=FuzzCycle` are kept under testdata/fuzz/FuzzCycle.
+func FuzzCycle(f *testing.F) {
+	f.Add([]byte{0x00, 0xE0, 0xA2, 0x2A, 0x60, 0x0C, 0x61, 0x08, 0xD0, 0x1F, 0x12, 0x00})
+	f.Fuzz(func(t *testing.T, rom []byte) {
+		cpu := setup()
+		if err := cpu.RAM.LoadROM(rom); err != nil {
+			return
+		}
+		for i := 0; i < 1000; i++ {
+			cpu.Cycle(false, cpu.RAM)
+			if cpu.PC > 0xFFF {
+				t.Fatalf("PC out of range: 0x%X", cpu.PC)
+			}
+			if cpu.I > 0xFFF {
+				t.Fatalf("I out of range: 0x%X", cpu.I)
+			}
+			if int(cpu.SP) > len(cpu.Stack) {
+				t.Fatalf("SP out of range: %d", cpu.SP)
+			}
+		}
+
+		var saved bytes.Buffer
+		if err := cpu.SaveState(&saved); err != nil {
+			t.Fatalf("SaveState: %v", err)
+		}
+		restored := setup()
+		if err := restored.LoadState(bytes.NewReader(saved.Bytes())); err != nil {
+			t.Fatalf("LoadState: %v", err)
+		}
+		var again bytes.Buffer
+		if err := restored.SaveState(&again); err != nil {
+			t.Fatalf("SaveState after restore: %v", err)
+		}
+		if !bytes.Equal(saved.Bytes(), again.Bytes()) {
+			t.Fatalf("save state did not round-trip")
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package cpu
+
+// instruction is an opcode with its operands already pulled apart, together
+// with the handler that executes it.
+type instruction struct {
+	exec   func(c *CPU, in *instruction)
+	opcode uint16
+	x, y   byte   // register indexes from the second and third nibble
+	n      byte   // lowest nibble
+	nn     byte   // lowest byte
+	nnn    uint16 // lowest 12 bits
+	branch bool   // may jump, skip, wait or draw, so it ends a translated block
+}
+
+// dispatch holds a pre-decoded instruction for every possible opcode, so the
+// interpreter never has to decode the same opcode twice.
+var dispatch [0x10000]instruction
+
+func init() {
+	for opcode := range dispatch {
+		dispatch[opcode] = decode(uint16(opcode))
+	}
+}
+
+// decode splits an opcode into its operands and picks its handler. It is only
+// called to fill the dispatch table.
+func decode(opcode uint16) instruction {
+	in := instruction{
+		opcode: opcode,
+		x:      byte((opcode & 0x0F00) >> 8), // get X
+		y:      byte((opcode & 0x00F0) >> 4), // get Y
+		n:      byte(opcode & 0x000F),
+		nn:     byte(opcode & 0x00FF),
+		nnn:    opcode & 0x0FFF,
+		exec:   opUnknown,
+		branch: true,
+	}
+	// straight assigns a handler that always falls through to the next
+	// instruction, so a translated block can carry on past it.
+	straight := func(exec func(c *CPU, in *instruction)) {
+		in.exec = exec
+		in.branch = false
+	}
+
+	switch opcode & 0xF000 { // get the top 4 bits
+	case 0x0000:
+		switch opcode {
+		case 0x00E0:
+			straight(op00E0)
+		case 0x00EE:
+			in.exec = op00EE
+		default:
+			in.exec = op0NNN
+		}
+	case 0x1000:
+		in.exec = op1NNN
+	case 0x2000:
+		in.exec = op2NNN
+	case 0x3000:
+		in.exec = op3XNN
+	case 0x4000:
+		in.exec = op4XNN
+	case 0x5000:
+		if in.n == 0 {
+			in.exec = op5XY0
+		}
+	case 0x6000:
+		straight(op6XNN)
+	case 0x7000:
+		straight(op7XNN)
+	case 0x8000:
+		switch in.n {
+		case 0x0:
+			straight(op8XY0)
+		case 0x1:
+			straight(op8XY1)
+		case 0x2:
+			straight(op8XY2)
+		case 0x3:
+			straight(op8XY3)
+		case 0x4:
+			straight(op8XY4)
+		case 0x5:
+			straight(op8XY5)
+		case 0x6:
+			straight(op8XY6)
+		case 0x7:
+			straight(op8XY7)
+		case 0xE:
+			straight(op8XYE)
+		}
+	case 0x9000:
+		if in.n == 0 {
+			in.exec = op9XY0
+		}
+	case 0xA000:
+		straight(opANNN)
+	case 0xB000:
+		in.exec = opBNNN
+	case 0xC000:
+		straight(opCXNN)
+	case 0xD000:
+		in.exec = opDXYN
+	case 0xE000:
+		switch in.nn {
+		case 0x9E:
+			in.exec = opEX9E
+		case 0xA1:
+			in.exec = opEXA1
+		}
+	case 0xF000:
+		switch in.nn {
+		case 0x07:
+			straight(opFX07)
+		case 0x0A:
+			in.exec = opFX0A
+		case 0x15:
+			straight(opFX15)
+		case 0x18:
+			straight(opFX18)
+		case 0x1E:
+			straight(opFX1E)
+		case 0x29:
+			straight(opFX29)
+		case 0x30:
+			in.exec = opFX30
+		case 0x33:
+			straight(opFX33)
+		case 0x55:
+			straight(opFX55)
+		case 0x65:
+			straight(opFX65)
+		case 0x75:
+			in.exec = opFX75
+		case 0x85:
+			in.exec = opFX85
+		}
+	}
+	return in
+}
@@ -0,0 +1,61 @@
+package cpu
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeOperands(t *testing.T) {
+	in := decode(0xD12F)
+	if in.x != 0x1 || in.y != 0x2 || in.n != 0xF || in.nn != 0x2F || in.nnn != 0x12F {
+		t.Errorf("Unexpected operands for 0xD12F: %+v", in)
+	}
+	if reflect.ValueOf(in.exec).Pointer() != reflect.ValueOf(opDXYN).Pointer() {
+		t.Errorf("Expected 0xD12F to decode to opDXYN")
+	}
+}
+
+func TestDecodeUnknown(t *testing.T) {
+	for _, opcode := range []uint16{0x5121, 0x8128, 0x9121, 0xE1FF, 0xF1FF} {
+		if reflect.ValueOf(dispatch[opcode].exec).Pointer() != reflect.ValueOf(opUnknown).Pointer() {
+			t.Errorf("Expected 0x%04X to be unknown", opcode)
+		}
+	}
+}
+
+// benchROM is a tight loop of register, arithmetic and skip instructions.
+var benchROM = []byte{
+	0x60, 0x01, // V0 = 1
+	0x71, 0x02, // V1 += 2
+	0x82, 0x14, // V2 += V1
+	0x30, 0x05, // skip if V0 == 5
+	0xA3, 0x00, // I = 0x300
+	0xF1, 0x1E, // I += V1
+	0x12, 0x00, // jump 0x200
+}
+
+func benchmarkCycles(b *testing.B, step func(c *CPU)) {
+	cpu := setup()
+	cpu.RAM.LoadROM(benchROM)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		step(cpu)
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "instr/s")
+}
+
+// BenchmarkCycle runs instructions through the dispatch table.
+func BenchmarkCycle(b *testing.B) {
+	benchmarkCycles(b, func(c *CPU) { c.Cycle(false, c.RAM) })
+}
+
+// BenchmarkCycleDecodeEachTime decodes every opcode as it is fetched, which is
+// what the interpreter did before the dispatch table.
+func BenchmarkCycleDecodeEachTime(b *testing.B) {
+	benchmarkCycles(b, func(c *CPU) {
+		c.CycleCount++
+		in := decode(c.fetch())
+		in.exec(c, &in)
+		c.PC &= c.addrMask()
+	})
+}
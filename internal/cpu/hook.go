@@ -0,0 +1,53 @@
+package cpu
+
+// Access is the way an instruction used a byte of memory as data.
+type Access int
+
+const (
+	// Load is a byte read by an instruction, such as sprite data or FX65.
+	Load Access = iota
+	// Store is a byte written by an instruction, such as FX33 or FX55.
+	Store
+)
+
+// OnExecute registers fn to be called after every instruction with the
+// address it ran from. While any are registered, Run interprets even if the
+// translator is selected.
+func (c *CPU) OnExecute(fn func(c *CPU, pc uint16)) {
+	c.executeHooks = append(c.executeHooks, fn)
+}
+
+func (c *CPU) executed(pc uint16) {
+	for _, fn := range c.executeHooks {
+		fn(c, pc)
+	}
+}
+
+// OnAccess registers fn to be called for every byte of memory an
+// instruction reads or writes as data. Fetching instructions does not count.
+func (c *CPU) OnAccess(fn func(c *CPU, a Access, address uint16)) {
+	c.accessHooks = append(c.accessHooks, fn)
+}
+
+// load reads a byte of data for an instruction.
+func (c *CPU) load(address uint16) byte {
+	value, _ := c.RAM.ReadByte(address)
+	if c.watchpoints != nil {
+		c.watch(Load, address)
+	}
+	for _, fn := range c.accessHooks {
+		fn(c, Load, address)
+	}
+	return value
+}
+
+// store writes a byte of data for an instruction.
+func (c *CPU) store(address uint16, value byte) {
+	c.RAM.WriteByte(address, value)
+	if c.watchpoints != nil {
+		c.watch(Store, address)
+	}
+	for _, fn := range c.accessHooks {
+		fn(c, Store, address)
+	}
+}
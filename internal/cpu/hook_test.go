@@ -0,0 +1,57 @@
+package cpu
+
+import "testing"
+
+func TestOnExecute(t *testing.T) {
+	for _, engine := range []Engine{Interpreter, Translator} {
+		cpu := setup()
+		cpu.Engine = engine
+		cpu.RAM.LoadROM([]byte{
+			0x60, 0x01, // V0 = 1
+			0x70, 0x01, // V0 += 1
+			0x12, 0x00, // jump 0x200
+		})
+		var ran []uint16
+		cpu.OnExecute(func(c *CPU, pc uint16) {
+			ran = append(ran, pc)
+			if pc == 0x202 {
+				c.V[0] = 9
+			}
+		})
+		cpu.Run(3)
+		if len(ran) != 3 || ran[0] != 0x200 || ran[1] != 0x202 || ran[2] != 0x204 {
+			t.Errorf("engine %d: expected hooks at 0x200, 0x202 and 0x204, got %X", engine, ran)
+		}
+		if cpu.V[0] != 9 {
+			t.Errorf("engine %d: expected the hook to set V0 after the add, got %d", engine, cpu.V[0])
+		}
+	}
+}
+
+func TestOnAccess(t *testing.T) {
+	cpu := setup()
+	cpu.RAM.LoadROM([]byte{
+		0xA3, 0x00, // I = 0x300
+		0x61, 0x07, // V1 = 7
+		0xF1, 0x55, // save V0-V1 at 0x300
+		0xA3, 0x00, // I = 0x300
+		0xF0, 0x65, // load V0 from 0x300
+		0xA3, 0x00, // I = 0x300
+		0xD0, 0x02, // draw 2 rows from 0x300
+	})
+	var loads, stores []uint16
+	cpu.OnAccess(func(c *CPU, a Access, address uint16) {
+		if a == Store {
+			stores = append(stores, address)
+		} else {
+			loads = append(loads, address)
+		}
+	})
+	cpu.Run(7)
+	if len(stores) != 2 || stores[0] != 0x300 || stores[1] != 0x301 {
+		t.Errorf("Expected stores to 0x300 and 0x301, got %X", stores)
+	}
+	if len(loads) != 3 || loads[0] != 0x300 || loads[1] != 0x300 || loads[2] != 0x301 {
+		t.Errorf("Expected loads from 0x300 by FX65 and 0x300-0x301 by the draw, got %X", loads)
+	}
+}
@@ -0,0 +1,351 @@
+package cpu
+
+import "github.com/jsutcodes/chip8-goemu/internal/font"
+
+// Instruction handlers. Each one receives the pre-decoded operands of its
+// opcode from the dispatch table, so none of them mask the opcode again.
+
+func opUnknown(c *CPU, in *instruction) {
+	log("Unknown opcode: 0x%X\n", in.opcode)
+}
+
+func op0NNN(c *CPU, in *instruction) {
+	// Call a machine code routine at NNN
+	if c.MachineCode == nil {
+		opUnknown(c, in)
+		return
+	}
+	c.PC += 2
+	c.MachineCode(c, in.nnn)
+}
+
+func op00E0(c *CPU, in *instruction) {
+	// Clear the display
+	c.Display.Clear()
+	c.PC += 2
+}
+
+func op00EE(c *CPU, in *instruction) {
+	// Return from a subroutine
+	if c.SP == 0 {
+		log("Stack underflow at 0x%X\n", c.PC)
+		c.PC += 2
+		return
+	}
+	c.SP--
+	c.PC = c.Stack[c.SP]
+	c.PC += 2
+}
+
+func op1NNN(c *CPU, in *instruction) {
+	// Jump to address NNN
+	c.PC = in.nnn
+}
+
+func op2NNN(c *CPU, in *instruction) {
+	// Call subroutine at NNN
+	if int(c.SP) >= len(c.Stack) {
+		log("Stack overflow at 0x%X\n", c.PC)
+		c.PC += 2
+		return
+	}
+	c.Stack[c.SP] = c.PC
+	c.SP++
+	c.PC = in.nnn
+}
+
+func op3XNN(c *CPU, in *instruction) {
+	// Skip next instruction if VX equals NN
+	c.PC += 2 // next instruction
+	if c.V[in.x] == in.nn {
+		c.PC += 2 // skip this instruction
+	}
+}
+
+func op4XNN(c *CPU, in *instruction) {
+	// Skip next instruction if VX doesn't equal NN
+	c.PC += 2 // next instruction
+	if c.V[in.x] != in.nn {
+		c.PC += 2 // skip this instruction
+	}
+}
+
+func op5XY0(c *CPU, in *instruction) {
+	// Skip next instruction if VX equals VY
+	c.PC += 2 // next instruction
+	if c.V[in.x] == c.V[in.y] {
+		c.PC += 2 // skip this instruction
+	}
+}
+
+func op6XNN(c *CPU, in *instruction) {
+	// Set VX to NN
+	c.V[in.x] = in.nn
+	c.PC += 2 // next instruction
+}
+
+func op7XNN(c *CPU, in *instruction) {
+	// Add NN to VX
+	c.V[in.x] += in.nn
+	c.PC += 2 // next instruction
+}
+
+func op8XY0(c *CPU, in *instruction) {
+	// Set VX to the value of VY
+	c.V[in.x] = c.V[in.y]
+	c.PC += 2 // next instruction
+}
+
+func op8XY1(c *CPU, in *instruction) {
+	// Set VX to VX OR VY
+	c.V[in.x] |= c.V[in.y]
+	if c.Quirks.Logic {
+		c.V[0xF] = 0
+	}
+	c.PC += 2 // next instruction
+}
+
+func op8XY2(c *CPU, in *instruction) {
+	// Set VX to VX AND VY
+	c.V[in.x] &= c.V[in.y]
+	if c.Quirks.Logic {
+		c.V[0xF] = 0
+	}
+	c.PC += 2 // next instruction
+}
+
+func op8XY3(c *CPU, in *instruction) {
+	// Set VX to VX XOR VY
+	c.V[in.x] ^= c.V[in.y]
+	if c.Quirks.Logic {
+		c.V[0xF] = 0
+	}
+	c.PC += 2 // next instruction
+}
+
+func op8XY4(c *CPU, in *instruction) {
+	// Add VY to VX
+	sum := uint16(c.V[in.x]) + uint16(c.V[in.y])
+	c.V[0xF] = 0 // reset carry flag
+	if sum > 0xFF {
+		c.V[0xF] = 1 // set carry flag
+	}
+	c.V[in.x] = byte(sum)
+	c.PC += 2 // next instruction
+}
+
+func op8XY5(c *CPU, in *instruction) {
+	// Subtract VY from VX
+	c.V[0xF] = 0 // reset borrow flag
+	if c.V[in.x] > c.V[in.y] {
+		c.V[0xF] = 1 // set borrow flag
+	}
+	c.V[in.x] -= c.V[in.y]
+	c.PC += 2 // next instruction
+}
+
+func op8XY6(c *CPU, in *instruction) {
+	// Shift VX right by one, after copying VY into it with the ShiftVY quirk
+	if c.Quirks.ShiftVY {
+		c.V[in.x] = c.V[in.y]
+	}
+	c.V[0xF] = c.V[in.x] & 0x1 // store least significant bit in VF
+	c.V[in.x] >>= 1
+	c.PC += 2 // next instruction
+}
+
+func op8XY7(c *CPU, in *instruction) {
+	// Set VX to VY minus VX
+	c.V[0xF] = 0 // reset borrow flag
+	if c.V[in.y] > c.V[in.x] {
+		c.V[0xF] = 1 // set borrow flag
+	}
+	c.V[in.x] = c.V[in.y] - c.V[in.x]
+	c.PC += 2 // next instruction
+}
+
+func op8XYE(c *CPU, in *instruction) {
+	// Shift VX left by one, after copying VY into it with the ShiftVY quirk
+	if c.Quirks.ShiftVY {
+		c.V[in.x] = c.V[in.y]
+	}
+	c.V[0xF] = (c.V[in.x] & 0x80) >> 7 // store most significant bit in VF
+	c.V[in.x] <<= 1
+	c.PC += 2 // next instruction
+}
+
+func op9XY0(c *CPU, in *instruction) {
+	// Skip next instruction if VX doesn't equal VY
+	c.PC += 2 // next instruction
+	if c.V[in.x] != c.V[in.y] {
+		c.PC += 2 // skip this instruction
+	}
+}
+
+func opANNN(c *CPU, in *instruction) {
+	// Set I to the address NNN
+	c.I = in.nnn
+	c.PC += 2 // next instruction
+}
+
+func opBNNN(c *CPU, in *instruction) {
+	// Jump to the address NNN plus V0, or plus VX with the Jump quirk
+	offset := c.V[0]
+	if c.Quirks.Jump {
+		offset = c.V[in.x]
+	}
+	c.PC = (in.nnn + uint16(offset)) & c.addrMask()
+}
+
+func opCXNN(c *CPU, in *instruction) {
+	// Set VX to a random number and NN
+	c.V[in.x] = byte(c.rng.Intn(256)) & in.nn
+	c.PC += 2 // next instruction
+}
+
+func opDXYN(c *CPU, in *instruction) {
+	// Draw a sprite at coordinate (VX, VY) that has a width of 8 pixels and a height of N pixels
+	if c.Quirks.DisplayWait && !c.vblank {
+		c.waitingForVBlank = true // leave PC alone and draw after the next VBlank
+		return
+	}
+	c.vblank = false
+	c.DrawCount++
+	var rows [15]byte
+	for i := range rows[:in.n] {
+		rows[i] = c.load((c.I + uint16(i)) & c.addrMask())
+	}
+	c.V[0xF] = 0
+	if c.Display.DrawSprite(int(c.V[in.x]), int(c.V[in.y]), rows[:in.n]) {
+		c.V[0xF] = 1 // a pixel was switched off
+	}
+	c.PC += 2 // next instruction
+}
+
+func opEX9E(c *CPU, in *instruction) {
+	// Skip next instruction if the key stored in VX is pressed
+	if c.Input.IsKeyPressed(c.V[in.x]) {
+		c.PC += 4 // skip next instruction
+	} else {
+		c.PC += 2 // next instruction
+	}
+}
+
+func opEXA1(c *CPU, in *instruction) {
+	// Skip next instruction if the key stored in VX isn't pressed
+	if !c.Input.IsKeyPressed(c.V[in.x]) {
+		c.PC += 4 // skip next instruction
+	} else {
+		c.PC += 2 // next instruction
+	}
+}
+
+func opFX07(c *CPU, in *instruction) {
+	// Set VX to the value of the delay timer
+	c.V[in.x] = c.DT
+	c.PC += 2 // next instruction
+}
+
+func opFX0A(c *CPU, in *instruction) {
+	// Wait for a key press and store the result in VX
+	if key, ok := c.Input.PressedKey(); ok {
+		c.V[in.x] = key
+		c.PC += 2 // next instruction
+	} // otherwise leave PC alone so the wait repeats next cycle
+}
+
+func opFX15(c *CPU, in *instruction) {
+	// Set the delay timer to VX
+	c.DT = c.V[in.x]
+	c.PC += 2 // next instruction
+}
+
+func opFX18(c *CPU, in *instruction) {
+	// Set the sound timer to VX
+	c.ST = c.V[in.x]
+	c.PC += 2 // next instruction
+}
+
+func opFX1E(c *CPU, in *instruction) {
+	// Add VX to I
+	c.I = (c.I + uint16(c.V[in.x])) & c.addrMask()
+	c.PC += 2 // next instruction
+}
+
+func opFX29(c *CPU, in *instruction) {
+	// Set I to the location of the sprite for the character in VX
+	c.I = c.RAM.Map().Font + font.SmallAt(c.V[in.x])
+	c.PC += 2 // next instruction
+}
+
+func opFX30(c *CPU, in *instruction) {
+	// Set I to the location of the large sprite for the character in VX
+	large := c.RAM.Map().LargeFont
+	if large == 0 {
+		opUnknown(c, in)
+		return
+	}
+	c.I = large + font.LargeAt(c.V[in.x])
+	c.PC += 2
+}
+
+func opFX33(c *CPU, in *instruction) {
+	// Store the binary-coded decimal representation of VX at the addresses I, I+1, and I+2
+	value := c.V[in.x]
+	c.store(c.I, value/100)
+	c.store((c.I+1)&c.addrMask(), (value/10)%10)
+	c.store((c.I+2)&c.addrMask(), (value%100)%10)
+	c.PC += 2 // next instruction
+}
+
+func opFX55(c *CPU, in *instruction) {
+	// Store V0 to VX in memory starting at address I
+	for i := uint16(0); i <= uint16(in.x); i++ {
+		c.store((c.I+i)&c.addrMask(), c.V[i])
+	}
+	c.I = (c.I + c.Quirks.memoryIncrement(in.x, false)) & c.addrMask()
+	c.PC += 2 // next instruction
+}
+
+func opFX65(c *CPU, in *instruction) {
+	// Fill V0 to VX with values from memory starting at address I
+	for i := uint16(0); i <= uint16(in.x); i++ {
+		value := c.load((c.I + i) & c.addrMask())
+		c.V[i] = value
+	}
+	c.I = (c.I + c.Quirks.memoryIncrement(in.x, true)) & c.addrMask()
+	c.PC += 2 // next instruction
+}
+
+// userFlags returns how many of V0 to VX FX75 and FX85 copy, which is capped
+// by the number of flags the platform has.
+func (c *CPU) userFlags(x byte) int {
+	n := int(x) + 1
+	if n > c.Quirks.UserFlags {
+		n = c.Quirks.UserFlags
+	}
+	return n
+}
+
+func opFX75(c *CPU, in *instruction) {
+	// Save V0 to VX in the RPL user flags
+	if c.Quirks.UserFlags == 0 {
+		opUnknown(c, in)
+		return
+	}
+	copy(c.Flags[:c.userFlags(in.x)], c.V[:])
+	if c.FlagsChanged != nil {
+		c.FlagsChanged(c)
+	}
+	c.PC += 2
+}
+
+func opFX85(c *CPU, in *instruction) {
+	// Load V0 to VX from the RPL user flags
+	if c.Quirks.UserFlags == 0 {
+		opUnknown(c, in)
+		return
+	}
+	copy(c.V[:c.userFlags(in.x)], c.Flags[:])
+	c.PC += 2
+}
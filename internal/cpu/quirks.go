@@ -0,0 +1,58 @@
+package cpu
+
+// Quirks selects between behaviours that differ across CHIP-8
+// implementations. The zero value is the behaviour most modern ROMs expect.
+type Quirks struct {
+	// DisplayWait makes DXYN wait for the next vertical blank before drawing,
+	// as on the COSMAC VIP, which limits programs to 60 sprites a second.
+	DisplayWait bool
+	// UserFlags is how many HP48 RPL user flags FX75 and FX85 can reach: 8
+	// on SUPER-CHIP, 16 on XO-CHIP. With none they are unknown opcodes.
+	UserFlags int
+
+	// ShiftVY makes 8XY6 and 8XYE shift VY into VX, as the COSMAC VIP and
+	// XO-CHIP do. Otherwise VX is shifted in place and VY is ignored.
+	ShiftVY bool
+	// MemoryIncrement makes FX55 advance I past the registers it stores, as
+	// FX65 always does, so both leave I at I+X+1 as on the COSMAC VIP.
+	// Otherwise FX55 leaves I alone.
+	MemoryIncrement bool
+	// MemoryIncrementByX leaves I at I+X after FX55 and FX65, as CHIP-48 and
+	// SUPER-CHIP 1.0 do.
+	MemoryIncrementByX bool
+	// MemoryLeaveIUnchanged leaves I alone after FX55 and FX65, as
+	// SUPER-CHIP 1.1 does.
+	MemoryLeaveIUnchanged bool
+	// Jump makes BNNN jump to NNN plus VX, X being the top digit of NNN, as
+	// SUPER-CHIP does, instead of NNN plus V0.
+	Jump bool
+	// Logic makes 8XY1, 8XY2 and 8XY3 reset VF, as the COSMAC VIP does.
+	Logic bool
+}
+
+// memoryIncrement is how far FX55, or FX65 if load is set, moves I for
+// registers V0 to VX.
+func (q Quirks) memoryIncrement(x byte, load bool) uint16 {
+	switch {
+	case q.MemoryLeaveIUnchanged:
+		return 0
+	case q.MemoryIncrementByX:
+		return uint16(x)
+	case load || q.MemoryIncrement:
+		return uint16(x) + 1
+	}
+	return 0
+}
+
+// VBlank tells the CPU a new 60 Hz frame has started. A draw held back by
+// the DisplayWait quirk goes ahead on the next instruction.
+func (c *CPU) VBlank() {
+	c.vblank = true
+	c.waitingForVBlank = false
+}
+
+// WaitingForVBlank reports whether the CPU is stalled on a draw until the
+// next frame. Run stops early when this happens.
+func (c *CPU) WaitingForVBlank() bool {
+	return c.waitingForVBlank
+}
@@ -0,0 +1,132 @@
+package cpu
+
+import "testing"
+
+func TestDisplayWaitQuirk(t *testing.T) {
+	for _, engine := range []Engine{Interpreter, Translator} {
+		cpu := setup()
+		cpu.Engine = engine
+		cpu.Quirks.DisplayWait = true
+		cpu.RAM.LoadROM([]byte{
+			0xD0, 0x01, // draw
+			0xD0, 0x01, // draw
+			0x12, 0x04, // jump to self
+		})
+
+		cpu.VBlank()
+		if n := cpu.Run(10); n != 2 || cpu.DrawCount != 1 || !cpu.WaitingForVBlank() {
+			t.Errorf("engine %d: expected to stop at the second draw after 2 instructions, ran %d with %d draws", engine, n, cpu.DrawCount)
+		}
+		if cpu.PC != 0x202 {
+			t.Errorf("engine %d: expected PC to stay on the waiting draw, got 0x%X", engine, cpu.PC)
+		}
+
+		cpu.VBlank()
+		cpu.Run(10)
+		if cpu.DrawCount != 2 || cpu.WaitingForVBlank() {
+			t.Errorf("engine %d: expected the second draw after VBlank, got %d draws", engine, cpu.DrawCount)
+		}
+	}
+}
+
+func TestDisplayWaitOff(t *testing.T) {
+	cpu := setup()
+	cpu.RAM.LoadROM([]byte{0xD0, 0x01, 0xD0, 0x01, 0x12, 0x04})
+	cpu.Run(3)
+	if cpu.DrawCount != 2 {
+		t.Errorf("Expected both draws without the quirk, got %d", cpu.DrawCount)
+	}
+}
+
+func TestUserFlags(t *testing.T) {
+	cpu := setup()
+	cpu.decodeAndExecute(0xF775)
+	if cpu.PC != 0x200 {
+		t.Errorf("Expected FX75 to be unknown without user flags, got PC 0x%X", cpu.PC)
+	}
+
+	cpu.Quirks.UserFlags = 8
+	changed := 0
+	cpu.FlagsChanged = func(c *CPU) { changed++ }
+	for i := range cpu.V {
+		cpu.V[i] = byte(i + 1)
+	}
+	cpu.decodeAndExecute(0xFF75) // only 8 flags to save into
+	if changed != 1 || cpu.Flags[7] != 8 || cpu.Flags[8] != 0 {
+		t.Errorf("Expected V0-V7 in the flags, got %v after %d changes", cpu.Flags, changed)
+	}
+
+	cpu.V = [16]byte{}
+	cpu.decodeAndExecute(0xF285)
+	if cpu.V[0] != 1 || cpu.V[2] != 3 || cpu.V[3] != 0 {
+		t.Errorf("Expected V0-V2 from the flags, got %v", cpu.V)
+	}
+	if cpu.PC != 0x204 {
+		t.Errorf("Expected PC 0x204, got 0x%X", cpu.PC)
+	}
+}
+
+func TestShiftQuirk(t *testing.T) {
+	cpu := setup()
+	cpu.V[1], cpu.V[2] = 0x81, 0x01
+	cpu.decodeAndExecute(0x8126)
+	if cpu.V[1] != 0x40 || cpu.V[0xF] != 1 {
+		t.Errorf("Expected VX shifted in place, got 0x%X VF %d", cpu.V[1], cpu.V[0xF])
+	}
+
+	cpu.Quirks.ShiftVY = true
+	cpu.V[1], cpu.V[2] = 0x01, 0x81
+	cpu.decodeAndExecute(0x812E)
+	if cpu.V[1] != 0x02 || cpu.V[0xF] != 1 {
+		t.Errorf("Expected VY shifted into VX, got 0x%X VF %d", cpu.V[1], cpu.V[0xF])
+	}
+}
+
+func TestMemoryQuirks(t *testing.T) {
+	tests := []struct {
+		quirks      Quirks
+		store, load uint16
+	}{
+		{Quirks{}, 0x300, 0x303},
+		{Quirks{MemoryIncrement: true}, 0x303, 0x303},
+		{Quirks{MemoryIncrementByX: true}, 0x302, 0x302},
+		{Quirks{MemoryLeaveIUnchanged: true}, 0x300, 0x300},
+	}
+	for _, tt := range tests {
+		for opcode, want := range map[uint16]uint16{0xF255: tt.store, 0xF265: tt.load} {
+			cpu := setup()
+			cpu.Quirks = tt.quirks
+			cpu.I = 0x300
+			cpu.decodeAndExecute(opcode)
+			if cpu.I != want {
+				t.Errorf("%+v: expected 0x%04X to leave I at 0x%X, got 0x%X", tt.quirks, opcode, want, cpu.I)
+			}
+		}
+	}
+}
+
+func TestJumpQuirk(t *testing.T) {
+	cpu := setup()
+	cpu.V[0], cpu.V[3] = 0x10, 0x20
+	cpu.decodeAndExecute(0xB300)
+	if cpu.PC != 0x310 {
+		t.Errorf("Expected a jump to NNN+V0, got 0x%X", cpu.PC)
+	}
+	cpu.Quirks.Jump = true
+	cpu.decodeAndExecute(0xB300)
+	if cpu.PC != 0x320 {
+		t.Errorf("Expected a jump to NNN+V3, got 0x%X", cpu.PC)
+	}
+}
+
+func TestLogicQuirk(t *testing.T) {
+	for _, logic := range []bool{false, true} {
+		cpu := setup()
+		cpu.Quirks.Logic = logic
+		cpu.V[0xF] = 1
+		cpu.decodeAndExecute(0x8011)
+		if reset := cpu.V[0xF] == 0; reset != logic {
+			t.Errorf("logic %v: expected VF reset %v, got VF %d", logic, logic, cpu.V[0xF])
+		}
+	}
+}
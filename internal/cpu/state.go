@@ -0,0 +1,78 @@
+package cpu
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// state is the on-disk layout of a save state: everything needed to resume
+// a program exactly where it stopped. It is followed by the RAM, whose
+// size depends on the memory map, and then the framebuffer.
+type state struct {
+	V       [16]byte
+	I       uint16
+	PC      uint16
+	SP      byte
+	Stack   [16]uint16
+	DT      byte
+	ST      byte
+	RAMSize uint32
+}
+
+// SaveState writes the registers, stack, timers, RAM and framebuffer to w.
+func (c *CPU) SaveState(w io.Writer) error {
+	s := state{
+		V:       c.V,
+		I:       c.I,
+		PC:      c.PC,
+		SP:      c.SP,
+		Stack:   c.Stack,
+		DT:      c.DT,
+		ST:      c.ST,
+		RAMSize: uint32(c.RAM.Size()),
+	}
+	screen := c.Display.Snapshot()
+	for _, v := range []interface{}{&s, c.RAM.Snapshot(), &screen} {
+		if err := binary.Write(w, binary.BigEndian, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadState restores a state written by SaveState. The CPU is left untouched
+// if the state cannot be read or is inconsistent, including when it was saved
+// with a different amount of RAM.
+func (c *CPU) LoadState(r io.Reader) error {
+	var s state
+	if err := binary.Read(r, binary.BigEndian, &s); err != nil {
+		return err
+	}
+	if int(s.SP) > len(s.Stack) {
+		return fmt.Errorf("invalid stack pointer %d in save state", s.SP)
+	}
+	if int(s.RAMSize) != c.RAM.Size() {
+		return fmt.Errorf("save state has %d bytes of RAM, this machine has %d", s.RAMSize, c.RAM.Size())
+	}
+	if s.PC > c.addrMask() || s.I > c.addrMask() {
+		return fmt.Errorf("address out of range in save state (PC 0x%X, I 0x%X)", s.PC, s.I)
+	}
+	ram := make([]byte, s.RAMSize)
+	screen := c.Display.Snapshot()
+	for _, v := range []interface{}{ram, &screen} {
+		if err := binary.Read(r, binary.BigEndian, v); err != nil {
+			return err
+		}
+	}
+	c.V = s.V
+	c.I = s.I
+	c.PC = s.PC
+	c.SP = s.SP
+	c.Stack = s.Stack
+	c.DT = s.DT
+	c.ST = s.ST
+	c.RAM.Restore(ram)
+	c.Display.Restore(screen)
+	return nil
+}
@@ -0,0 +1,57 @@
+package cpu
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSaveLoadState(t *testing.T) {
+	cpu := setup()
+	cpu.V[3] = 0x42
+	cpu.I = 0x321
+	cpu.PC = 0x246
+	cpu.Stack[0] = 0x200
+	cpu.SP = 1
+	cpu.DT = 7
+	cpu.RAM.WriteByte(0x300, 0xAB)
+	cpu.Display.SetPixel(1, 2, true)
+
+	var buf bytes.Buffer
+	if err := cpu.SaveState(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored := setup()
+	if err := restored.LoadState(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if restored.V != cpu.V || restored.I != cpu.I || restored.PC != cpu.PC || restored.SP != cpu.SP ||
+		restored.Stack != cpu.Stack || restored.DT != cpu.DT {
+		t.Errorf("Expected registers to round-trip, got %+v", restored)
+	}
+	if value, _ := restored.RAM.ReadByte(0x300); value != 0xAB {
+		t.Errorf("Expected RAM to round-trip, got 0x%X at 0x300", value)
+	}
+	if !restored.Display.IsPixelOn(1, 2) {
+		t.Errorf("Expected framebuffer to round-trip")
+	}
+}
+
+func TestLoadStateRejectsBadStackPointer(t *testing.T) {
+	cpu := setup()
+	cpu.SP = 2
+	var buf bytes.Buffer
+	if err := cpu.SaveState(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := buf.Bytes()
+	data[16+2+2] = 17 // SP follows V, I and PC
+
+	restored := setup()
+	if err := restored.LoadState(bytes.NewReader(data)); err == nil {
+		t.Errorf("Expected an error for stack pointer 17")
+	}
+	if restored.SP != 0 {
+		t.Errorf("Expected CPU to be untouched, got SP %d", restored.SP)
+	}
+}
@@ -0,0 +1,123 @@
+package cpu
+
+// TimingModel returns how many machine cycles of the emulated hardware an
+// opcode takes to execute with the CPU in its current state.
+type TimingModel func(c *CPU, opcode uint16) int
+
+// COSMAC VIP timing. The VIP's 1802 runs at 1.7609 MHz with 8 clocks per
+// machine cycle, giving 3668 machine cycles per 60 Hz frame. The 1861 video
+// chip takes 8 of them by DMA on each of the 128 displayed lines, and the
+// interrupt routine that sets up the display and ticks the timers takes
+// some more, leaving the rest for the interpreter.
+const (
+	VIPCyclesPerFrame = 3668
+	vipDisplayDMA     = 128 * 8
+	vipInterrupt      = 46
+	// VIPCyclesForInterpreter is what is left of a frame for CHIP-8 code.
+	VIPCyclesForInterpreter = VIPCyclesPerFrame - vipDisplayDMA - vipInterrupt
+)
+
+// vipFetch is the cost of the interpreter's fetch and dispatch loop, paid by
+// every instruction on top of the cost of executing it.
+const vipFetch = 40
+
+// VIPTiming approximates the execution times of the original COSMAC VIP
+// interpreter, following Laurence Scotford's annotated disassembly of it.
+// Costs that depend on data, such as sprite height and alignment, BCD digits
+// or the number of registers stored, are computed from the current state.
+func VIPTiming(c *CPU, opcode uint16) int {
+	in := &dispatch[opcode]
+	x, y := c.V[in.x], c.V[in.y]
+	skip := func(taken bool, base int) int {
+		if taken {
+			return vipFetch + base + 4
+		}
+		return vipFetch + base
+	}
+
+	switch opcode & 0xF000 {
+	case 0x0000:
+		switch opcode {
+		case 0x00E0:
+			return vipFetch + 24 + 256*12 // clears the 256 byte display page
+		case 0x00EE:
+			return vipFetch + 10
+		}
+		return vipFetch + 26 // machine code call, not counting the routine
+	case 0x1000:
+		return vipFetch + 12
+	case 0x2000:
+		return vipFetch + 26
+	case 0x3000:
+		return skip(x == in.nn, 10)
+	case 0x4000:
+		return skip(x != in.nn, 10)
+	case 0x5000:
+		return skip(x == y, 14)
+	case 0x6000:
+		return vipFetch + 6
+	case 0x7000:
+		return vipFetch + 10
+	case 0x8000:
+		return vipFetch + 44 // built and run as a small 1802 routine on the stack
+	case 0x9000:
+		return skip(x != y, 14)
+	case 0xA000:
+		return vipFetch + 12
+	case 0xB000:
+		if (in.nnn+uint16(c.V[0]))&0xFF00 != in.nnn&0xFF00 {
+			return vipFetch + 24 // crossing a page costs an extra carry
+		}
+		return vipFetch + 22
+	case 0xC000:
+		return vipFetch + 36
+	case 0xD000:
+		// Sprites on a byte boundary are copied a byte per row; anywhere
+		// else each row is shifted across two display bytes.
+		perRow := 45
+		if x%8 == 0 {
+			perRow = 23
+		}
+		return vipFetch + 26 + int(in.n)*perRow
+	case 0xE000:
+		pressed := c.Input.IsKeyPressed(x)
+		if in.nn == 0x9E {
+			return skip(pressed, 14)
+		}
+		return skip(!pressed, 14)
+	case 0xF000:
+		switch in.nn {
+		case 0x07, 0x15, 0x18:
+			return vipFetch + 10
+		case 0x0A:
+			return vipFetch + 19
+		case 0x1E, 0x29:
+			return vipFetch + 16
+		case 0x33:
+			// Each digit is found by repeated subtraction.
+			return vipFetch + 80 + 16*int(x/100+(x/10)%10+x%10)
+		case 0x55, 0x65:
+			return vipFetch + 14 + 14*int(in.x+1)
+		}
+	}
+	return vipFetch
+}
+
+// RunCycles executes instructions until at least budget machine cycles, as
+// costed by model, have been used, and returns the cycles used. It stops
+// early if a draw has to wait for the next VBlank or PC reaches a
+// breakpoint. Timed execution always interprets, since every instruction
+// has to be costed as it runs.
+func (c *CPU) RunCycles(budget int, model TimingModel) int {
+	c.stopping()
+	used := 0
+	for used < budget {
+		c.PC &= c.addrMask()
+		used += model(c, c.fetch())
+		c.Cycle(false, c.RAM)
+		if c.waitingForVBlank || c.checkBreakpoint() {
+			break
+		}
+	}
+	return used
+}
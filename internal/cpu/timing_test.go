@@ -0,0 +1,49 @@
+package cpu
+
+import "testing"
+
+func TestVIPTiming(t *testing.T) {
+	cpu := setup()
+	cpu.V[1] = 5
+	cpu.V[2] = 8
+
+	tests := []struct {
+		name   string
+		opcode uint16
+		cycles int
+	}{
+		{"6XNN", 0x6012, vipFetch + 6},
+		{"1NNN", 0x1200, vipFetch + 12},
+		{"3XNN not taken", 0x3104, vipFetch + 10},
+		{"3XNN taken", 0x3105, vipFetch + 14},
+		{"DXYN aligned", 0xD235, vipFetch + 26 + 5*23},
+		{"DXYN unaligned", 0xD125, vipFetch + 26 + 5*45},
+		{"FX55 stores X+1 registers", 0xF355, vipFetch + 14 + 4*14},
+		{"FX33 depends on the digits", 0xF133, vipFetch + 80 + 16*5},
+	}
+	for _, tt := range tests {
+		if got := VIPTiming(cpu, tt.opcode); got != tt.cycles {
+			t.Errorf("%s: expected %d cycles, got %d", tt.name, tt.cycles, got)
+		}
+	}
+}
+
+func TestRunCycles(t *testing.T) {
+	cpu := setup()
+	cpu.RAM.LoadROM([]byte{
+		0x60, 0x01, // V0 = 1
+		0x12, 0x00, // jump 0x200
+	})
+	pair := 2*vipFetch + 6 + 12
+
+	used := cpu.RunCycles(10*pair, VIPTiming)
+	if used != 10*pair || cpu.CycleCount != 20 {
+		t.Errorf("Expected 20 instructions in %d cycles, ran %d in %d", 10*pair, cpu.CycleCount, used)
+	}
+
+	// A budget that ends mid-instruction overshoots by the rest of it.
+	used = cpu.RunCycles(1, VIPTiming)
+	if used != vipFetch+6 {
+		t.Errorf("Expected one instruction's worth of cycles, got %d", used)
+	}
+}
@@ -0,0 +1,823 @@
+// Package dap lets editors such as VS Code debug CHIP-8 programs through the
+// Debug Adapter Protocol. The adapter launches a ROM, sets breakpoints by
+// address, symbol or source line (through a source map), steps, shows the
+// registers as variables and the call stack as frames, and reads, writes and
+// disassembles memory. It drives the emulator through the debugger core, so
+// it shares breakpoints and stepping with the console.
+//
+// The program has a single thread, numbered 1. Its registers are in the
+// "Registers" scope and the return addresses on its stack in the "Stack"
+// scope of every frame.
+package dap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jsutcodes/chip8-goemu/internal/cpu"
+	"github.com/jsutcodes/chip8-goemu/internal/debugger"
+	"github.com/jsutcodes/chip8-goemu/internal/disasm"
+	"github.com/jsutcodes/chip8-goemu/internal/emulator"
+	"github.com/jsutcodes/chip8-goemu/internal/platform"
+	"github.com/jsutcodes/chip8-goemu/internal/symbols"
+)
+
+// The variables references of the two scopes.
+const (
+	registersRef = 1 + iota
+	stackRef
+)
+
+// Server is a debug adapter for one emulator, talking to one client.
+type Server struct {
+	d   *debugger.Debugger
+	emu *emulator.Emulator
+	r   *bufio.Reader
+	w   io.Writer
+
+	mu  sync.Mutex // serialises writes to w and numbering of messages
+	seq int
+
+	launched chan struct{} // closed when the program may start running
+	done     chan struct{} // closed when Serve returns
+	running  bool          // Run may be going, so use the debugger through Do
+	quit     bool          // the client disconnected
+	pending  []event       // events to send after the current response
+
+	stopOnEntry bool
+	sourceMap   *symbols.SourceMap
+	sourceDir   string // what file names in the source map are relative to
+
+	// Breakpoints the client asked for, which are all set on the CPU.
+	lineBreaks        map[string][]uint16 // by source file
+	instructionBreaks []uint16
+	functionBreaks    []uint16
+	owned             map[uint16]bool // breakpoints this adapter set
+}
+
+// NewServer returns an adapter that reads requests from r and writes
+// responses and events to w. It has to be created before the emulator
+// starts running.
+func NewServer(d *debugger.Debugger, r io.Reader, w io.Writer) *Server {
+	s := &Server{
+		d:          d,
+		emu:        d.Emulator(),
+		r:          bufio.NewReader(r),
+		w:          w,
+		launched:   make(chan struct{}),
+		done:       make(chan struct{}),
+		lineBreaks: map[string][]uint16{},
+		owned:      map[uint16]bool{},
+	}
+	d.EnableRemote()
+	d.OnStop(func(pc uint16) {
+		s.event("stopped", stopped("breakpoint"))
+	})
+	return s
+}
+
+// Launched is closed once the client has launched the program and finished
+// setting it up, when the emulator should start running.
+func (s *Server) Launched() <-chan struct{} {
+	return s.launched
+}
+
+// Exited tells the client the program has ended, then carries on serving
+// its requests until it disconnects. Call it once Run returns.
+func (s *Server) Exited() {
+	s.event("exited", map[string]int{"exitCode": 0})
+	s.event("terminated", nil)
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-time.After(10 * time.Millisecond):
+			s.emu.Poll()
+		}
+	}
+}
+
+// Serve handles requests until the client disconnects or its input ends.
+func (s *Server) Serve() error {
+	defer close(s.done)
+	for !s.quit {
+		body, err := readMessage(s.r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			return fmt.Errorf("bad message: %w", err)
+		}
+		if req.Type != "request" {
+			continue
+		}
+		s.handle(req)
+	}
+	return nil
+}
+
+// handle runs one request, answers it and then sends any events it raised.
+func (s *Server) handle(req request) {
+	resp := response{Type: "response", RequestSeq: req.Seq, Command: req.Command, Success: true}
+	handler, ok := handlers[req.Command]
+	if !ok {
+		resp.Success, resp.Message = false, fmt.Sprintf("%s is not supported", req.Command)
+	} else if body, err := handler(s, req.Arguments); err != nil {
+		resp.Success, resp.Message = false, err.Error()
+	} else {
+		resp.Body = body
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	resp.Seq = s.seq
+	writeMessage(s.w, resp)
+	for _, e := range s.pending {
+		s.seq++
+		e.Seq = s.seq
+		writeMessage(s.w, e)
+	}
+	s.pending = nil
+}
+
+// event sends an event straight away. It may be called from any goroutine.
+func (s *Server) event(name string, body any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	writeMessage(s.w, event{Seq: s.seq, Type: "event", Event: name, Body: body})
+}
+
+// later sends an event after the response to the current request.
+func (s *Server) later(name string, body any) {
+	s.pending = append(s.pending, event{Type: "event", Event: name, Body: body})
+}
+
+func stopped(reason string) map[string]any {
+	return map[string]any{"reason": reason, "threadId": 1, "allThreadsStopped": true}
+}
+
+// do runs fn with the emulator to itself: directly until the program is
+// running, and between frames after that.
+func (s *Server) do(fn func()) {
+	if s.running {
+		s.d.Do(fn)
+		return
+	}
+	fn()
+}
+
+type handler func(s *Server, args json.RawMessage) (any, error)
+
+var handlers map[string]handler
+
+func init() {
+	handlers = map[string]handler{
+		"initialize":                (*Server).initialize,
+		"launch":                    (*Server).launch,
+		"setBreakpoints":            (*Server).setBreakpoints,
+		"setInstructionBreakpoints": (*Server).setInstructionBreakpoints,
+		"setFunctionBreakpoints":    (*Server).setFunctionBreakpoints,
+		"setExceptionBreakpoints":   (*Server).setExceptionBreakpoints,
+		"configurationDone":         (*Server).configurationDone,
+		"threads":                   (*Server).threads,
+		"stackTrace":                (*Server).stackTrace,
+		"scopes":                    (*Server).scopes,
+		"variables":                 (*Server).variables,
+		"setVariable":               (*Server).setVariable,
+		"continue":                  (*Server).resume,
+		"next":                      (*Server).next,
+		"stepIn":                    (*Server).stepIn,
+		"stepOut":                   (*Server).stepOut,
+		"pause":                     (*Server).pause,
+		"readMemory":                (*Server).readMemory,
+		"writeMemory":               (*Server).writeMemory,
+		"disassemble":               (*Server).disassemble,
+		"evaluate":                  (*Server).evaluate,
+		"terminate":                 (*Server).terminate,
+		"disconnect":                (*Server).disconnect,
+	}
+}
+
+// decode unmarshals the arguments of a request, which may be absent.
+func decode(args json.RawMessage, v any) error {
+	if len(args) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(args, v); err != nil {
+		return fmt.Errorf("bad arguments: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) initialize(args json.RawMessage) (any, error) {
+	return map[string]bool{
+		"supportsConfigurationDoneRequest": true,
+		"supportsFunctionBreakpoints":      true,
+		"supportsInstructionBreakpoints":   true,
+		"supportsSetVariable":              true,
+		"supportsReadMemoryRequest":        true,
+		"supportsWriteMemoryRequest":       true,
+		"supportsDisassembleRequest":       true,
+		"supportsTerminateRequest":         true,
+	}, nil
+}
+
+func (s *Server) launch(args json.RawMessage) (any, error) {
+	var a struct {
+		Program     string `json:"program"`
+		Platform    string `json:"platform"`
+		Symbols     string `json:"symbols"`
+		SourceMap   string `json:"sourceMap"`
+		StopOnEntry bool   `json:"stopOnEntry"`
+	}
+	if err := decode(args, &a); err != nil {
+		return nil, err
+	}
+	if a.Program == "" {
+		return nil, errors.New("launch needs a program")
+	}
+	if a.Platform != "" {
+		p, ok := platform.Lookup(a.Platform)
+		if !ok {
+			return nil, fmt.Errorf("unknown platform %q", a.Platform)
+		}
+		s.emu.SetProfile(p)
+		s.emu.DetectPlatform = false
+	}
+	if err := s.emu.LoadROMFile(a.Program); err != nil {
+		return nil, fmt.Errorf("failed to load ROM: %w", err)
+	}
+	if a.Symbols != "" {
+		table, err := symbols.Open(a.Symbols)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load symbols: %w", err)
+		}
+		s.emu.Symbols = table
+	}
+	if a.SourceMap != "" {
+		m, err := symbols.OpenSourceMap(a.SourceMap)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load source map: %w", err)
+		}
+		s.sourceMap, s.sourceDir = m, filepath.Dir(a.SourceMap)
+	}
+	s.stopOnEntry = a.StopOnEntry
+	s.later("initialized", nil)
+	return nil, nil
+}
+
+// breakpoint is a breakpoint as the client sees it.
+type breakpoint struct {
+	Verified             bool   `json:"verified"`
+	Message              string `json:"message,omitempty"`
+	Line                 int    `json:"line,omitempty"`
+	InstructionReference string `json:"instructionReference,omitempty"`
+}
+
+func (s *Server) setBreakpoints(args json.RawMessage) (any, error) {
+	var a struct {
+		Source struct {
+			Path string `json:"path"`
+			Name string `json:"name"`
+		} `json:"source"`
+		Breakpoints []struct {
+			Line int `json:"line"`
+		} `json:"breakpoints"`
+	}
+	if err := decode(args, &a); err != nil {
+		return nil, err
+	}
+	file := a.Source.Path
+	if file == "" {
+		file = a.Source.Name
+	}
+	var addresses []uint16
+	result := []breakpoint{}
+	for _, b := range a.Breakpoints {
+		address, line, ok := s.sourceMap.Address(file, b.Line)
+		if !ok {
+			result = append(result, breakpoint{Line: b.Line, Message: "no code at or after this line"})
+			continue
+		}
+		addresses = append(addresses, address)
+		result = append(result, breakpoint{Verified: true, Line: line, InstructionReference: reference(address)})
+	}
+	s.lineBreaks[file] = addresses
+	s.syncBreakpoints()
+	return map[string]any{"breakpoints": result}, nil
+}
+
+func (s *Server) setInstructionBreakpoints(args json.RawMessage) (any, error) {
+	var a struct {
+		Breakpoints []struct {
+			InstructionReference string `json:"instructionReference"`
+			Offset               int    `json:"offset"`
+		} `json:"breakpoints"`
+	}
+	if err := decode(args, &a); err != nil {
+		return nil, err
+	}
+	s.instructionBreaks = nil
+	result := []breakpoint{}
+	for _, b := range a.Breakpoints {
+		address, ok := s.address(b.InstructionReference, b.Offset)
+		if !ok {
+			result = append(result, breakpoint{Message: "not an address in memory"})
+			continue
+		}
+		s.instructionBreaks = append(s.instructionBreaks, address)
+		result = append(result, breakpoint{Verified: true, InstructionReference: reference(address)})
+	}
+	s.syncBreakpoints()
+	return map[string]any{"breakpoints": result}, nil
+}
+
+// setFunctionBreakpoints sets breakpoints by name, which may be a symbol,
+// a symbol plus an offset or an address.
+func (s *Server) setFunctionBreakpoints(args json.RawMessage) (any, error) {
+	var a struct {
+		Breakpoints []struct {
+			Name string `json:"name"`
+		} `json:"breakpoints"`
+	}
+	if err := decode(args, &a); err != nil {
+		return nil, err
+	}
+	s.functionBreaks = nil
+	result := []breakpoint{}
+	for _, b := range a.Breakpoints {
+		address, ok := s.address(b.Name, 0)
+		if !ok {
+			result = append(result, breakpoint{Message: fmt.Sprintf("no symbol %s", b.Name)})
+			continue
+		}
+		s.functionBreaks = append(s.functionBreaks, address)
+		result = append(result, breakpoint{Verified: true, InstructionReference: reference(address)})
+	}
+	s.syncBreakpoints()
+	return map[string]any{"breakpoints": result}, nil
+}
+
+// setExceptionBreakpoints accepts the empty set of exception filters the
+// client sends whatever the adapter supports.
+func (s *Server) setExceptionBreakpoints(args json.RawMessage) (any, error) {
+	return nil, nil
+}
+
+// syncBreakpoints sets every breakpoint the client wants on the CPU and
+// clears those it no longer wants. Breakpoints set by other front ends are
+// left alone.
+func (s *Server) syncBreakpoints() {
+	wanted := map[uint16]bool{}
+	for _, addresses := range s.lineBreaks {
+		for _, address := range addresses {
+			wanted[address] = true
+		}
+	}
+	for _, address := range append(s.instructionBreaks, s.functionBreaks...) {
+		wanted[address] = true
+	}
+	s.do(func() {
+		for address := range s.owned {
+			if !wanted[address] {
+				s.emu.CPU.ClearBreakpoint(address)
+			}
+		}
+		for address := range wanted {
+			s.emu.CPU.SetBreakpoint(address)
+		}
+	})
+	s.owned = wanted
+}
+
+func (s *Server) configurationDone(args json.RawMessage) (any, error) {
+	if s.running {
+		return nil, nil
+	}
+	if s.stopOnEntry {
+		s.emu.Pause()
+		s.later("stopped", stopped("entry"))
+	}
+	s.running = true
+	close(s.launched)
+	return nil, nil
+}
+
+func (s *Server) threads(args json.RawMessage) (any, error) {
+	return map[string]any{"threads": []map[string]any{{"id": 1, "name": "CHIP-8"}}}, nil
+}
+
+// source is a source file as the client sees it.
+type source struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+type stackFrame struct {
+	ID                          int     `json:"id"`
+	Name                        string  `json:"name"`
+	Source                      *source `json:"source,omitempty"`
+	Line                        int     `json:"line"`
+	Column                      int     `json:"column"`
+	InstructionPointerReference string  `json:"instructionPointerReference"`
+}
+
+// stackTrace gives PC as frame 0 and the calls on the stack, innermost
+// first, as the frames after it.
+func (s *Server) stackTrace(args json.RawMessage) (any, error) {
+	var a struct {
+		StartFrame int `json:"startFrame"`
+		Levels     int `json:"levels"`
+	}
+	if err := decode(args, &a); err != nil {
+		return nil, err
+	}
+	var frames []stackFrame
+	s.do(func() {
+		c := s.emu.CPU
+		addresses := []uint16{c.PC}
+		for i := int(c.SP) - 1; i >= 0; i-- {
+			addresses = append(addresses, c.Stack[i])
+		}
+		for id, address := range addresses {
+			frames = append(frames, s.frame(id, address))
+		}
+	})
+	total := len(frames)
+	frames = frames[min(a.StartFrame, total):]
+	if a.Levels > 0 && a.Levels < len(frames) {
+		frames = frames[:a.Levels]
+	}
+	return map[string]any{"stackFrames": frames, "totalFrames": total}, nil
+}
+
+func (s *Server) frame(id int, address uint16) stackFrame {
+	f := stackFrame{ID: id, Name: s.emu.Symbols.Format(address), InstructionPointerReference: reference(address)}
+	if p, ok := s.sourceMap.Position(address); ok {
+		path := p.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(s.sourceDir, path)
+		}
+		f.Source = &source{Name: filepath.Base(p.File), Path: path}
+		f.Line, f.Column = p.Line, 1
+	}
+	return f
+}
+
+func (s *Server) scopes(args json.RawMessage) (any, error) {
+	return map[string]any{"scopes": []map[string]any{
+		{"name": "Registers", "presentationHint": "registers", "variablesReference": registersRef, "expensive": false},
+		{"name": "Stack", "variablesReference": stackRef, "expensive": false},
+	}}, nil
+}
+
+type variable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	VariablesReference int    `json:"variablesReference"`
+	MemoryReference    string `json:"memoryReference,omitempty"`
+}
+
+func (s *Server) variables(args json.RawMessage) (any, error) {
+	var a struct {
+		VariablesReference int `json:"variablesReference"`
+	}
+	if err := decode(args, &a); err != nil {
+		return nil, err
+	}
+	vars := []variable{}
+	s.do(func() {
+		c := s.emu.CPU
+		switch a.VariablesReference {
+		case registersRef:
+			for _, r := range registers {
+				vars = append(vars, r.variable(c))
+			}
+		case stackRef:
+			for i := 0; i < int(c.SP); i++ {
+				vars = append(vars, variable{Name: strconv.Itoa(i), Value: s.d.Where(c.Stack[i]), MemoryReference: reference(c.Stack[i])})
+			}
+		}
+	})
+	return map[string]any{"variables": vars}, nil
+}
+
+func (s *Server) setVariable(args json.RawMessage) (any, error) {
+	var a struct {
+		VariablesReference int    `json:"variablesReference"`
+		Name               string `json:"name"`
+		Value              string `json:"value"`
+	}
+	if err := decode(args, &a); err != nil {
+		return nil, err
+	}
+	value, err := strconv.ParseUint(strings.TrimSpace(a.Value), 0, 16)
+	if err != nil {
+		return nil, fmt.Errorf("bad value %q", a.Value)
+	}
+	var result variable
+	s.do(func() {
+		c := s.emu.CPU
+		switch a.VariablesReference {
+		case registersRef:
+			r, ok := lookupRegister(a.Name)
+			if !ok {
+				err = fmt.Errorf("no register %s", a.Name)
+				return
+			}
+			r.set(c, uint16(value)&s.emu.RAM.Mask())
+			result = r.variable(c)
+		case stackRef:
+			i, ierr := strconv.Atoi(a.Name)
+			if ierr != nil || i < 0 || i >= int(c.SP) {
+				err = fmt.Errorf("no stack entry %s", a.Name)
+				return
+			}
+			c.Stack[i] = uint16(value) & s.emu.RAM.Mask()
+			result = variable{Value: s.d.Where(c.Stack[i])}
+		default:
+			err = errors.New("no such variables")
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"value": result.Value, "memoryReference": result.MemoryReference}, nil
+}
+
+func (s *Server) resume(args json.RawMessage) (any, error) {
+	s.do(s.emu.Resume)
+	return map[string]bool{"allThreadsContinued": true}, nil
+}
+
+func (s *Server) next(args json.RawMessage) (any, error) {
+	return s.step(func() { s.d.StepOver() })
+}
+
+func (s *Server) stepIn(args json.RawMessage) (any, error) {
+	return s.step(func() { s.d.Step(1) })
+}
+
+func (s *Server) stepOut(args json.RawMessage) (any, error) {
+	return s.step(func() { s.d.StepOut() })
+}
+
+// step runs a stepping command, then reports the program stopped again, at
+// a breakpoint if stepping ran into one.
+func (s *Server) step(fn func()) (any, error) {
+	reason := "step"
+	s.do(func() {
+		fn()
+		if s.emu.CPU.AtBreakpoint() {
+			reason = "breakpoint"
+		}
+	})
+	s.later("stopped", stopped(reason))
+	return nil, nil
+}
+
+func (s *Server) pause(args json.RawMessage) (any, error) {
+	s.do(s.emu.Pause)
+	s.later("stopped", stopped("pause"))
+	return nil, nil
+}
+
+func (s *Server) readMemory(args json.RawMessage) (any, error) {
+	var a struct {
+		MemoryReference string `json:"memoryReference"`
+		Offset          int    `json:"offset"`
+		Count           int    `json:"count"`
+	}
+	if err := decode(args, &a); err != nil {
+		return nil, err
+	}
+	address, ok := s.address(a.MemoryReference, a.Offset)
+	if !ok {
+		return nil, fmt.Errorf("bad memory reference %q", a.MemoryReference)
+	}
+	count := min(a.Count, s.emu.RAM.Size()-int(address))
+	data := make([]byte, max(count, 0))
+	s.do(func() {
+		for i := range data {
+			data[i], _ = s.emu.RAM.ReadByte(address + uint16(i))
+		}
+	})
+	return map[string]any{
+		"address":         reference(address),
+		"data":            base64.StdEncoding.EncodeToString(data),
+		"unreadableBytes": a.Count - len(data),
+	}, nil
+}
+
+func (s *Server) writeMemory(args json.RawMessage) (any, error) {
+	var a struct {
+		MemoryReference string `json:"memoryReference"`
+		Offset          int    `json:"offset"`
+		Data            string `json:"data"`
+	}
+	if err := decode(args, &a); err != nil {
+		return nil, err
+	}
+	address, ok := s.address(a.MemoryReference, a.Offset)
+	if !ok {
+		return nil, fmt.Errorf("bad memory reference %q", a.MemoryReference)
+	}
+	data, err := base64.StdEncoding.DecodeString(a.Data)
+	if err != nil {
+		return nil, fmt.Errorf("bad data: %w", err)
+	}
+	data = data[:min(len(data), s.emu.RAM.Size()-int(address))]
+	s.do(func() {
+		for i, b := range data {
+			s.emu.RAM.WriteByte(address+uint16(i), b)
+		}
+	})
+	return map[string]int{"offset": a.Offset, "bytesWritten": len(data)}, nil
+}
+
+type instruction struct {
+	Address          string  `json:"address"`
+	InstructionBytes string  `json:"instructionBytes,omitempty"`
+	Instruction      string  `json:"instruction"`
+	Symbol           string  `json:"symbol,omitempty"`
+	Location         *source `json:"location,omitempty"`
+	Line             int     `json:"line,omitempty"`
+	PresentationHint string  `json:"presentationHint,omitempty"`
+}
+
+// disassemble decodes instructions around a reference. Instructions are
+// taken to be two bytes long when counting back from it.
+func (s *Server) disassemble(args json.RawMessage) (any, error) {
+	var a struct {
+		MemoryReference   string `json:"memoryReference"`
+		Offset            int    `json:"offset"`
+		InstructionOffset int    `json:"instructionOffset"`
+		InstructionCount  int    `json:"instructionCount"`
+	}
+	if err := decode(args, &a); err != nil {
+		return nil, err
+	}
+	base, ok := s.address(a.MemoryReference, a.Offset)
+	if !ok {
+		return nil, fmt.Errorf("bad memory reference %q", a.MemoryReference)
+	}
+	var memory []byte
+	s.do(func() { memory = s.emu.RAM.Snapshot() })
+	result := []instruction{}
+	for address := int(base) + 2*a.InstructionOffset; len(result) < a.InstructionCount; {
+		if address < 0 || address+1 >= len(memory) {
+			result = append(result, instruction{Address: fmt.Sprintf("0x%03X", max(address, 0)), Instruction: "??", PresentationHint: "invalid"})
+			address += 2
+			continue
+		}
+		line := disasm.Disassemble(memory, 0, uint16(address), 1)[0]
+		in := instruction{
+			Address:          reference(line.Address),
+			InstructionBytes: fmt.Sprintf("%04X", line.Opcode),
+			Instruction:      line.Named(s.emu.Symbols.Format),
+		}
+		in.Symbol, _ = s.emu.Symbols.Name(line.Address)
+		if f := s.frame(0, line.Address); f.Source != nil {
+			in.Location, in.Line = f.Source, f.Line
+		}
+		result = append(result, in)
+		address += disasm.Size(line.Opcode)
+	}
+	return map[string]any{"instructions": result}, nil
+}
+
+// evaluate runs console commands typed into the debug console, and gives
+// the value of a register, symbol or address anywhere else, such as when
+// hovering over source.
+func (s *Server) evaluate(args json.RawMessage) (any, error) {
+	var a struct {
+		Expression string `json:"expression"`
+		Context    string `json:"context"`
+	}
+	if err := decode(args, &a); err != nil {
+		return nil, err
+	}
+	var result variable
+	var err error
+	s.do(func() {
+		if a.Context == "repl" {
+			var out bytes.Buffer
+			err = s.d.Exec(a.Expression, &out)
+			result.Value = strings.TrimRight(out.String(), "\n")
+			return
+		}
+		if r, ok := lookupRegister(a.Expression); ok {
+			result = r.variable(s.emu.CPU)
+			return
+		}
+		if address, ok := s.address(a.Expression, 0); ok {
+			result = variable{Value: s.d.Where(address), MemoryReference: reference(address)}
+			return
+		}
+		err = fmt.Errorf("cannot evaluate %q", a.Expression)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"result": result.Value, "variablesReference": 0, "memoryReference": result.MemoryReference}, nil
+}
+
+func (s *Server) terminate(args json.RawMessage) (any, error) {
+	s.do(s.emu.Stop)
+	return nil, nil
+}
+
+func (s *Server) disconnect(args json.RawMessage) (any, error) {
+	s.do(s.emu.Stop)
+	s.quit = true
+	return nil, nil
+}
+
+// address parses a memory reference, symbol or address, adds offset and
+// checks the result lies in memory.
+func (s *Server) address(ref string, offset int) (uint16, bool) {
+	base, ok := s.emu.Symbols.Parse(strings.TrimSpace(ref))
+	address := int(base) + offset
+	if !ok || address < 0 || address >= s.emu.RAM.Size() {
+		return 0, false
+	}
+	return uint16(address), true
+}
+
+// reference is how addresses are given to the client as memory and
+// instruction references.
+func reference(address uint16) string {
+	return fmt.Sprintf("0x%03X", address)
+}
+
+// register is one CPU register shown as a variable.
+type register struct {
+	name   string
+	digits int
+	get    func(c *cpu.CPU) uint16
+	set    func(c *cpu.CPU, v uint16)
+	memory bool // the value is an address worth browsing
+}
+
+var registers []register
+
+func init() {
+	for i := range 16 {
+		registers = append(registers, register{
+			name: fmt.Sprintf("V%X", i), digits: 2,
+			get: func(c *cpu.CPU) uint16 { return uint16(c.V[i]) },
+			set: func(c *cpu.CPU, v uint16) { c.V[i] = byte(v) },
+		})
+	}
+	registers = append(registers,
+		register{name: "I", digits: 3, memory: true,
+			get: func(c *cpu.CPU) uint16 { return c.I },
+			set: func(c *cpu.CPU, v uint16) { c.I = v }},
+		register{name: "PC", digits: 3, memory: true,
+			get: func(c *cpu.CPU) uint16 { return c.PC },
+			set: func(c *cpu.CPU, v uint16) { c.PC = v }},
+		register{name: "SP", digits: 1,
+			get: func(c *cpu.CPU) uint16 { return uint16(c.SP) },
+			set: func(c *cpu.CPU, v uint16) { c.SP = byte(min(v, uint16(len(c.Stack)))) }},
+		register{name: "DT", digits: 2,
+			get: func(c *cpu.CPU) uint16 { return uint16(c.DT) },
+			set: func(c *cpu.CPU, v uint16) { c.DT = byte(v) }},
+		register{name: "ST", digits: 2,
+			get: func(c *cpu.CPU) uint16 { return uint16(c.ST) },
+			set: func(c *cpu.CPU, v uint16) { c.ST = byte(v) }},
+	)
+}
+
+// lookupRegister finds a register by name, ignoring case.
+func lookupRegister(name string) (register, bool) {
+	for _, r := range registers {
+		if strings.EqualFold(r.name, strings.TrimSpace(name)) {
+			return r, true
+		}
+	}
+	return register{}, false
+}
+
+func (r register) variable(c *cpu.CPU) variable {
+	v := r.get(c)
+	result := variable{Name: r.name, Value: fmt.Sprintf("0x%0*X", r.digits, v)}
+	if r.memory {
+		result.MemoryReference = reference(v)
+	}
+	return result
+}
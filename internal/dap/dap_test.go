@@ -0,0 +1,284 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jsutcodes/chip8-goemu/internal/debugger"
+	"github.com/jsutcodes/chip8-goemu/internal/emulator"
+)
+
+// message is any message from the adapter.
+type message struct {
+	Type    string          `json:"type"`
+	Command string          `json:"command"`
+	Event   string          `json:"event"`
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Body    json.RawMessage `json:"body"`
+}
+
+// client is a scripted editor talking to the adapter.
+type client struct {
+	t        *testing.T
+	w        io.Writer
+	seq      int
+	messages chan message
+	backlog  []message
+}
+
+func (c *client) send(command string, args any) {
+	c.t.Helper()
+	c.seq++
+	if err := writeMessage(c.w, map[string]any{"seq": c.seq, "type": "request", "command": command, "arguments": args}); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// await returns the next response to command or event called name,
+// whichever kind is given. Other messages are kept for later, since events
+// may overtake responses.
+func (c *client) await(kind, name string) message {
+	c.t.Helper()
+	match := func(m message) bool {
+		return m.Type == kind && (m.Command == name || m.Event == name)
+	}
+	for i, m := range c.backlog {
+		if match(m) {
+			c.backlog = append(c.backlog[:i], c.backlog[i+1:]...)
+			return m
+		}
+	}
+	for {
+		select {
+		case m := <-c.messages:
+			if match(m) {
+				return m
+			}
+			c.backlog = append(c.backlog, m)
+		case <-time.After(5 * time.Second):
+			c.t.Fatalf("no %s %s", kind, name)
+		}
+	}
+}
+
+// ask sends a request and decodes the body of its successful response.
+func (c *client) ask(command string, args any, body any) {
+	c.t.Helper()
+	c.send(command, args)
+	m := c.await("response", command)
+	if !m.Success {
+		c.t.Fatalf("%s failed: %s", command, m.Message)
+	}
+	if body != nil {
+		if err := json.Unmarshal(m.Body, body); err != nil {
+			c.t.Fatalf("%s: %v", command, err)
+		}
+	}
+}
+
+// stopped waits for the program to stop and returns why.
+func (c *client) stopped() string {
+	c.t.Helper()
+	var body struct{ Reason string }
+	json.Unmarshal(c.await("event", "stopped").Body, &body)
+	return body.Reason
+}
+
+func start(t *testing.T) (*client, *emulator.Emulator) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"game.ch8": string([]byte{
+			0x60, 0x01, // 200: V0 = 1
+			0x22, 0x08, // 202: call bump
+			0x12, 0x02, // 204: jump 202
+			0x00, 0x00, // 206
+			0x70, 0x01, // 208: V0 += 1
+			0x00, 0xEE, // 20A: return
+		}),
+		"game.sym": "200 main\n208 bump\n",
+		"game.map": "200 game.8o:1\n202 game.8o:2\n204 game.8o:3\n208 game.8o:5\n20A game.8o:6\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	emu := emulator.NewEmulator()
+	emu.Dump.Path = ""
+	in, toServer := io.Pipe()
+	fromServer, out := io.Pipe()
+	s := NewServer(debugger.New(emu), in, out)
+	go s.Serve()
+
+	// Stand in for Run without drawing the screen.
+	quit := make(chan struct{})
+	t.Cleanup(func() { close(quit) })
+	go func() {
+		select {
+		case <-s.Launched():
+		case <-quit:
+			return
+		}
+		for {
+			select {
+			case <-quit:
+				return
+			default:
+			}
+			emu.Poll()
+			if !emu.Paused() {
+				emu.Frame()
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	c := &client{t: t, w: toServer, messages: make(chan message, 100)}
+	go func() {
+		r := bufio.NewReader(fromServer)
+		for {
+			body, err := readMessage(r)
+			if err != nil {
+				return
+			}
+			var m message
+			json.Unmarshal(body, &m)
+			c.messages <- m
+		}
+	}()
+
+	var caps map[string]bool
+	c.ask("initialize", map[string]string{"adapterID": "chip8"}, &caps)
+	if !caps["supportsConfigurationDoneRequest"] || !caps["supportsReadMemoryRequest"] {
+		t.Errorf("Unexpected capabilities %v", caps)
+	}
+	c.ask("launch", map[string]any{
+		"program":     filepath.Join(dir, "game.ch8"),
+		"symbols":     filepath.Join(dir, "game.sym"),
+		"sourceMap":   filepath.Join(dir, "game.map"),
+		"stopOnEntry": true,
+	}, nil)
+	c.await("event", "initialized")
+	return c, emu
+}
+
+func TestDebugSession(t *testing.T) {
+	c, emu := start(t)
+
+	var set struct {
+		Breakpoints []breakpoint
+	}
+	c.ask("setBreakpoints", map[string]any{
+		"source":      map[string]string{"path": "/src/game.8o"},
+		"breakpoints": []map[string]int{{"line": 4}, {"line": 9}},
+	}, &set)
+	if len(set.Breakpoints) != 2 || !set.Breakpoints[0].Verified || set.Breakpoints[0].Line != 5 || set.Breakpoints[1].Verified {
+		t.Errorf("Expected line 4 to move to line 5 and line 9 to fail, got %+v", set.Breakpoints)
+	}
+	c.ask("configurationDone", nil, nil)
+	if reason := c.stopped(); reason != "entry" {
+		t.Errorf("Expected to stop on entry, got %q", reason)
+	}
+
+	c.ask("continue", map[string]int{"threadId": 1}, nil)
+	if reason := c.stopped(); reason != "breakpoint" {
+		t.Errorf("Expected to stop at the breakpoint, got %q", reason)
+	}
+	var trace struct {
+		StackFrames []stackFrame
+		TotalFrames int
+	}
+	c.ask("stackTrace", map[string]int{"threadId": 1}, &trace)
+	if trace.TotalFrames != 2 {
+		t.Fatalf("Expected two frames, got %+v", trace)
+	}
+	if f := trace.StackFrames[0]; f.Name != "bump" || f.Line != 5 || f.Source == nil || f.Source.Name != "game.8o" {
+		t.Errorf("Unexpected top frame %+v", f)
+	}
+	if f := trace.StackFrames[1]; f.Name != "main+2" || f.Line != 2 || f.InstructionPointerReference != "0x202" {
+		t.Errorf("Unexpected caller frame %+v", f)
+	}
+
+	var vars struct{ Variables []variable }
+	c.ask("variables", map[string]int{"variablesReference": registersRef}, &vars)
+	if len(vars.Variables) != 21 || vars.Variables[0].Value != "0x01" || vars.Variables[17].Value != "0x208" {
+		t.Errorf("Unexpected registers %+v", vars.Variables)
+	}
+	c.ask("variables", map[string]int{"variablesReference": stackRef}, &vars)
+	if len(vars.Variables) != 1 || vars.Variables[0].Value != "0x202 main+2" {
+		t.Errorf("Unexpected stack %+v", vars.Variables)
+	}
+
+	c.ask("next", map[string]int{"threadId": 1}, nil)
+	c.stopped()
+	c.ask("stepOut", map[string]int{"threadId": 1}, nil)
+	if reason := c.stopped(); reason != "step" || emu.CPU.PC != 0x204 {
+		t.Errorf("Expected to step out to 0x204, stopped for %q at 0x%X", reason, emu.CPU.PC)
+	}
+
+	c.ask("setVariable", map[string]any{"variablesReference": registersRef, "name": "V0", "value": "0x10"}, nil)
+	var result struct{ Result string }
+	c.ask("evaluate", map[string]string{"expression": "v0", "context": "hover"}, &result)
+	if result.Result != "0x10" {
+		t.Errorf("Expected V0 to be set to 0x10, got %q", result.Result)
+	}
+	c.ask("evaluate", map[string]string{"expression": "regs", "context": "repl"}, &result)
+	if !strings.Contains(result.Result, "V0=10") {
+		t.Errorf("Expected console output, got %q", result.Result)
+	}
+
+	var memory struct {
+		Address string
+		Data    string
+	}
+	c.ask("readMemory", map[string]any{"memoryReference": "main", "count": 4}, &memory)
+	if memory.Address != "0x200" || memory.Data != "YAEiCA==" {
+		t.Errorf("Unexpected memory %+v", memory)
+	}
+	c.ask("writeMemory", map[string]any{"memoryReference": "0x300", "data": "qg=="}, nil)
+	if b, _ := emu.RAM.ReadByte(0x300); b != 0xAA {
+		t.Errorf("Expected 0xAA written at 0x300, got 0x%02X", b)
+	}
+
+	var code struct{ Instructions []instruction }
+	c.ask("disassemble", map[string]any{"memoryReference": "0x204", "instructionOffset": -1, "instructionCount": 2}, &code)
+	if len(code.Instructions) != 2 || code.Instructions[0].Instruction != "CALL bump" || code.Instructions[1].Instruction != "JP main+2" || code.Instructions[1].Line != 3 {
+		t.Errorf("Unexpected disassembly %+v", code.Instructions)
+	}
+
+	c.send("frobnicate", nil)
+	if m := c.await("response", "frobnicate"); m.Success {
+		t.Errorf("Expected an unknown request to fail")
+	}
+	c.ask("disconnect", nil, nil)
+}
+
+func TestInstructionBreakpoints(t *testing.T) {
+	c, emu := start(t)
+	emu.CPU.SetBreakpoint(0x20A) // set by another front end
+	var set struct{ Breakpoints []breakpoint }
+	c.ask("setFunctionBreakpoints", map[string]any{"breakpoints": []map[string]string{{"name": "bump"}, {"name": "nowhere"}}}, &set)
+	if !set.Breakpoints[0].Verified || set.Breakpoints[0].InstructionReference != "0x208" || set.Breakpoints[1].Verified {
+		t.Errorf("Unexpected function breakpoints %+v", set.Breakpoints)
+	}
+	c.ask("setInstructionBreakpoints", map[string]any{"breakpoints": []map[string]any{{"instructionReference": "0x200", "offset": 4}}}, nil)
+	c.ask("setFunctionBreakpoints", map[string]any{"breakpoints": []any{}}, nil)
+	if got := emu.CPU.Breakpoints(); len(got) != 2 || got[0] != 0x204 || got[1] != 0x20A {
+		t.Errorf("Expected breakpoints at 0x204 and 0x20A, got %X", got)
+	}
+	c.ask("configurationDone", nil, nil)
+	c.stopped()
+	c.ask("continue", nil, nil)
+	if reason := c.stopped(); reason != "breakpoint" {
+		t.Errorf("Expected a breakpoint, got %q", reason)
+	}
+	c.ask("disconnect", nil, nil)
+}
@@ -0,0 +1,54 @@
+package debugger
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jsutcodes/chip8-goemu/internal/cheat"
+)
+
+func (d *Debugger) cheats(args []string, w io.Writer) error {
+	if len(args) == 0 || args[0] == "list" {
+		if d.emu.Cheats == nil {
+			return nil
+		}
+		for i, c := range d.emu.Cheats.Cheats {
+			on := " "
+			if c.Enabled {
+				on = "x"
+			}
+			fmt.Fprintf(w, "%2d [%s] %s\n", i+1, on, c)
+		}
+		return nil
+	}
+	switch args[0] {
+	case "on", "off":
+		if len(args) != 2 {
+			return ErrUsage
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return ErrUsage
+		}
+		if d.emu.Cheats == nil || n < 1 || n > len(d.emu.Cheats.Cheats) {
+			return fmt.Errorf("no cheat %d, see cheat list", n)
+		}
+		return d.emu.EnableCheat(n-1, args[0] == "on")
+	case "add":
+		c, err := cheat.ParseCheat(strings.Join(args[1:], " "))
+		if err != nil {
+			return err
+		}
+		s := d.emu.Cheats
+		if s == nil {
+			s = &cheat.Set{}
+		}
+		s.Cheats = append(s.Cheats, c)
+		d.emu.SetCheats(s)
+		fmt.Fprintf(w, "Cheat %d: %s\n", len(s.Cheats), c)
+		return nil
+	}
+	return ErrUsage
+}
@@ -0,0 +1,351 @@
+// Package debugger is the debugging core shared by the emulator's front
+// ends: it pauses, steps and resumes the emulator, keeps breakpoints, and
+// runs the commands typed into a debugger console.
+package debugger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jsutcodes/chip8-goemu/internal/disasm"
+	"github.com/jsutcodes/chip8-goemu/internal/emulator"
+	"github.com/jsutcodes/chip8-goemu/internal/memory"
+)
+
+// ErrUsage is wrapped by the errors Exec returns for commands it cannot
+// make sense of.
+var ErrUsage = errors.New("usage")
+
+// Debugger drives an emulator on behalf of a front end. Its methods must be
+// called from the goroutine running the emulator, for instance from Poll.
+type Debugger struct {
+	emu    *emulator.Emulator
+	search *memory.Search
+	calls  chan func() // functions queued by Do, set by EnableRemote
+	stops  []func(pc uint16)
+}
+
+// New returns a debugger for emu. From then on breakpoints pause emu, and
+// whatever OnBreakpoint emu had is called through OnStop.
+func New(emu *emulator.Emulator) *Debugger {
+	d := &Debugger{emu: emu}
+	if emu.OnBreakpoint != nil {
+		d.OnStop(emu.OnBreakpoint)
+	}
+	emu.PauseAtBreakpoints = true
+	emu.OnBreakpoint = d.stopped
+	return d
+}
+
+// Emulator returns the emulator being debugged.
+func (d *Debugger) Emulator() *emulator.Emulator {
+	return d.emu
+}
+
+// command is one console command.
+type command struct {
+	usage string
+	help  string
+	run   func(d *Debugger, args []string, w io.Writer) error
+}
+
+var commands map[string]*command
+
+// aliases are the short names of the commonest commands.
+var aliases = map[string]string{
+	"b":  "break",
+	"bt": "backtrace",
+	"c":  "continue",
+	"n":  "next",
+	"s":  "step",
+	"r":  "regs",
+}
+
+func init() {
+	commands = map[string]*command{
+		"help":      {"help", "list the commands", (*Debugger).help},
+		"pause":     {"pause", "stop running", (*Debugger).pause},
+		"continue":  {"continue", "carry on running", (*Debugger).resume},
+		"step":      {"step [count]", "run one or count instructions", (*Debugger).step},
+		"next":      {"next", "run one instruction, or the whole subroutine it calls", (*Debugger).next},
+		"finish":    {"finish", "run until the current subroutine returns", (*Debugger).finish},
+		"break":     {"break [address]", "stop when execution reaches address, or list breakpoints", (*Debugger).setBreak},
+		"delete":    {"delete address", "remove a breakpoint", (*Debugger).deleteBreak},
+		"regs":      {"regs", "show the registers, timers and stack", (*Debugger).regs},
+		"backtrace": {"backtrace", "show the subroutine calls that led to PC", (*Debugger).backtrace},
+		"trace":     {"trace on | off", "print every instruction as it runs", (*Debugger).trace},
+		"x":         {"x address [count]", "show count bytes of memory (16 by default)", (*Debugger).examine},
+		"list":      {"list [address] [count]", "disassemble from PC or address", (*Debugger).list},
+		"search":    {"search start [range] | changed | unchanged | increased | decreased | eq value | list", "narrow down where a value is kept", (*Debugger).searchMemory},
+		"freeze":    {"freeze [address [value]]", "hold address at value (by default its current one), or list frozen addresses", (*Debugger).freeze},
+		"unfreeze":  {"unfreeze address", "let programs write to address again", (*Debugger).unfreeze},
+		"cheat":     {"cheat [list] | on n | off n | add [name:] code...", "list, toggle or add cheats", (*Debugger).cheats},
+	}
+}
+
+// Exec runs one console command, writing its output to w. Blank lines do
+// nothing.
+func (d *Debugger) Exec(line string, w io.Writer) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	name := fields[0]
+	if full, ok := aliases[name]; ok {
+		name = full
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q, try help", fields[0])
+	}
+	if err := cmd.run(d, fields[1:], w); err != nil {
+		if errors.Is(err, ErrUsage) {
+			return fmt.Errorf("%w: %s", err, cmd.usage)
+		}
+		return err
+	}
+	return nil
+}
+
+// Attach runs a console on the emulator: lines read from r are executed as
+// commands between frames, with output and breakpoint stops written to w.
+// It chains onto any Poll the emulator already has.
+func (d *Debugger) Attach(r io.Reader, w io.Writer) {
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	poll := d.emu.Poll
+	d.emu.Poll = func() {
+		if poll != nil {
+			poll()
+		}
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					return
+				}
+				if err := d.Exec(line, w); err != nil {
+					fmt.Fprintln(w, err)
+				}
+			default:
+				return
+			}
+		}
+	}
+	d.OnStop(func(pc uint16) {
+		fmt.Fprintf(w, "Breakpoint at %s\n", d.Where(pc))
+	})
+}
+
+func (d *Debugger) help(args []string, w io.Writer) error {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%-30s %s\n", commands[name].usage, commands[name].help)
+	}
+	return nil
+}
+
+func (d *Debugger) pause(args []string, w io.Writer) error {
+	d.emu.Pause()
+	fmt.Fprintf(w, "Paused at 0x%03X\n", d.emu.CPU.PC)
+	return nil
+}
+
+func (d *Debugger) resume(args []string, w io.Writer) error {
+	d.emu.Resume()
+	return nil
+}
+
+// Step pauses the emulator and runs count instructions, stopping early at a
+// breakpoint. It returns how many ran.
+func (d *Debugger) Step(count int) int {
+	d.emu.Pause()
+	for i := 1; i <= count; i++ {
+		if d.emu.StepInstruction() {
+			return i
+		}
+	}
+	return count
+}
+
+func (d *Debugger) step(args []string, w io.Writer) error {
+	count := 1
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return ErrUsage
+		}
+		count = n
+	}
+	d.Step(count)
+	return d.showPC(w)
+}
+
+// showPC prints the instruction at PC.
+func (d *Debugger) showPC(w io.Writer) error {
+	line := disasm.Disassemble(d.emu.RAM.Snapshot(), 0, d.emu.CPU.PC, 1)
+	if len(line) == 1 {
+		fmt.Fprintf(w, "%s  %04X  %s\n", d.Where(line[0].Address), line[0].Opcode, line[0].Named(d.emu.Symbols.Format))
+	}
+	return nil
+}
+
+func (d *Debugger) setBreak(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		for _, address := range d.emu.CPU.Breakpoints() {
+			fmt.Fprintln(w, d.Where(address))
+		}
+		return nil
+	}
+	address, err := d.address(args[0])
+	if err != nil {
+		return err
+	}
+	d.emu.CPU.SetBreakpoint(address)
+	return nil
+}
+
+func (d *Debugger) deleteBreak(args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return ErrUsage
+	}
+	address, err := d.address(args[0])
+	if err != nil {
+		return err
+	}
+	d.emu.CPU.ClearBreakpoint(address)
+	return nil
+}
+
+func (d *Debugger) regs(args []string, w io.Writer) error {
+	c := d.emu.CPU
+	for i, v := range c.V {
+		fmt.Fprintf(w, "V%X=%02X ", i, v)
+		if i%8 == 7 {
+			fmt.Fprintln(w)
+		}
+	}
+	fmt.Fprintf(w, "PC=%03X I=%03X SP=%X DT=%02X ST=%02X\n", c.PC, c.I, c.SP, c.DT, c.ST)
+	if c.SP > 0 {
+		fmt.Fprint(w, "Stack:")
+		for _, address := range c.Stack[:c.SP] {
+			fmt.Fprintf(w, " %03X", address)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+func (d *Debugger) backtrace(args []string, w io.Writer) error {
+	c := d.emu.CPU
+	fmt.Fprintf(w, "#0  %s\n", d.Where(c.PC))
+	for i := 1; i <= int(c.SP); i++ {
+		fmt.Fprintf(w, "#%d  %s\n", i, d.Where(c.Stack[int(c.SP)-i]))
+	}
+	return nil
+}
+
+func (d *Debugger) trace(args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return ErrUsage
+	}
+	switch args[0] {
+	case "on":
+		d.emu.TraceTo(w)
+	case "off":
+		d.emu.TraceTo(nil)
+	default:
+		return ErrUsage
+	}
+	return nil
+}
+
+func (d *Debugger) examine(args []string, w io.Writer) error {
+	if len(args) == 0 || len(args) > 2 {
+		return ErrUsage
+	}
+	address, err := d.address(args[0])
+	if err != nil {
+		return err
+	}
+	count := 16
+	if len(args) == 2 {
+		if count, err = strconv.Atoi(args[1]); err != nil || count < 1 {
+			return ErrUsage
+		}
+	}
+	end := int(address) + count
+	if end > d.emu.RAM.Size() {
+		end = d.emu.RAM.Size()
+	}
+	return d.emu.RAM.Dump(w, memory.Xxd, memory.Range{Start: int(address), End: end})
+}
+
+func (d *Debugger) list(args []string, w io.Writer) error {
+	address, count := d.emu.CPU.PC, 10
+	var err error
+	if len(args) > 0 {
+		if address, err = d.address(args[0]); err != nil {
+			return err
+		}
+	}
+	if len(args) > 1 {
+		if count, err = strconv.Atoi(args[1]); err != nil || count < 1 {
+			return ErrUsage
+		}
+	}
+	for _, line := range disasm.Disassemble(d.emu.RAM.Snapshot(), 0, address, count) {
+		if name, ok := d.emu.Symbols.Name(line.Address); ok {
+			fmt.Fprintf(w, "%s:\n", name)
+		}
+		marker := "  "
+		if line.Address == d.emu.CPU.PC {
+			marker = "=>"
+		}
+		fmt.Fprintf(w, "%s 0x%03X  %04X  %s\n", marker, line.Address, line.Opcode, line.Named(d.emu.Symbols.Format))
+	}
+	return nil
+}
+
+// address parses a symbol, a symbol plus an offset or a hex address, with
+// or without 0x, and checks it is in memory.
+func (d *Debugger) address(s string) (uint16, error) {
+	v, ok := d.emu.Symbols.Parse(s)
+	if !ok || int(v) >= d.emu.RAM.Size() {
+		return 0, fmt.Errorf("bad address %q", s)
+	}
+	return v, nil
+}
+
+// Where writes an address in hex, followed by where it is in the program if
+// there are symbols for it.
+func (d *Debugger) Where(address uint16) string {
+	if name := d.emu.Symbols.Format(address); !strings.HasPrefix(name, "0x") {
+		return fmt.Sprintf("0x%03X %s", address, name)
+	}
+	return fmt.Sprintf("0x%03X", address)
+}
+
+// value parses a byte, in decimal or, with 0x, in hex.
+func value(s string) (byte, error) {
+	v, err := strconv.ParseUint(s, 0, 8)
+	if err != nil {
+		return 0, fmt.Errorf("bad value %q", s)
+	}
+	return byte(v), nil
+}
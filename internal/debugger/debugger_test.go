@@ -0,0 +1,234 @@
+package debugger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jsutcodes/chip8-goemu/internal/emulator"
+	"github.com/jsutcodes/chip8-goemu/internal/platform"
+	"github.com/jsutcodes/chip8-goemu/internal/symbols"
+)
+
+func setup(t *testing.T) (*emulator.Emulator, *Debugger) {
+	t.Helper()
+	emu := emulator.NewEmulator()
+	emu.RAM.LoadROM([]byte{
+		0x60, 0x03, // V0 = 3
+		0x70, 0xFF, // V0 -= 1
+		0xA3, 0x00, // I = 0x300
+		0xF0, 0x55, // save V0 at 0x300
+		0x12, 0x02, // jump 0x202
+	})
+	return emu, New(emu)
+}
+
+func run(t *testing.T, d *Debugger, line string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := d.Exec(line, &out); err != nil {
+		t.Fatalf("%s: unexpected error: %v", line, err)
+	}
+	return out.String()
+}
+
+func TestStepAndBreak(t *testing.T) {
+	emu, d := setup(t)
+	if out := run(t, d, "s"); !strings.Contains(out, "0x202  70FF  ADD V0, 0xFF") {
+		t.Errorf("Expected step to show the next instruction, got %q", out)
+	}
+	if !emu.Paused() {
+		t.Errorf("Expected stepping to pause the emulator")
+	}
+	run(t, d, "break 206")
+	if out := run(t, d, "b"); out != "0x206\n" {
+		t.Errorf("Expected one breakpoint listed, got %q", out)
+	}
+	run(t, d, "step 10")
+	if emu.CPU.PC != 0x206 {
+		t.Errorf("Expected stepping to stop at the breakpoint, PC is 0x%X", emu.CPU.PC)
+	}
+	if out := run(t, d, "regs"); !strings.Contains(out, "V0=02") || !strings.Contains(out, "PC=206") {
+		t.Errorf("Unexpected registers %q", out)
+	}
+	if out := run(t, d, "list 200 2"); !strings.Contains(out, "   0x200  6003") {
+		t.Errorf("Unexpected listing %q", out)
+	}
+	run(t, d, "delete 206")
+	if len(emu.CPU.Breakpoints()) != 0 {
+		t.Errorf("Expected the breakpoint to be deleted")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	_, d := setup(t)
+	if err := d.Exec("frobnicate", &bytes.Buffer{}); err == nil {
+		t.Errorf("Expected an error for an unknown command")
+	}
+	if err := d.Exec("step many", &bytes.Buffer{}); !errors.Is(err, ErrUsage) {
+		t.Errorf("Expected a usage error, got %v", err)
+	}
+	if err := d.Exec("break 10000", &bytes.Buffer{}); err == nil {
+		t.Errorf("Expected an error for an address past the end of memory")
+	}
+	if err := d.Exec("search changed", &bytes.Buffer{}); err == nil {
+		t.Errorf("Expected an error filtering before a search is started")
+	}
+}
+
+func TestSearchAndFreeze(t *testing.T) {
+	emu, d := setup(t)
+	d.Step(4)
+	if out := run(t, d, "search start 300-30f"); out != "16 candidates\n" {
+		t.Errorf("Unexpected start %q", out)
+	}
+	run(t, d, "search eq 2")
+	d.Step(4)
+	run(t, d, "search decreased")
+	if out := run(t, d, "search list"); out != "0x300 = 1 (0x01)\n" {
+		t.Errorf("Expected to find the counter at 0x300, got %q", out)
+	}
+
+	run(t, d, "freeze 300 9")
+	d.Step(4)
+	if v, _ := emu.RAM.ReadByte(0x300); v != 9 {
+		t.Errorf("Expected 0x300 to stay frozen at 9, got %d", v)
+	}
+	if out := run(t, d, "freeze"); out != "0x300 = 9 (0x09)\n" {
+		t.Errorf("Unexpected frozen list %q", out)
+	}
+	run(t, d, "unfreeze 300")
+	d.Step(4)
+	if v, _ := emu.RAM.ReadByte(0x300); v == 9 {
+		t.Errorf("Expected 0x300 to change after unfreezing")
+	}
+}
+
+func TestAttach(t *testing.T) {
+	emu, d := setup(t)
+	var out bytes.Buffer
+	d.Attach(strings.NewReader("break 206\n"), &out)
+	for deadline := time.Now().Add(time.Second); len(emu.CPU.Breakpoints()) == 0 && time.Now().Before(deadline); {
+		emu.Poll()
+		time.Sleep(time.Millisecond)
+	}
+	emu.Frame()
+	if !emu.Paused() || !strings.Contains(out.String(), "Breakpoint at 0x206") {
+		t.Errorf("Expected a command read from the console to pause at 0x206, got %q", out.String())
+	}
+}
+
+func TestCheat(t *testing.T) {
+	emu, d := setup(t)
+	if out := run(t, d, "cheat add More lives: P201=09"); out != "Cheat 1: More lives: P201=09\n" {
+		t.Errorf("Unexpected output %q", out)
+	}
+	if b, _ := emu.RAM.ReadByte(0x201); b != 0x09 {
+		t.Errorf("Expected the patch to apply, got %02X", b)
+	}
+	run(t, d, "cheat add W300=01")
+	run(t, d, "cheat off 1")
+	if b, _ := emu.RAM.ReadByte(0x201); b != 0x03 {
+		t.Errorf("Expected the patch to be undone, got %02X", b)
+	}
+	if out := run(t, d, "cheat"); out != " 1 [ ] More lives: P201=09\n 2 [x] W300=01\n" {
+		t.Errorf("Unexpected listing %q", out)
+	}
+	if err := d.Exec("cheat on 3", new(bytes.Buffer)); err == nil {
+		t.Errorf("Expected an error for a missing cheat")
+	}
+	if err := d.Exec("cheat add Q1", new(bytes.Buffer)); err == nil {
+		t.Errorf("Expected an error for a bad code")
+	}
+}
+
+func TestSymbols(t *testing.T) {
+	emu, d := setup(t)
+	emu.RAM.LoadROM([]byte{
+		0x22, 0x04, // 200: call count
+		0x12, 0x00, // 202: jump main
+		0x70, 0x01, // 204: V0 += 1
+		0x00, 0xEE, // 206: return
+	})
+	emu.Symbols = symbols.New()
+	emu.Symbols.Add("main", 0x200)
+	emu.Symbols.Add("count", 0x204)
+
+	run(t, d, "break count+2")
+	if out := run(t, d, "b"); out != "0x206 count+2\n" {
+		t.Errorf("Expected the breakpoint listed by name, got %q", out)
+	}
+	run(t, d, "step 5")
+	if out := run(t, d, "bt"); out != "#0  0x206 count+2\n#1  0x200 main\n" {
+		t.Errorf("Unexpected backtrace %q", out)
+	}
+	want := "main:\n   0x200  2204  CALL count\n   0x202  1200  JP main\ncount:\n   0x204  7001  ADD V0, 0x01\n=> 0x206  00EE  RET\n"
+	if out := run(t, d, "list main 4"); out != want {
+		t.Errorf("Expected a listing with labels, got %q", out)
+	}
+
+	var trace bytes.Buffer
+	d.Exec("trace on", &trace)
+	d.Step(2)
+	d.Exec("trace off", &trace)
+	d.Step(1)
+	lines := strings.Split(strings.TrimSpace(trace.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "0x206 count+2") || !strings.Contains(lines[1], "JP main") {
+		t.Errorf("Unexpected trace %q", trace.String())
+	}
+}
+
+func TestNextAndFinish(t *testing.T) {
+	emu, d := setup(t)
+	emu.RAM.LoadROM([]byte{
+		0x22, 0x06, // 200: call 206
+		0x60, 0x07, // 202: V0 = 7
+		0x12, 0x04, // 204: jump 204
+		0x22, 0x0A, // 206: call 20A
+		0x00, 0xEE, // 208: return
+		0x71, 0x01, // 20A: V1 += 1
+		0x00, 0xEE, // 20C: return
+	})
+	if out := run(t, d, "n"); out != "0x202  6007  LD V0, 0x07\n" || emu.CPU.V[1] != 1 {
+		t.Errorf("Expected next to run the whole call, got %q with V1=%d", out, emu.CPU.V[1])
+	}
+
+	emu.CPU.PC = 0x200
+	d.Step(3)
+	if emu.CPU.PC != 0x20C || emu.CPU.SP != 2 {
+		t.Fatalf("Expected to be two calls deep at 0x20C, at 0x%X SP=%d", emu.CPU.PC, emu.CPU.SP)
+	}
+	if out := run(t, d, "finish"); out != "0x208  00EE  RET\n" {
+		t.Errorf("Expected finish to return to 0x208, got %q", out)
+	}
+	run(t, d, "break 204")
+	d.StepOut()
+	d.StepOver()
+	if emu.CPU.PC != 0x204 || !emu.CPU.AtBreakpoint() {
+		t.Errorf("Expected stepping over to stop at the breakpoint, PC is 0x%X", emu.CPU.PC)
+	}
+	if n := d.StepOut(); n != 1 {
+		t.Errorf("Expected finish outside a subroutine to step once, ran %d", n)
+	}
+}
+
+func TestStepOverDisplayWait(t *testing.T) {
+	emu := emulator.NewEmulator()
+	emu.SetProfile(platform.VIP)
+	emu.RAM.LoadROM([]byte{
+		0x22, 0x06, // 200: call 206
+		0x12, 0x04, // 202: jump 204
+		0x12, 0x04, // 204: jump 204
+		0xD0, 0x05, // 206: draw
+		0xD0, 0x05, // 208: draw
+		0x00, 0xEE, // 20A: return
+	})
+	d := New(emu)
+	emu.Pause()
+	run(t, d, "next")
+	if emu.CPU.PC != 0x202 || emu.CPU.DrawCount != 2 {
+		t.Errorf("Expected next to run a drawing subroutine on the VIP, PC is 0x%X after %d draws", emu.CPU.PC, emu.CPU.DrawCount)
+	}
+}
@@ -0,0 +1,36 @@
+package debugger
+
+// EnableRemote lets front ends on other goroutines, such as network servers,
+// use the debugger through Do. It chains onto any Poll the emulator already
+// has, so call it before the emulator starts running.
+func (d *Debugger) EnableRemote() {
+	if d.calls != nil {
+		return
+	}
+	d.calls = make(chan func())
+	poll := d.emu.Poll
+	d.emu.Poll = func() {
+		if poll != nil {
+			poll()
+		}
+		for {
+			select {
+			case fn := <-d.calls:
+				fn()
+			default:
+				return
+			}
+		}
+	}
+}
+
+// Do runs fn on the emulator's goroutine between frames and waits for it to
+// finish. EnableRemote must have been called.
+func (d *Debugger) Do(fn func()) {
+	done := make(chan struct{})
+	d.calls <- func() {
+		defer close(done)
+		fn()
+	}
+	<-done
+}
@@ -0,0 +1,110 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jsutcodes/chip8-goemu/internal/memory"
+)
+
+// maxListed caps how many candidates "search list" prints.
+const maxListed = 32
+
+var conditions = map[string]memory.Condition{
+	"changed":   memory.Changed,
+	"unchanged": memory.Unchanged,
+	"increased": memory.Increased,
+	"decreased": memory.Decreased,
+}
+
+func (d *Debugger) searchMemory(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+	switch args[0] {
+	case "start":
+		var r memory.Range
+		if len(args) > 1 {
+			var err error
+			if r, err = memory.ParseRange(args[1]); err != nil {
+				return err
+			}
+		}
+		s, err := d.emu.RAM.NewSearch(r)
+		if err != nil {
+			return err
+		}
+		d.search = s
+		fmt.Fprintf(w, "%d candidates\n", s.Len())
+		return nil
+	case "list":
+		if d.search == nil {
+			return errors.New("no search started, use search start")
+		}
+		candidates := d.search.Candidates()
+		for i, c := range candidates {
+			if i == maxListed {
+				fmt.Fprintf(w, "... and %d more\n", len(candidates)-maxListed)
+				break
+			}
+			fmt.Fprintf(w, "0x%03X = %d (0x%02X)\n", c.Address, c.Value, c.Value)
+		}
+		return nil
+	}
+
+	var c memory.Condition
+	switch {
+	case args[0] == "eq" && len(args) == 2:
+		v, err := value(args[1])
+		if err != nil {
+			return err
+		}
+		c = memory.Equals(v)
+	case conditions[args[0]] != nil:
+		c = conditions[args[0]]
+	default:
+		return ErrUsage
+	}
+	if d.search == nil {
+		return errors.New("no search started, use search start")
+	}
+	fmt.Fprintf(w, "%d candidates\n", d.search.Filter(c))
+	return nil
+}
+
+func (d *Debugger) freeze(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		for _, c := range d.emu.RAM.Frozen() {
+			fmt.Fprintf(w, "0x%03X = %d (0x%02X)\n", c.Address, c.Value, c.Value)
+		}
+		return nil
+	}
+	if len(args) > 2 {
+		return ErrUsage
+	}
+	address, err := d.address(args[0])
+	if err != nil {
+		return err
+	}
+	v, _ := d.emu.RAM.ReadByte(address)
+	if len(args) == 2 {
+		if v, err = value(args[1]); err != nil {
+			return err
+		}
+	}
+	d.emu.RAM.Freeze(address, v)
+	return nil
+}
+
+func (d *Debugger) unfreeze(args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return ErrUsage
+	}
+	address, err := d.address(args[0])
+	if err != nil {
+		return err
+	}
+	d.emu.RAM.Unfreeze(address)
+	return nil
+}
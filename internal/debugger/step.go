@@ -0,0 +1,66 @@
+package debugger
+
+import "io"
+
+// maxStepOver caps how many instructions StepOver and StepOut run looking
+// for the return, in case the subroutine never comes back.
+const maxStepOver = 1 << 20
+
+// StepOver runs one instruction, or a whole subroutine if the instruction is
+// a call, stopping early at a breakpoint. It returns how many instructions
+// ran.
+func (d *Debugger) StepOver() int {
+	c := d.emu.CPU
+	hi, _ := d.emu.RAM.ReadByte(c.PC)
+	if hi>>4 != 0x2 {
+		return d.Step(1)
+	}
+	depth, back := c.SP, c.PC+2
+	return d.stepUntil(func() bool { return c.SP == depth && c.PC == back })
+}
+
+// StepOut runs until the current subroutine returns, stopping early at a
+// breakpoint. Outside any subroutine it steps one instruction. It returns
+// how many instructions ran.
+func (d *Debugger) StepOut() int {
+	c := d.emu.CPU
+	if c.SP == 0 {
+		return d.Step(1)
+	}
+	depth := c.SP
+	return d.stepUntil(func() bool { return c.SP < depth })
+}
+
+// stepUntil steps until done reports true, a breakpoint is reached or
+// maxStepOver instructions have run.
+func (d *Debugger) stepUntil(done func() bool) int {
+	d.emu.Pause()
+	for i := 1; i <= maxStepOver; i++ {
+		if d.emu.StepInstruction() || done() {
+			return i
+		}
+	}
+	return maxStepOver
+}
+
+// OnStop registers fn to be called, on the emulator's goroutine, whenever
+// running stops at a breakpoint or watchpoint.
+func (d *Debugger) OnStop(fn func(pc uint16)) {
+	d.stops = append(d.stops, fn)
+}
+
+func (d *Debugger) stopped(pc uint16) {
+	for _, fn := range d.stops {
+		fn(pc)
+	}
+}
+
+func (d *Debugger) next(args []string, w io.Writer) error {
+	d.StepOver()
+	return d.showPC(w)
+}
+
+func (d *Debugger) finish(args []string, w io.Writer) error {
+	d.StepOut()
+	return d.showPC(w)
+}
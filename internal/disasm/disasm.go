@@ -0,0 +1,267 @@
+// Package disasm turns CHIP-8, SUPER-CHIP and XO-CHIP opcodes into
+// assembly text and finds which parts of a ROM are reachable code.
+package disasm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Mnemonic returns the assembly for an opcode, in the classic Cowgod style
+// extended with the SUPER-CHIP and XO-CHIP instructions. Opcodes that are
+// not instructions come out as data.
+func Mnemonic(opcode uint16) string {
+	x := (opcode >> 8) & 0xF
+	y := (opcode >> 4) & 0xF
+	n := opcode & 0xF
+	nn := opcode & 0xFF
+	nnn := opcode & 0xFFF
+
+	switch opcode >> 12 {
+	case 0x0:
+		switch {
+		case opcode == 0x00E0:
+			return "CLS"
+		case opcode == 0x00EE:
+			return "RET"
+		case opcode&0xFFF0 == 0x00C0:
+			return fmt.Sprintf("SCD %d", n)
+		case opcode&0xFFF0 == 0x00D0:
+			return fmt.Sprintf("SCU %d", n)
+		case opcode == 0x00FB:
+			return "SCR"
+		case opcode == 0x00FC:
+			return "SCL"
+		case opcode == 0x00FD:
+			return "EXIT"
+		case opcode == 0x00FE:
+			return "LOW"
+		case opcode == 0x00FF:
+			return "HIGH"
+		}
+		return fmt.Sprintf("SYS 0x%03X", nnn)
+	case 0x1:
+		return fmt.Sprintf("JP 0x%03X", nnn)
+	case 0x2:
+		return fmt.Sprintf("CALL 0x%03X", nnn)
+	case 0x3:
+		return fmt.Sprintf("SE V%X, 0x%02X", x, nn)
+	case 0x4:
+		return fmt.Sprintf("SNE V%X, 0x%02X", x, nn)
+	case 0x5:
+		switch n {
+		case 0x0:
+			return fmt.Sprintf("SE V%X, V%X", x, y)
+		case 0x2:
+			return fmt.Sprintf("SAVE V%X-V%X", x, y)
+		case 0x3:
+			return fmt.Sprintf("LOAD V%X-V%X", x, y)
+		}
+	case 0x6:
+		return fmt.Sprintf("LD V%X, 0x%02X", x, nn)
+	case 0x7:
+		return fmt.Sprintf("ADD V%X, 0x%02X", x, nn)
+	case 0x8:
+		if name, ok := aluNames[n]; ok {
+			return fmt.Sprintf("%s V%X, V%X", name, x, y)
+		}
+	case 0x9:
+		if n == 0 {
+			return fmt.Sprintf("SNE V%X, V%X", x, y)
+		}
+	case 0xA:
+		return fmt.Sprintf("LD I, 0x%03X", nnn)
+	case 0xB:
+		return fmt.Sprintf("JP V0, 0x%03X", nnn)
+	case 0xC:
+		return fmt.Sprintf("RND V%X, 0x%02X", x, nn)
+	case 0xD:
+		return fmt.Sprintf("DRW V%X, V%X, %d", x, y, n)
+	case 0xE:
+		switch nn {
+		case 0x9E:
+			return fmt.Sprintf("SKP V%X", x)
+		case 0xA1:
+			return fmt.Sprintf("SKNP V%X", x)
+		}
+	case 0xF:
+		switch {
+		case opcode == 0xF000:
+			return "LD I, long"
+		case opcode == 0xF002:
+			return "AUDIO"
+		case nn == 0x01:
+			return fmt.Sprintf("PLANE %d", x)
+		}
+		if format, ok := loadFormats[nn]; ok {
+			return fmt.Sprintf(format, x)
+		}
+	}
+	return fmt.Sprintf("DW 0x%04X", opcode)
+}
+
+var aluNames = map[uint16]string{
+	0x0: "LD", 0x1: "OR", 0x2: "AND", 0x3: "XOR", 0x4: "ADD",
+	0x5: "SUB", 0x6: "SHR", 0x7: "SUBN", 0xE: "SHL",
+}
+
+var loadFormats = map[uint16]string{
+	0x07: "LD V%X, DT",
+	0x0A: "LD V%X, K",
+	0x15: "LD DT, V%X",
+	0x18: "LD ST, V%X",
+	0x1E: "ADD I, V%X",
+	0x29: "LD F, V%X",
+	0x30: "LD HF, V%X",
+	0x33: "LD B, V%X",
+	0x3A: "PITCH V%X",
+	0x55: "LD [I], V%X",
+	0x65: "LD V%X, [I]",
+	0x75: "LD R, V%X",
+	0x85: "LD V%X, R",
+}
+
+// Known reports whether opcode is an instruction on any supported platform.
+func Known(opcode uint16) bool {
+	return !strings.HasPrefix(Mnemonic(opcode), "DW ")
+}
+
+// Size is how many bytes the instruction starting with opcode takes: four
+// for XO-CHIP's F000 NNNN, which carries a 16-bit address, two otherwise.
+func Size(opcode uint16) int {
+	if opcode == 0xF000 {
+		return 4
+	}
+	return 2
+}
+
+// Line is one disassembled instruction.
+type Line struct {
+	Address uint16
+	Opcode  uint16
+	Text    string
+}
+
+// Target returns the address the instruction jumps to, calls or points I
+// at, if it has one.
+func (l Line) Target() (uint16, bool) {
+	switch l.Opcode >> 12 {
+	case 0x1, 0x2, 0xA, 0xB:
+		return l.Opcode & 0xFFF, true
+	case 0x0:
+		return l.Opcode & 0xFFF, strings.HasPrefix(l.Text, "SYS ")
+	}
+	var long uint16
+	if l.Opcode == 0xF000 {
+		if _, err := fmt.Sscanf(l.Text, "LD I, 0x%X", &long); err == nil {
+			return long, true
+		}
+	}
+	return 0, false
+}
+
+// Named returns the text of the instruction with its target written the way
+// format writes addresses, if that gives it a name rather than a number.
+func (l Line) Named(format func(address uint16) string) string {
+	target, ok := l.Target()
+	if !ok {
+		return l.Text
+	}
+	name := format(target)
+	if strings.HasPrefix(name, "0x") {
+		return l.Text
+	}
+	i := strings.LastIndex(l.Text, "0x")
+	return l.Text[:i] + name
+}
+
+// opcodeAt reads the big-endian opcode at address in code loaded at base,
+// and whether it lies wholly inside code.
+func opcodeAt(code []byte, base, address uint16) (uint16, bool) {
+	i := int(address) - int(base)
+	if i < 0 || i+1 >= len(code) {
+		return 0, false
+	}
+	return uint16(code[i])<<8 | uint16(code[i+1]), true
+}
+
+// Disassemble decodes count instructions of code, which is loaded at base,
+// starting from address.
+func Disassemble(code []byte, base, address uint16, count int) []Line {
+	var lines []Line
+	for len(lines) < count {
+		opcode, ok := opcodeAt(code, base, address)
+		if !ok {
+			break
+		}
+		text := Mnemonic(opcode)
+		if opcode == 0xF000 {
+			if long, ok := opcodeAt(code, base, address+2); ok {
+				text = fmt.Sprintf("LD I, 0x%04X", long)
+			}
+		}
+		lines = append(lines, Line{Address: address, Opcode: opcode, Text: text})
+		address += uint16(Size(opcode))
+	}
+	return lines
+}
+
+// Trace follows every path through code, loaded at base, from its first
+// instruction and returns the addresses of the instructions it reaches, in
+// order. Both outcomes of a skip are followed, calls are followed and also
+// fall through, and paths end at returns, EXIT, computed jumps (BNNN) and
+// anything that does not decode.
+func Trace(code []byte, base uint16) []uint16 {
+	seen := map[uint16]bool{}
+	work := []uint16{base}
+	for len(work) > 0 {
+		address := work[len(work)-1]
+		work = work[:len(work)-1]
+		for !seen[address] {
+			opcode, ok := opcodeAt(code, base, address)
+			if !ok || !Known(opcode) {
+				break
+			}
+			seen[address] = true
+			next := address + uint16(Size(opcode))
+
+			switch {
+			case opcode == 0x00EE || opcode == 0x00FD || opcode>>12 == 0xB:
+				next = address // end of the path
+			case opcode>>12 == 0x1:
+				next = opcode & 0xFFF
+			case opcode>>12 == 0x2:
+				work = append(work, opcode&0xFFF)
+			case isSkip(opcode):
+				// The skipped instruction may be a four byte F000.
+				skipped, _ := opcodeAt(code, base, next)
+				work = append(work, next+uint16(Size(skipped)))
+			}
+			if next == address {
+				break
+			}
+			address = next
+		}
+	}
+
+	addresses := make([]uint16, 0, len(seen))
+	for address := range seen {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i] < addresses[j] })
+	return addresses
+}
+
+// isSkip reports whether opcode conditionally skips the next instruction.
+func isSkip(opcode uint16) bool {
+	switch opcode >> 12 {
+	case 0x3, 0x4:
+		return true
+	case 0x5, 0x9:
+		return opcode&0xF == 0
+	case 0xE:
+		return opcode&0xFF == 0x9E || opcode&0xFF == 0xA1
+	}
+	return false
+}
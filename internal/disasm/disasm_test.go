@@ -0,0 +1,83 @@
+package disasm
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMnemonic(t *testing.T) {
+	tests := map[uint16]string{
+		0x00E0: "CLS",
+		0x00EE: "RET",
+		0x00C4: "SCD 4",
+		0x00FF: "HIGH",
+		0x0123: "SYS 0x123",
+		0x1234: "JP 0x234",
+		0x2ABC: "CALL 0xABC",
+		0x3A12: "SE VA, 0x12",
+		0x5120: "SE V1, V2",
+		0x5122: "SAVE V1-V2",
+		0x5121: "DW 0x5121",
+		0x812E: "SHL V1, V2",
+		0x8128: "DW 0x8128",
+		0xB300: "JP V0, 0x300",
+		0xD125: "DRW V1, V2, 5",
+		0xE39E: "SKP V3",
+		0xF000: "LD I, long",
+		0xF201: "PLANE 2",
+		0xF165: "LD V1, [I]",
+		0xF775: "LD R, V7",
+		0xFFFF: "DW 0xFFFF",
+	}
+	for opcode, want := range tests {
+		if got := Mnemonic(opcode); got != want {
+			t.Errorf("0x%04X: expected %q, got %q", opcode, want, got)
+		}
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	code := []byte{0xF0, 0x00, 0x12, 0x34, 0x00, 0xE0, 0x12}
+	lines := Disassemble(code, 0x200, 0x200, 10)
+	want := []Line{
+		{0x200, 0xF000, "LD I, 0x1234"},
+		{0x204, 0x00E0, "CLS"},
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("Expected %v, got %v", want, lines)
+	}
+}
+
+func TestNamed(t *testing.T) {
+	format := func(address uint16) string {
+		if address == 0x2A0 {
+			return "draw-paddle"
+		}
+		return fmt.Sprintf("0x%03X", address)
+	}
+	code := []byte{0x22, 0xA0, 0xA2, 0xA4, 0xB2, 0xA0, 0xF0, 0x00, 0x02, 0xA0, 0x60, 0x01}
+	want := []string{"CALL draw-paddle", "LD I, 0x2A4", "JP V0, draw-paddle", "LD I, draw-paddle", "LD V0, 0x01"}
+	for i, line := range Disassemble(code, 0x200, 0x200, 5) {
+		if got := line.Named(format); got != want[i] {
+			t.Errorf("Expected %q, got %q", want[i], got)
+		}
+	}
+}
+
+func TestTrace(t *testing.T) {
+	code := []byte{
+		0x22, 0x0A, // 200 call 20A
+		0x30, 0x01, // 202 skip if V0 == 1
+		0xF0, 0x00, 0x00, 0x00, // 204 long load, skipped as a whole
+		0x12, 0x0E, // 208 jump 20E
+		0x00, 0xEE, // 20A return
+		0xFF, 0xFF, // 20C data
+		0xB2, 0x00, // 20E computed jump
+		0x00, 0xE0, // 210 never reached
+	}
+	want := []uint16{0x200, 0x202, 0x204, 0x208, 0x20A, 0x20E}
+	if got := Trace(code, 0x200); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %X, got %X", want, got)
+	}
+}
@@ -10,8 +10,26 @@ const (
 	fps    = 60
 )
 
+// Width and Height are the size of the screen in pixels.
+const (
+	Width  = width
+	Height = height
+)
+
+// EdgeMode decides what happens to the part of a sprite that runs past the
+// right or bottom edge of the screen. The starting coordinate always wraps.
+type EdgeMode int
+
+const (
+	// Clip drops pixels past the edge, as the COSMAC VIP interpreter did.
+	Clip EdgeMode = iota
+	// Wrap draws pixels past the edge on the opposite side of the screen.
+	Wrap
+)
+
 type Display struct {
 	pixels *[width * height]bool
+	Edge   EdgeMode
 }
 
 func (d *Display) Clear() {
@@ -29,12 +47,48 @@ func (d *Display) SetPixel(x, y int, on bool) {
 func (d *Display) IsPixelOn(x, y int) bool {
 
 	if x >= 0 && x < width && y >= 0 && y < height {
-		index := x + y*64%2048
-		return d.pixels[index]
+		return d.pixels[y*width+x]
 	}
 	return false
 }
 
+// DrawSprite XORs an 8 pixel wide sprite onto the screen with its top left
+// corner at (x, y), one byte per row, most significant bit leftmost. The
+// starting coordinate wraps around the screen; the rest of the sprite is
+// clipped or wrapped according to Edge. It reports whether any pixel was
+// switched from on to off.
+func (d *Display) DrawSprite(x, y int, rows []byte) (collided bool) {
+	x = ((x % width) + width) % width
+	y = ((y % height) + height) % height
+	for row, bits := range rows {
+		py := y + row
+		if py >= height {
+			if d.Edge == Clip {
+				break
+			}
+			py %= height
+		}
+		for col := 0; col < 8; col++ {
+			if bits&(0x80>>col) == 0 {
+				continue
+			}
+			px := x + col
+			if px >= width {
+				if d.Edge == Clip {
+					break
+				}
+				px %= width
+			}
+			i := py*width + px
+			if d.pixels[i] {
+				collided = true
+			}
+			d.pixels[i] = !d.pixels[i]
+		}
+	}
+	return collided
+}
+
 func (d *Display) Render() *[2048]bool {
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
@@ -50,6 +104,16 @@ func (d *Display) Render() *[2048]bool {
 	return d.pixels
 }
 
+// Snapshot returns a copy of the framebuffer.
+func (d *Display) Snapshot() [width * height]bool {
+	return *d.pixels
+}
+
+// Restore overwrites the framebuffer with a previous Snapshot.
+func (d *Display) Restore(pixels [width * height]bool) {
+	*d.pixels = pixels
+}
+
 func NewDisplay() *Display {
 	return &Display{
 		pixels: &[width * height]bool{},
@@ -64,3 +64,126 @@ func TestRender(t *testing.T) {
 		t.Errorf("Expected array:\n%v\nGot array:\n%v", expectedPixels, *actualPixels)
 	}
 }
+
+func TestSnapshotRestore(t *testing.T) {
+	display := NewDisplay()
+	display.SetPixel(5, 5, true)
+	snap := display.Snapshot()
+
+	display.Clear()
+	display.Restore(snap)
+
+	if !display.IsPixelOn(5, 5) {
+		t.Errorf("Expected pixel at (5, 5) to be restored")
+	}
+}
+
+func TestDrawSprite(t *testing.T) {
+	type pixel struct{ x, y int }
+	tests := []struct {
+		name     string
+		edge     EdgeMode
+		before   []pixel // pixels switched on before drawing
+		x, y     int
+		rows     []byte
+		on       []pixel // pixels expected on afterwards
+		collided bool
+	}{
+		{
+			name: "draws onto an empty screen",
+			x:    2, y: 3,
+			rows: []byte{0x81},
+			on:   []pixel{{2, 3}, {9, 3}},
+		},
+		{
+			name:     "erases lit pixels and reports a collision",
+			before:   []pixel{{0, 0}, {1, 0}},
+			rows:     []byte{0x80},
+			on:       []pixel{{1, 0}},
+			collided: true,
+		},
+		{
+			name:   "lighting a pixel next to a lit one is not a collision",
+			before: []pixel{{1, 0}},
+			rows:   []byte{0x80},
+			on:     []pixel{{0, 0}, {1, 0}},
+		},
+		{
+			name: "starting coordinate wraps",
+			x:    64 + 5, y: 32 + 1,
+			rows: []byte{0x80},
+			on:   []pixel{{5, 1}},
+		},
+		{
+			name: "clips at the right edge",
+			x:    62, y: 0,
+			rows: []byte{0xF0},
+			on:   []pixel{{62, 0}, {63, 0}},
+		},
+		{
+			name: "clips at the bottom edge",
+			x:    0, y: 31,
+			rows: []byte{0x80, 0x80},
+			on:   []pixel{{0, 31}},
+		},
+		{
+			name: "wraps at the right edge",
+			edge: Wrap,
+			x:    62, y: 0,
+			rows: []byte{0xF0},
+			on:   []pixel{{62, 0}, {63, 0}, {0, 0}, {1, 0}},
+		},
+		{
+			name: "wraps at the bottom edge",
+			edge: Wrap,
+			x:    0, y: 31,
+			rows: []byte{0x80, 0x80},
+			on:   []pixel{{0, 31}, {0, 0}},
+		},
+		{
+			name:   "collision on a wrapped pixel",
+			edge:   Wrap,
+			before: []pixel{{0, 0}},
+			x:      63, y: 0,
+			rows:     []byte{0xC0},
+			on:       []pixel{{63, 0}},
+			collided: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			display := NewDisplay()
+			display.Edge = tt.edge
+			for _, p := range tt.before {
+				display.SetPixel(p.x, p.y, true)
+			}
+
+			if collided := display.DrawSprite(tt.x, tt.y, tt.rows); collided != tt.collided {
+				t.Errorf("Expected collided to be %v", tt.collided)
+			}
+
+			expected := [width * height]bool{}
+			for _, p := range tt.on {
+				expected[p.y*width+p.x] = true
+			}
+			if display.Snapshot() != expected {
+				for y := 0; y < height; y++ {
+					for x := 0; x < width; x++ {
+						if display.IsPixelOn(x, y) != expected[y*width+x] {
+							t.Errorf("Expected pixel at (%d, %d) to be %v", x, y, expected[y*width+x])
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestIsPixelOnIndexing(t *testing.T) {
+	display := NewDisplay()
+	display.SetPixel(63, 31, true)
+	if !display.IsPixelOn(63, 31) || display.IsPixelOn(31, 63) {
+		t.Errorf("Expected only pixel (63, 31) to be on")
+	}
+}
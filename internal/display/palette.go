@@ -0,0 +1,135 @@
+package display
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RGB is a 24-bit colour.
+type RGB struct {
+	R, G, B uint8
+}
+
+// ParseRGB reads a colour written as six hex digits, with or without a
+// leading #.
+func ParseRGB(s string) (RGB, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	if err != nil || len(strings.TrimPrefix(s, "#")) != 6 {
+		return RGB{}, fmt.Errorf("bad colour %q, want RRGGBB", s)
+	}
+	return RGB{uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+}
+
+// Palette is the colours of lit and unlit pixels.
+type Palette struct {
+	On, Off RGB
+}
+
+// Palettes are the built-in palettes, by name.
+var Palettes = map[string]Palette{
+	"white": {On: RGB{0xFF, 0xFF, 0xFF}, Off: RGB{0x00, 0x00, 0x00}},
+	"amber": {On: RGB{0xFF, 0xB0, 0x00}, Off: RGB{0x1A, 0x10, 0x00}},
+	"green": {On: RGB{0x33, 0xFF, 0x66}, Off: RGB{0x05, 0x1A, 0x0A}},
+	"lcd":   {On: RGB{0x0F, 0x38, 0x0F}, Off: RGB{0x9B, 0xBC, 0x0F}},
+}
+
+// PaletteNames returns the names of the built-in palettes in order.
+func PaletteNames() []string {
+	names := make([]string, 0, len(Palettes))
+	for name := range Palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParsePalette reads a built-in palette's name or two colours, lit and
+// unlit, such as "ffb000,1a1000".
+func ParsePalette(s string) (Palette, error) {
+	if p, ok := Palettes[s]; ok {
+		return p, nil
+	}
+	on, off, ok := strings.Cut(s, ",")
+	if !ok {
+		return Palette{}, fmt.Errorf("unknown palette %q, want one of %s or two colours such as ffb000,1a1000", s, strings.Join(PaletteNames(), ", "))
+	}
+	var p Palette
+	var err error
+	if p.On, err = ParseRGB(on); err != nil {
+		return Palette{}, err
+	}
+	if p.Off, err = ParseRGB(off); err != nil {
+		return Palette{}, err
+	}
+	return p, nil
+}
+
+// ColorDepth is how many colours a terminal can show.
+type ColorDepth int
+
+const (
+	// NoColor leaves the terminal's own colours alone.
+	NoColor ColorDepth = iota
+	// Color256 uses the xterm 256-colour palette.
+	Color256
+	// TrueColor uses 24-bit colour.
+	TrueColor
+)
+
+// ParseColorDepth reads "none", "256" or "truecolor".
+func ParseColorDepth(s string) (ColorDepth, error) {
+	switch s {
+	case "none":
+		return NoColor, nil
+	case "256":
+		return Color256, nil
+	case "truecolor", "24bit":
+		return TrueColor, nil
+	}
+	return NoColor, fmt.Errorf("unknown colour depth %q, want none, 256 or truecolor", s)
+}
+
+// DetectColorDepth guesses what the terminal supports from the COLORTERM
+// and TERM environment variables, read with getenv.
+func DetectColorDepth(getenv func(string) string) ColorDepth {
+	switch {
+	case getenv("COLORTERM") == "truecolor" || getenv("COLORTERM") == "24bit":
+		return TrueColor
+	case strings.Contains(getenv("TERM"), "256color"):
+		return Color256
+	}
+	return NoColor
+}
+
+// xterm256 returns the colour in the xterm 256-colour palette nearest to c,
+// from the 6x6x6 colour cube or the grey ramp.
+func xterm256(c RGB) int {
+	level := func(v uint8) int {
+		if v < 48 {
+			return 0
+		}
+		if v < 115 {
+			return 1
+		}
+		return int(v-35) / 40
+	}
+	steps := [6]int{0, 95, 135, 175, 215, 255}
+	r, g, b := level(c.R), level(c.G), level(c.B)
+	cube := 16 + 36*r + 6*g + b
+	cubeDist := distance(c, steps[r], steps[g], steps[b])
+
+	average := (int(c.R) + int(c.G) + int(c.B)) / 3
+	grey := min(max((average-3)/10, 0), 23)
+	v := 8 + 10*grey
+	if distance(c, v, v, v) < cubeDist {
+		return 232 + grey
+	}
+	return cube
+}
+
+func distance(c RGB, r, g, b int) int {
+	dr, dg, db := int(c.R)-r, int(c.G)-g, int(c.B)-b
+	return dr*dr + dg*dg + db*db
+}
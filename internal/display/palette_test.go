@@ -0,0 +1,58 @@
+package display
+
+import "testing"
+
+func TestParsePalette(t *testing.T) {
+	p, err := ParsePalette("amber")
+	if err != nil || p != Palettes["amber"] {
+		t.Errorf("ParsePalette(amber) = %v, %v", p, err)
+	}
+	p, err = ParsePalette("#ffb000,1A1000")
+	want := Palette{On: RGB{0xFF, 0xB0, 0x00}, Off: RGB{0x1A, 0x10, 0x00}}
+	if err != nil || p != want {
+		t.Errorf("ParsePalette(#ffb000,1A1000) = %v, %v, want %v", p, err, want)
+	}
+	for _, s := range []string{"", "purple", "ffb000", "ffb000,1a10", "ffb000,zz1000"} {
+		if _, err := ParsePalette(s); err == nil {
+			t.Errorf("ParsePalette(%q) succeeded", s)
+		}
+	}
+}
+
+func TestXterm256(t *testing.T) {
+	tests := []struct {
+		c    RGB
+		want int
+	}{
+		{RGB{0, 0, 0}, 16},
+		{RGB{0xFF, 0xFF, 0xFF}, 231},
+		{RGB{0xFF, 0, 0}, 196},
+		{RGB{0xFF, 0xAF, 0}, 214},
+		{RGB{0x80, 0x80, 0x80}, 244},
+	}
+	for _, tt := range tests {
+		if got := xterm256(tt.c); got != tt.want {
+			t.Errorf("xterm256(%v) = %d, want %d", tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestDetectColorDepth(t *testing.T) {
+	tests := []struct {
+		colorterm, term string
+		want            ColorDepth
+	}{
+		{"truecolor", "xterm-256color", TrueColor},
+		{"24bit", "xterm", TrueColor},
+		{"", "xterm-256color", Color256},
+		{"", "xterm", NoColor},
+		{"", "", NoColor},
+	}
+	for _, tt := range tests {
+		env := map[string]string{"COLORTERM": tt.colorterm, "TERM": tt.term}
+		got := DetectColorDepth(func(key string) string { return env[key] })
+		if got != tt.want {
+			t.Errorf("DetectColorDepth(COLORTERM=%q TERM=%q) = %v, want %v", tt.colorterm, tt.term, got, tt.want)
+		}
+	}
+}
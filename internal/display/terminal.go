@@ -0,0 +1,240 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Mode is how a Terminal draws pixels with characters.
+type Mode int
+
+const (
+	// FullBlock draws each pixel as two full blocks side by side, so that
+	// pixels come out about square: 128x32 characters.
+	FullBlock Mode = iota
+	// HalfBlock draws two pixels, one above the other, in each character
+	// with half blocks: 64x16 characters.
+	HalfBlock
+	// Braille draws a block of 2x4 pixels in each braille character: 32x8
+	// characters.
+	Braille
+)
+
+var modeNames = [...]string{FullBlock: "full", HalfBlock: "half", Braille: "braille"}
+
+func (m Mode) String() string {
+	return modeNames[m]
+}
+
+// ParseMode reads "full", "half" or "braille".
+func ParseMode(s string) (Mode, error) {
+	for m, name := range modeNames {
+		if s == name {
+			return Mode(m), nil
+		}
+	}
+	return FullBlock, fmt.Errorf("unknown screen mode %q, want full, half or braille", s)
+}
+
+// size returns how many cells the screen takes in mode m.
+func (m Mode) size() (cols, rows int) {
+	switch m {
+	case FullBlock:
+		return width, height
+	case HalfBlock:
+		return width, height / 2
+	}
+	return width / 2, height / 4
+}
+
+// cellWidth returns how many characters wide each cell is in mode m.
+func (m Mode) cellWidth() int {
+	if m == FullBlock {
+		return 2
+	}
+	return 1
+}
+
+// termCell is one character position of the screen on a terminal. Full
+// block cells are two characters wide.
+type termCell struct {
+	r      rune
+	fg, bg RGB
+}
+
+// Terminal draws the screen on a terminal in place, rewriting only the
+// characters that changed since the last frame, so it does not flicker or
+// scroll.
+type Terminal struct {
+	Mode    Mode
+	Colors  ColorDepth
+	Palette Palette
+	// Size, if set, returns the size of the terminal, so Draw can fall back
+	// to a denser mode when the screen does not fit. An error is taken to
+	// mean the size is unknown.
+	Size func() (width, height int, err error)
+	// Reserve is how many rows to keep free under the screen for other
+	// output, such as stats.
+	Reserve int
+
+	w             io.Writer
+	cells         []termCell // what the terminal shows, or nil to redraw it all
+	mode          Mode       // the mode cells are in
+	width, height int        // the terminal size cells were drawn at
+	tooSmall      bool       // the terminal shows a message instead
+}
+
+// NewTerminal returns a renderer writing to w, in half blocks with the
+// terminal's own colours.
+func NewTerminal(w io.Writer) *Terminal {
+	return &Terminal{Mode: HalfBlock, Palette: Palettes["white"], w: w}
+}
+
+// Draw shows the screen of d. If the terminal is too small for Mode it
+// uses the first denser mode that fits, and if none does it says so
+// instead. The cursor is left on the row under the screen.
+func (t *Terminal) Draw(d *Display) error {
+	width, height := t.terminalSize()
+	mode, ok := t.fit(width, height)
+	if width != t.width || height != t.height || mode != t.mode {
+		t.cells, t.width, t.height, t.mode = nil, width, height, mode
+	}
+	var b strings.Builder
+	if !ok {
+		if t.tooSmall && t.cells != nil {
+			return nil
+		}
+		cols, rows := Braille.size()
+		fmt.Fprintf(&b, "\x1b[0m\x1b[2J\x1b[HThe terminal is %dx%d; the screen needs at least %dx%d.\r\n", width, height, cols, rows+1+t.Reserve)
+		// An empty screen marks the message as shown.
+		t.cells, t.tooSmall = []termCell{}, true
+		_, err := io.WriteString(t.w, b.String())
+		return err
+	}
+	if t.cells == nil || t.tooSmall {
+		b.WriteString("\x1b[0m\x1b[2J")
+		t.cells, t.tooSmall = nil, false
+	}
+
+	cols, rows := mode.size()
+	next := make([]termCell, cols*rows)
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			next[y*cols+x] = t.cell(d, mode, x, y)
+		}
+	}
+	cellWidth := mode.cellWidth()
+	var pen *termCell // the colours last selected
+	lastX, lastY := -2, -1
+	for i, c := range next {
+		if t.cells != nil && t.cells[i] == c {
+			continue
+		}
+		x, y := i%cols, i/cols
+		if x != lastX+1 || y != lastY {
+			fmt.Fprintf(&b, "\x1b[%d;%dH", y+1, x*cellWidth+1)
+		}
+		if t.Colors != NoColor && (pen == nil || pen.fg != c.fg || pen.bg != c.bg) {
+			b.WriteString(t.sgr(c.fg, c.bg))
+			pen = &next[i]
+		}
+		for range cellWidth {
+			b.WriteRune(c.r)
+		}
+		lastX, lastY = x, y
+	}
+	if pen != nil {
+		b.WriteString("\x1b[0m")
+	}
+	fmt.Fprintf(&b, "\x1b[%d;1H", rows+1)
+	t.cells = next
+	_, err := io.WriteString(t.w, b.String())
+	return err
+}
+
+// terminalSize returns the size of the terminal, or zero if it is not
+// known.
+func (t *Terminal) terminalSize() (width, height int) {
+	if t.Size == nil {
+		return 0, 0
+	}
+	width, height, err := t.Size()
+	if err != nil {
+		return 0, 0
+	}
+	return width, height
+}
+
+// fit picks the mode to draw in on a terminal of the given size, which is
+// zero if it is not known.
+func (t *Terminal) fit(width, height int) (Mode, bool) {
+	if width == 0 && height == 0 {
+		return t.Mode, true
+	}
+	for m := t.Mode; m <= Braille; m++ {
+		cols, rows := m.size()
+		if cols*m.cellWidth() <= width && rows+1+t.Reserve <= height {
+			return m, true
+		}
+	}
+	return t.Mode, false
+}
+
+// cell works out the character at (x, y) in mode m.
+func (t *Terminal) cell(d *Display, m Mode, x, y int) termCell {
+	colour := func(on bool) RGB {
+		if on {
+			return t.Palette.On
+		}
+		return t.Palette.Off
+	}
+	colored := t.Colors != NoColor
+	switch m {
+	case FullBlock:
+		on := d.IsPixelOn(x, y)
+		if colored {
+			return termCell{r: ' ', fg: colour(on), bg: colour(on)}
+		}
+		if on {
+			return termCell{r: '█'}
+		}
+		return termCell{r: ' '}
+	case HalfBlock:
+		upper, lower := d.IsPixelOn(x, 2*y), d.IsPixelOn(x, 2*y+1)
+		if colored {
+			return termCell{r: '▀', fg: colour(upper), bg: colour(lower)}
+		}
+		return termCell{r: []rune(" ▄▀█")[btoi(upper)<<1|btoi(lower)]}
+	}
+	// Braille dots are numbered down the left column and then the right,
+	// with the bottom row added last.
+	dots := [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+	r := rune(0x2800)
+	for row := range 4 {
+		for col := range 2 {
+			if d.IsPixelOn(2*x+col, 4*y+row) {
+				r |= dots[row][col]
+			}
+		}
+	}
+	if colored {
+		return termCell{r: r, fg: t.Palette.On, bg: t.Palette.Off}
+	}
+	return termCell{r: r}
+}
+
+// sgr selects fg and bg at the terminal's colour depth.
+func (t *Terminal) sgr(fg, bg RGB) string {
+	if t.Colors == Color256 {
+		return fmt.Sprintf("\x1b[38;5;%d;48;5;%dm", xterm256(fg), xterm256(bg))
+	}
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%d;48;2;%d;%d;%dm", fg.R, fg.G, fg.B, bg.R, bg.G, bg.B)
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,125 @@
+package display
+
+import (
+	"strings"
+	"testing"
+)
+
+// drawn draws d and returns what was written.
+func drawn(t *testing.T, term *Terminal, out *strings.Builder, d *Display) string {
+	t.Helper()
+	out.Reset()
+	if err := term.Draw(d); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestParseMode(t *testing.T) {
+	for _, m := range []Mode{FullBlock, HalfBlock, Braille} {
+		if got, err := ParseMode(m.String()); err != nil || got != m {
+			t.Errorf("ParseMode(%q) = %v, %v", m.String(), got, err)
+		}
+	}
+	if _, err := ParseMode("quarter"); err == nil {
+		t.Error("ParseMode(quarter) succeeded")
+	}
+}
+
+func TestTerminalModes(t *testing.T) {
+	d := NewDisplay()
+	d.SetPixel(0, 0, true)
+	d.SetPixel(1, 1, true)
+	d.SetPixel(1, 3, true)
+	tests := []struct {
+		mode Mode
+		want string // the start of the first line
+	}{
+		{FullBlock, "\x1b[1;1H██    "},
+		{HalfBlock, "\x1b[1;1H▀▄ "},
+		{Braille, "\x1b[1;1H⢑⠀"},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		term := NewTerminal(&out)
+		term.Mode = tt.mode
+		got := drawn(t, term, &out, d)
+		if !strings.HasPrefix(got, "\x1b[0m\x1b[2J"+tt.want) {
+			t.Errorf("%v mode drew %q, want it to start %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestTerminalDrawsOnlyChanges(t *testing.T) {
+	var out strings.Builder
+	term := NewTerminal(&out)
+	d := NewDisplay()
+	drawn(t, term, &out, d)
+
+	if got := drawn(t, term, &out, d); got != "\x1b[17;1H" {
+		t.Errorf("redrawing the same screen wrote %q, want only the cursor moved", got)
+	}
+	d.SetPixel(10, 5, true)
+	d.SetPixel(11, 5, true)
+	d.SetPixel(40, 31, true)
+	want := "\x1b[3;11H▄▄\x1b[16;41H▄\x1b[17;1H"
+	if got := drawn(t, term, &out, d); got != want {
+		t.Errorf("changing three pixels wrote %q, want %q", got, want)
+	}
+}
+
+func TestTerminalColors(t *testing.T) {
+	var out strings.Builder
+	term := NewTerminal(&out)
+	term.Colors = TrueColor
+	term.Palette = Palettes["amber"]
+	d := NewDisplay()
+	drawn(t, term, &out, d)
+
+	d.SetPixel(0, 0, true)
+	want := "\x1b[1;1H\x1b[38;2;255;176;0;48;2;26;16;0m▀\x1b[0m\x1b[17;1H"
+	if got := drawn(t, term, &out, d); got != want {
+		t.Errorf("truecolor wrote %q, want %q", got, want)
+	}
+
+	term.Colors = Color256
+	d.SetPixel(0, 0, false)
+	d.SetPixel(0, 1, true)
+	want = "\x1b[1;1H\x1b[38;5;233;48;5;214m▀\x1b[0m\x1b[17;1H"
+	if got := drawn(t, term, &out, d); got != want {
+		t.Errorf("256 colours wrote %q, want %q", got, want)
+	}
+}
+
+func TestTerminalFallback(t *testing.T) {
+	var out strings.Builder
+	term := NewTerminal(&out)
+	term.Mode = FullBlock
+	term.Reserve = 1
+	width, height := 80, 24
+	term.Size = func() (int, int, error) { return width, height, nil }
+	d := NewDisplay()
+	d.SetPixel(63, 31, true)
+
+	// 128 columns do not fit in 80, so half blocks are used.
+	if got := drawn(t, term, &out, d); !strings.HasSuffix(got, "▄\x1b[17;1H") {
+		t.Errorf("80x24 drew %q, want half blocks", got)
+	}
+	width, height = 40, 12
+	if got := drawn(t, term, &out, d); !strings.HasPrefix(got, "\x1b[0m\x1b[2J") || !strings.HasSuffix(got, "⠀⢀\x1b[9;1H") {
+		t.Errorf("40x12 drew %q, want the screen cleared and braille", got)
+	}
+	width, height = 20, 5
+	got := drawn(t, term, &out, d)
+	if !strings.Contains(got, "The terminal is 20x5; the screen needs at least 32x10.") {
+		t.Errorf("20x5 drew %q, want a message", got)
+	}
+	if got := drawn(t, term, &out, d); got != "" {
+		t.Errorf("20x5 again drew %q, want nothing", got)
+	}
+	width, height = 200, 50
+	if got := drawn(t, term, &out, d); !strings.HasSuffix(got, "  ██\x1b[33;1H") {
+		t.Errorf("200x50 drew %q, want full blocks", got)
+	}
+
+}
@@ -0,0 +1,51 @@
+package emulator
+
+import (
+	"fmt"
+
+	"github.com/jsutcodes/chip8-goemu/internal/cheat"
+	"github.com/jsutcodes/chip8-goemu/internal/cpu"
+)
+
+// SetCheats applies a set of cheats from now on, replacing any before it.
+// Patch codes take effect on the loaded ROM straight away. Passing the
+// current set again picks up cheats added to it.
+func (emu *Emulator) SetCheats(s *cheat.Set) {
+	if emu.Cheats != nil && emu.Cheats != s {
+		for _, c := range emu.Cheats.Cheats {
+			c.Enabled = false
+		}
+		emu.Cheats.Patch(emu.RAM)
+	}
+	emu.Cheats = s
+	if s == nil {
+		return
+	}
+	if s.HasRegisterCodes() && !emu.cheatHook {
+		emu.CPU.OnExecute(func(c *cpu.CPU, pc uint16) {
+			if emu.Cheats != nil {
+				emu.Cheats.Executed(c, pc)
+			}
+		})
+		emu.cheatHook = true
+	}
+	s.Patch(emu.RAM)
+}
+
+// EnableCheat turns the i'th cheat on or off while the program runs.
+func (emu *Emulator) EnableCheat(i int, on bool) error {
+	if emu.Cheats == nil || i < 0 || i >= len(emu.Cheats.Cheats) {
+		return fmt.Errorf("no cheat %d", i)
+	}
+	emu.Cheats.Cheats[i].Enabled = on
+	emu.Cheats.Patch(emu.RAM)
+	return nil
+}
+
+// patchCheats applies the patch codes to a freshly loaded ROM.
+func (emu *Emulator) patchCheats() {
+	if emu.Cheats != nil {
+		emu.Cheats.Loaded()
+		emu.Cheats.Patch(emu.RAM)
+	}
+}
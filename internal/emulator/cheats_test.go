@@ -0,0 +1,47 @@
+package emulator
+
+import (
+	"testing"
+
+	"github.com/jsutcodes/chip8-goemu/internal/cheat"
+)
+
+func TestSetCheats(t *testing.T) {
+	emu := NewEmulator()
+	emu.SetInstructionsPerFrame(4)
+	emu.RAM.LoadROM([]byte{
+		0x60, 0x01, // V0 = 1
+		0x61, 0x01, // V1 = 1
+		0x12, 0x00, // jump 0x200
+	})
+	s := &cheat.Set{Cheats: []*cheat.Cheat{
+		{Name: "patch", Enabled: true, Codes: []cheat.Code{{Kind: cheat.Patch, Address: 0x201, Value: 0x05}}},
+		{Name: "register", Enabled: true, Codes: []cheat.Code{{Kind: cheat.Register, Address: 0x202, Register: 1, Value: 7}}},
+		{Name: "write", Enabled: true, Codes: []cheat.Code{{Kind: cheat.Write, Address: 0x300, Value: 3}}},
+	}}
+	emu.SetCheats(s)
+
+	emu.Frame()
+	if emu.CPU.V[0] != 5 || emu.CPU.V[1] != 7 {
+		t.Errorf("Expected V0=5 from the patch and V1=7 from the register code, got %d and %d", emu.CPU.V[0], emu.CPU.V[1])
+	}
+	if b, _ := emu.RAM.ReadByte(0x300); b != 3 {
+		t.Errorf("Expected the write code to apply each frame, got %d", b)
+	}
+
+	if err := emu.EnableCheat(0, false); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := emu.RAM.ReadByte(0x201); b != 0x01 {
+		t.Errorf("Expected disabling the patch to restore the ROM, got %02X", b)
+	}
+	if err := emu.EnableCheat(3, true); err == nil {
+		t.Errorf("Expected an error for a missing cheat")
+	}
+
+	emu.EnableCheat(0, true)
+	emu.SetCheats(nil)
+	if b, _ := emu.RAM.ReadByte(0x201); b != 0x01 {
+		t.Errorf("Expected removing the cheats to undo their patches, got %02X", b)
+	}
+}
@@ -0,0 +1,61 @@
+package emulator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jsutcodes/chip8-goemu/internal/memory"
+)
+
+// MemoryDump is a file kept up to date with the contents of memory, so that
+// an external viewer can follow a running program, for example with
+//
+//	watch -n 1 cat memory.dump
+type MemoryDump struct {
+	Path   string // empty turns dumping off
+	Format memory.Format
+	Range  memory.Range // the zero Range dumps all of memory
+	// Every rewrites the dump after this many frames. With 0 it is only
+	// written when Run starts.
+	Every int
+	// OnBreakpoint also rewrites the dump whenever the CPU stops at a
+	// breakpoint.
+	OnBreakpoint bool
+}
+
+// DefaultMemoryDump is the xxd dump of all of memory written to
+// memory.dump when Run starts.
+func DefaultMemoryDump() MemoryDump {
+	return MemoryDump{Path: "memory.dump", Format: memory.Xxd}
+}
+
+// WriteMemoryDump writes the dump Dump describes now. The file is replaced
+// in one step, so a viewer never sees half a dump.
+func (emu *Emulator) WriteMemoryDump() error {
+	d := emu.Dump
+	if d.Path == "" {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(d.Path), ".memory-dump-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := emu.RAM.Dump(tmp, d.Format, d.Range); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), d.Path)
+}
+
+// refreshMemoryDump rewrites the dump, reporting rather than returning any
+// failure since it happens while the program runs.
+func (emu *Emulator) refreshMemoryDump() {
+	if err := emu.WriteMemoryDump(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write memory dump: %v\n", err)
+	}
+}
@@ -0,0 +1,50 @@
+package emulator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jsutcodes/chip8-goemu/internal/memory"
+)
+
+func TestMemoryDumpRefresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.dump")
+	emu := NewEmulator()
+	emu.Dump = MemoryDump{Path: path, Format: memory.Xxd, Range: memory.Range{Start: 0x300, End: 0x301}, Every: 2}
+	emu.SetInstructionsPerFrame(1)
+	emu.RAM.LoadROM([]byte{
+		0x60, 0x07, // V0 = 7
+		0xA3, 0x00, // I = 0x300
+		0xF0, 0x33, // BCD of V0 at I
+		0x12, 0x06, // jump to self
+	})
+
+	emu.Frame()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Expected no dump after one frame, got %v", err)
+	}
+	emu.Frame()
+	if data, _ := os.ReadFile(path); !strings.HasPrefix(string(data), "00000300: 00") {
+		t.Errorf("Expected a dump after two frames, got %q", data)
+	}
+
+	emu.Dump.Every = 0
+	emu.Dump.OnBreakpoint = true
+	emu.CPU.SetBreakpoint(0x206)
+	emu.Frame()
+	if data, _ := os.ReadFile(path); !strings.HasPrefix(string(data), "00000300: 00") {
+		t.Errorf("Expected the dump to wait for the breakpoint, got %q", data)
+	}
+	emu.SetInstructionsPerFrame(5)
+	emu.CPU.V[0] = 200
+	emu.CPU.PC = 0x204
+	emu.Frame()
+	if data, _ := os.ReadFile(path); !strings.HasPrefix(string(data), "00000300: 02") {
+		t.Errorf("Expected the dump refreshed at the breakpoint, got %q", data)
+	}
+	if emu.CPU.CycleCount != 8 {
+		t.Errorf("Expected the frame to carry on past the breakpoint, got %d instructions", emu.CPU.CycleCount)
+	}
+}
@@ -2,35 +2,28 @@ package emulator
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/jsutcodes/chip8-goemu/internal/cdp1802"
+	"github.com/jsutcodes/chip8-goemu/internal/cheat"
 	"github.com/jsutcodes/chip8-goemu/internal/cpu"
+	"github.com/jsutcodes/chip8-goemu/internal/font"
 	"github.com/jsutcodes/chip8-goemu/internal/memory"
+	"github.com/jsutcodes/chip8-goemu/internal/platform"
+	"github.com/jsutcodes/chip8-goemu/internal/romdb"
+	"github.com/jsutcodes/chip8-goemu/internal/romfile"
+	"github.com/jsutcodes/chip8-goemu/internal/symbols"
 	"github.com/jsutcodes/chip8-goemu/internal/timer"
 
 	"github.com/jsutcodes/chip8-goemu/internal/display"
 	"github.com/jsutcodes/chip8-goemu/internal/input"
-)
 
-var chip8Fontset = [80]byte{
-	0xF0, 0x90, 0x90, 0x90, 0xF0, // 0
-	0x20, 0x60, 0x20, 0x20, 0x70, // 1
-	0xF0, 0x10, 0xF0, 0x80, 0xF0, // 2
-	0xF0, 0x10, 0xF0, 0x10, 0xF0, // 3
-	0x90, 0x90, 0xF0, 0x10, 0x10, // 4
-	0xF0, 0x80, 0xF0, 0x10, 0xF0, // 5
-	0xF0, 0x80, 0xF0, 0x90, 0xF0, // 6
-	0xF0, 0x10, 0x20, 0x40, 0x40, // 7
-	0xF0, 0x90, 0xF0, 0x90, 0xF0, // 8
-	0xF0, 0x90, 0xF0, 0x10, 0xF0, // 9
-	0xF0, 0x90, 0xF0, 0x90, 0x90, // A
-	0xE0, 0x90, 0xE0, 0x90, 0xE0, // B
-	0xF0, 0x80, 0x80, 0x80, 0xF0, // C
-	0xE0, 0x90, 0x90, 0x90, 0xE0, // D
-	0xF0, 0x80, 0xF0, 0x80, 0xF0, // E
-	0xF0, 0x80, 0xF0, 0x80, 0x80, // F
-}
+	"github.com/veandco/go-sdl2/sdl"
+)
 
 const CLOCK_SPEED = 700
 
@@ -44,7 +37,74 @@ type Emulator struct {
 	Input   *input.Keypad
 	Display *display.Display
 	Timer   *timer.Timer
-	running bool
+	Speed   Speed
+	Profile platform.Profile
+	Font    font.Font   // set by SetFont
+	running atomic.Bool // Stop may clear it from another goroutine
+
+	// Poll, if set, is called once per tick of Run so a front end can feed
+	// key events into Input.
+	Poll func()
+	// Events, if set, is drained once per tick of Run. It returns the next
+	// pending SDL event, or nil when there are none, as sdl.PollEvent does.
+	// Keyboard events drive the speed hotkeys and the keypad.
+	Events func() sdl.Event
+	// ShowStats prints the latest Stats under the screen on every redraw.
+	ShowStats bool
+	// StatsInterval, if non-zero, prints the latest Stats to stderr this often.
+	StatsInterval time.Duration
+	// CycleAccurate budgets each frame in machine cycles using the profile's
+	// timing model, instead of a fixed number of instructions. It has no
+	// effect on profiles without a timing model.
+	CycleAccurate bool
+	// FlagsDir is where the RPL user flags of each ROM are saved, in a file
+	// named after the ROM's SHA-1. Empty, as NewEmulator leaves it, turns
+	// persistence off.
+	FlagsDir string
+	// DetectPlatform looks each loaded ROM up in the ROM database and
+	// switches to the profile it recommends.
+	DetectPlatform bool
+	// ChooseROM picks which ROM to run from an archive that holds several.
+	// If it is nil, such archives fail to load.
+	ChooseROM func(roms []romfile.ROM) (int, error)
+	// Dump is the memory dump kept for external viewers.
+	Dump MemoryDump
+	// PauseAtBreakpoints pauses emulation whenever the CPU reaches a
+	// breakpoint, so a debugger can take over. Otherwise breakpoints only
+	// refresh the memory dump.
+	PauseAtBreakpoints bool
+	// OnBreakpoint, if set, is called whenever the CPU stops at a breakpoint.
+	OnBreakpoint func(pc uint16)
+	// Cheats are the cheat codes in use, set by SetCheats.
+	Cheats *cheat.Set
+	// Symbols names addresses in the loaded ROM for traces and debuggers.
+	// It may be nil.
+	Symbols *symbols.Table
+	// Terminal, if set, redraws the screen in place on a terminal instead of
+	// printing it line by line.
+	Terminal *display.Terminal
+	// ROMColors draws the screen on the Terminal in the colours the ROM
+	// database gives for a recognised ROM.
+	ROMColors bool
+
+	stats        statsCollector
+	lastStatsLog time.Time
+	frameDraws   int // DXYN instructions in the last frame
+	frames       int // frames run so far
+	cycleCarry   int // machine cycles left over from (or overspent in) the last frame
+
+	fastForward bool
+	slowMotion  bool
+	paused      bool
+
+	vip   *cdp1802.VIP // set by BootInterpreter
+	romID string       // SHA-1 of the loaded ROM
+	rom   []byte       // the loaded ROM, kept to move it if the memory map changes
+
+	savedFlags [16]byte  // user flags as last loaded or saved
+	cheatHook  bool      // the CPU calls the cheats after each instruction
+	trace      io.Writer // where TraceTo sends lines, or nil
+	traceHook  bool      // the CPU calls traced after each instruction
 }
 
 func (emu *Emulator) Test() {
@@ -57,42 +117,254 @@ func NewEmulator() *Emulator {
 	ram := memory.NewMemory()
 	display := display.NewDisplay()
 	keypad := input.NewKeypad()
-	return &Emulator{
-		RAM:     ram,
-		CPU:     cpu.NewCPU(ram, display, keypad),
-		Input:   keypad,
-		Display: display,
-		Timer:   timer.NewTimer(),
-		running: false,
+	emu := &Emulator{
+		RAM:            ram,
+		CPU:            cpu.NewCPU(ram, display, keypad),
+		Input:          keypad,
+		Display:        display,
+		Timer:          timer.NewTimer(),
+		Speed:          DefaultSpeed(),
+		DetectPlatform: true,
+		Dump:           DefaultMemoryDump(),
+	}
+	emu.CPU.FlagsChanged = emu.saveFlags
+	emu.SetProfile(platform.Modern)
+	return emu
+}
+
+// SetProfile makes the emulator behave like the given platform: its quirks,
+// how sprites meet the screen edge, its usual clock speed, its memory map and
+// font, and whether 0NNN runs machine code.
+func (emu *Emulator) SetProfile(p platform.Profile) {
+	emu.Profile = p
+	emu.setMemoryMap(p.Memory)
+	emu.SetFont(p.Font)
+	emu.CPU.Quirks = p.Quirks
+	emu.Display.Edge = p.Edge
+	emu.SetInstructionsPerFrame(p.InstructionsPerFrame)
+	emu.CPU.MachineCode = nil
+	if p.MachineCode {
+		emu.CPU.MachineCode = emu.runMachineCode
+	}
+}
+
+// setMemoryMap lays memory out for a platform. If that moves where programs
+// load or start, or resizes RAM, the loaded ROM is loaded again and the CPU
+// restarts at the new entry point.
+func (emu *Emulator) setMemoryMap(m memory.Map) {
+	if m.Size == 0 {
+		m = memory.DefaultMap
+	}
+	old := emu.RAM.Map()
+	emu.RAM.SetMap(m)
+	if m.Load == old.Load && m.Entry == old.Entry && m.Size == old.Size {
+		return
+	}
+	emu.CPU.Reset()
+	if emu.rom != nil {
+		for i := int(old.Load); i < int(old.Load)+len(emu.rom) && i < m.Size; i++ {
+			emu.RAM.WriteByte(uint16(i), 0)
+		}
+		if err := emu.RAM.LoadROM(emu.rom); err != nil {
+			fmt.Fprintf(os.Stderr, "ROM does not fit the %s memory map: %v\n", m.Name, err)
+		}
+		emu.patchCheats()
+	}
+}
+
+// SetFont copies a font's small digits to where the memory map keeps them
+// for FX29 and, if the map has room for them, its large digits for FX30. A
+// font without small digits selects the Octo font.
+func (emu *Emulator) SetFont(f font.Font) {
+	if len(f.Small) == 0 {
+		f = font.Octo
+	}
+	emu.Font = f
+	m := emu.RAM.Map()
+	emu.writeFont(m.Font, f.Small)
+	if m.LargeFont != 0 {
+		emu.writeFont(m.LargeFont, f.Large)
+	}
+}
+
+func (emu *Emulator) writeFont(address uint16, glyphs []byte) {
+	for i, b := range glyphs {
+		if int(address)+i >= emu.RAM.Size() {
+			return
+		}
+		emu.RAM.WriteByte(address+uint16(i), b)
 	}
 }
 
 func (emu *Emulator) Run() {
-	// Load the fontset into memory (0 - 80)
 	fmt.Println(">>> Running Emulator")
-	for i, b := range chip8Fontset {
-		emu.RAM.WriteByte(uint16(i), b)
+
+	emu.refreshMemoryDump()
+
+	// Pace once per frame rather than once per instruction. Each tick runs
+	// one frame, or several while fast-forwarding, then redraws and sleeps
+	// until the next tick is due. Uncapped mode skips the sleep and only
+	// redraws at the normal frame rate.
+	next := time.Now()
+	lastRender := time.Time{}
+	for emu.running.Load() {
+		sample := tickSample{start: time.Now()}
+		emu.pollEvents()
+		if emu.Poll != nil {
+			emu.Poll()
+		}
+		sample.input = time.Since(sample.start)
+
+		cpuStart := time.Now()
+		cycles, draws := emu.CPU.CycleCount, emu.CPU.DrawCount
+		for i := emu.framesPerTick(); i > 0 && !emu.paused; i-- {
+			emu.Frame()
+			sample.frames++
+			if emu.frameDraws > sample.maxDraws {
+				sample.maxDraws = emu.frameDraws
+			}
+		}
+		sample.instructions = emu.CPU.CycleCount - cycles
+		sample.draws = emu.CPU.DrawCount - draws
+		sample.cpu = time.Since(cpuStart)
+
+		if emu.Speed.Uncapped {
+			if time.Since(lastRender) >= frameDuration {
+				sample.render = emu.render()
+				lastRender = time.Now()
+			}
+			emu.recordStats(sample)
+			next = time.Now()
+			continue
+		}
+
+		sample.render = emu.render()
+		emu.recordStats(sample)
+		next = next.Add(emu.tickDuration())
+		if wait := time.Until(next); wait > 0 {
+			time.Sleep(wait)
+		} else {
+			next = time.Now() // fell behind; don't try to catch up
+		}
+	}
+	if emu.CPU.Flags != emu.savedFlags {
+		emu.saveFlags(emu.CPU)
+	}
+}
+
+// pollEvents handles the SDL events that arrived since the last tick:
+// speed hotkeys first, then the keypad.
+func (emu *Emulator) pollEvents() {
+	if emu.Events == nil {
+		return
 	}
+	for event := emu.Events(); event != nil; event = emu.Events() {
+		if hotkey, pressed := input.HotkeyFor(event); hotkey != input.NoHotkey {
+			emu.HandleHotkey(hotkey, pressed)
+			continue
+		}
+		emu.Input.HandleEvent(event)
+	}
+}
 
-	// Run with : watch -n 1 "cat memory.dump | xxd -r -p | xxd"
-	emu.RAM.PrintMemoryToFile("memory.dump")
+// Stop makes Run return after the current tick. It is safe to call from
+// another goroutine, such as a signal handler.
+func (emu *Emulator) Stop() {
+	emu.running.Store(false)
+}
 
-	var ticker *time.Ticker
-	debug := false
-	if debug {
-		ticker = time.NewTicker(10 * time.Second)
-	} else {
-		ticker = time.NewTicker(time.Second / CLOCK_SPEED)
+// render redraws the screen, plus the stats overlay if enabled, and returns
+// how long that took.
+func (emu *Emulator) render() time.Duration {
+	start := time.Now()
+	emu.drawScreen()
+	switch {
+	case emu.ShowStats && emu.Terminal != nil:
+		// Clear the rest of the line, since it is written over each time.
+		fmt.Printf("%s\x1b[K\n", emu.Stats())
+	case emu.ShowStats:
+		fmt.Println(emu.Stats())
+	}
+	return time.Since(start)
+}
+
+// drawScreen shows the screen on the Terminal, or prints it if there is
+// none.
+func (emu *Emulator) drawScreen() {
+	if emu.Terminal == nil {
+		emu.Display.Render()
+		return
+	}
+	if err := emu.Terminal.Draw(emu.Display); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to draw the screen: %v\n", err)
+		emu.Terminal = nil
+	}
+}
+
+func (emu *Emulator) recordStats(sample tickSample) {
+	if !emu.stats.record(sample) || emu.StatsInterval <= 0 {
+		return
+	}
+	if time.Since(emu.lastStatsLog) >= emu.StatsInterval {
+		fmt.Fprintln(os.Stderr, emu.Stats())
+		emu.lastStatsLog = time.Now()
 	}
+}
 
-	for emu.running {
-		select {
-		case <-ticker.C:
-			//fmt.Printf("%d\n", emu.CPU.CycleCount)
-			//emu.CPU.CycleCount++
-			//fmt.Print("\033[H\033[2J") // clear terminal screen
-			emu.Step()
+// Frame runs one 60 Hz frame: a vertical blank, a frame's worth of
+// instructions, then a tick of the delay and sound timers. With the
+// DisplayWait quirk the CPU may give up the rest of the frame at a draw.
+// After BootInterpreter the emulated VIP runs the frame instead.
+func (emu *Emulator) Frame() {
+	if emu.vip != nil {
+		emu.vip.RunFrame()
+		emu.vip.DrawTo(emu.Display)
+		return
+	}
+	draws := emu.CPU.DrawCount
+	emu.CPU.VBlank()
+	if emu.CycleAccurate && emu.Profile.Timing != nil {
+		budget := emu.Profile.CyclesPerFrame + emu.cycleCarry
+		used := 0
+		for used < budget {
+			used += emu.CPU.RunCycles(budget-used, emu.Profile.Timing)
+			if !emu.CPU.AtBreakpoint() {
+				break
+			}
+			emu.atBreakpoint()
+			if emu.paused {
+				break
+			}
 		}
+		emu.cycleCarry = budget - used
+		if emu.CPU.WaitingForVBlank() {
+			emu.cycleCarry = 0 // the rest of the frame is spent idle
+		}
+	} else {
+		for n := emu.instructionsPerFrame(); n > 0; {
+			n -= emu.CPU.Run(n)
+			if !emu.CPU.AtBreakpoint() {
+				break
+			}
+			emu.atBreakpoint()
+			if emu.paused {
+				break
+			}
+		}
+	}
+	if emu.Cheats != nil {
+		emu.Cheats.Frame(emu.RAM)
+	}
+	emu.frames++
+	if emu.Dump.Every > 0 && emu.frames%emu.Dump.Every == 0 {
+		emu.refreshMemoryDump()
+	}
+	emu.frameDraws = emu.CPU.DrawCount - draws
+	if emu.CPU.DT > 0 {
+		emu.CPU.DT--
+	}
+	if emu.CPU.ST > 0 {
+		emu.CPU.ST--
 	}
 }
 
@@ -100,21 +372,86 @@ func (emu *Emulator) Step() {
 	// Run the emulator
 	emu.CPU.Cycle(false, emu.RAM)
 	//emu.Timer.Update() // TODO: why does this cause issue??
-	emu.Display.Render()
+	emu.drawScreen()
 }
 
-func (emu *Emulator) LoadROM(path string) {
-	// Implement the load ROM logic
-	// convert string to file and load into bytes
-	// pass the bytes to the LoadROM method in memory
-	fmt.Println("Loading ROM: ", path)
+// LoadROMFile loads the ROM at path, or from standard input if path is "-".
+func (emu *Emulator) LoadROMFile(path string) error {
+	if path == "-" {
+		return emu.LoadROM(os.Stdin, "stdin")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return emu.LoadROM(f, path)
+}
 
-	data, err := os.ReadFile(path)
-	//fmt.Print(data)
+// LoadROM reads a ROM from r and loads it into memory. The content may be a
+// raw binary, a hex dump or a zip archive; for an archive
+// holding several ROMs, ChooseROM picks one. name is only used in messages.
+func (emu *Emulator) LoadROM(r io.Reader, name string) error {
+	fmt.Println("Loading ROM: ", name)
+	roms, err := romfile.Read(r, name)
 	if err != nil {
-		fmt.Printf("Failed to read ROM: %v", err)
+		return err
+	}
+	rom := roms[0]
+	if len(roms) > 1 {
+		if emu.ChooseROM == nil {
+			return fmt.Errorf("%s holds %d ROMs", name, len(roms))
+		}
+		i, err := emu.ChooseROM(roms)
+		if err != nil {
+			return err
+		}
+		rom = roms[i]
+	}
+	if rom.Format != romfile.Raw {
+		fmt.Printf("Found %s in %s\n", rom.Name, rom.Format)
 	}
-	emu.RAM.LoadROM(data)
 
-	emu.running = true
+	// Pick the platform first: its memory map decides where the ROM goes.
+	emu.rom = nil
+	emu.romID = romdb.Hash(rom.Data)
+	if emu.DetectPlatform {
+		emu.detectPlatform(rom.Data)
+	}
+	if err := emu.RAM.LoadROM(rom.Data); err != nil {
+		return err
+	}
+	emu.rom = rom.Data
+	emu.patchCheats()
+	emu.loadFlags()
+
+	emu.running.Store(true)
+	return nil
+}
+
+// ROM returns the program LoadROM loaded, or nil if there is none.
+func (emu *Emulator) ROM() []byte {
+	return emu.rom
+}
+
+// detectPlatform applies the profile the ROM database gives for the loaded
+// ROM, along with its colours, and says what its keys do. For a ROM the
+// database does not know it applies the profile its instructions suggest.
+func (emu *Emulator) detectPlatform(rom []byte) {
+	if entry, ok := romdb.Lookup(emu.romID); ok {
+		if p, ok := entry.Profile(); ok {
+			fmt.Printf("Recognised %s, running as %s\n", entry.Program.Title, p.Name)
+			emu.SetProfile(p)
+			if hints := entry.KeyHints(); len(hints) > 0 {
+				fmt.Printf("Keys: %s\n", strings.Join(hints, ", "))
+			}
+			if palette, ok := entry.Palette(); ok && emu.ROMColors && emu.Terminal != nil {
+				emu.Terminal.Palette = palette
+			}
+			return
+		}
+	}
+	guess := romdb.GuessPlatform(rom)
+	fmt.Printf("Guessing %s (%.0f%% sure): %s\n", guess.Profile.Name, guess.Confidence*100, guess.Reasons[0])
+	emu.SetProfile(guess.Profile)
 }
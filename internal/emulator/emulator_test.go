@@ -1,13 +1,19 @@
 package emulator
 
 import (
+	"archive/zip"
+	"bytes"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/jsutcodes/chip8-goemu/internal/cpu"
 	"github.com/jsutcodes/chip8-goemu/internal/display"
+	"github.com/jsutcodes/chip8-goemu/internal/font"
 	"github.com/jsutcodes/chip8-goemu/internal/input"
 	"github.com/jsutcodes/chip8-goemu/internal/memory"
+	"github.com/jsutcodes/chip8-goemu/internal/platform"
+	"github.com/jsutcodes/chip8-goemu/internal/romfile"
 	"github.com/jsutcodes/chip8-goemu/internal/timer"
 )
 
@@ -21,22 +27,14 @@ func TestRun(t *testing.T) {
 		Input:   keypad,
 		Display: display,
 		Timer:   timer.NewTimer(),
-		running: true,
 	}
+	emu.running.Store(true)
 
 	go emu.Run()
 
 	// Allow some time for the emulator to run
 	time.Sleep(100 * time.Millisecond)
 
-	// Check if the fontset is loaded into memory
-	for i, b := range chip8Fontset {
-		if byteRead, err := ram.ReadByte(uint16(i)); err != nil || byteRead != b {
-			byteRead, _ := ram.ReadByte(uint16(i))
-			t.Errorf("Expected fontset byte %x at position %d, but got %x", b, i, byteRead)
-		}
-	}
-
 	// Check if the emulator is stepping
 	// This is a bit tricky to test directly, so we will check if the CPU cycle count has increased
 	initialCycleCount := emu.CPU.CycleCount
@@ -46,5 +44,164 @@ func TestRun(t *testing.T) {
 	}
 
 	// Stop the emulator
-	emu.running = false
+	emu.Stop()
+}
+
+func TestNewEmulatorLoadsFont(t *testing.T) {
+	emu := NewEmulator()
+	for i, b := range font.Octo.Small {
+		if byteRead, _ := emu.RAM.ReadByte(uint16(i)); byteRead != b {
+			t.Fatalf("Expected font byte %x at position %d, but got %x", b, i, byteRead)
+		}
+	}
+	if byteRead, _ := emu.RAM.ReadByte(0x50); byteRead != font.Octo.Large[0] {
+		t.Errorf("Expected the large font at 0x50, got %x", byteRead)
+	}
+
+	emu.SetProfile(platform.VIP)
+	if byteRead, _ := emu.RAM.ReadByte(4 * font.SmallHeight); byteRead != 0xA0 {
+		t.Errorf("Expected the VIP's 4 after switching profile, got %x", byteRead)
+	}
+}
+
+func TestSetFont(t *testing.T) {
+	emu := NewEmulator()
+	emu.SetFont(font.DREAM6800)
+	emu.RAM.LoadROM([]byte{0x61, 0x02, 0xF1, 0x29}) // V1 = 2, I = digit 2
+	emu.CPU.Run(2)
+	if byteRead, _ := emu.RAM.ReadByte(emu.CPU.I); emu.CPU.I != 10 || byteRead != 0xE0 {
+		t.Errorf("Expected I at the DREAM 6800 digit 2, got 0x%X holding %x", emu.CPU.I, byteRead)
+	}
+}
+
+func TestFrame(t *testing.T) {
+	emu := NewEmulator()
+	emu.SetInstructionsPerFrame(5)
+	emu.CPU.DT = 2
+	emu.RAM.LoadROM([]byte{0x12, 0x00}) // jump to self
+
+	emu.Frame()
+
+	if emu.CPU.CycleCount != 5 {
+		t.Errorf("Expected 5 instructions in a frame, got %d", emu.CPU.CycleCount)
+	}
+	if emu.CPU.DT != 1 {
+		t.Errorf("Expected delay timer to tick once per frame, got %d", emu.CPU.DT)
+	}
+}
+
+func TestFrameDisplayWait(t *testing.T) {
+	emu := NewEmulator()
+	emu.SetProfile(platform.VIP)
+	emu.RAM.LoadROM([]byte{0xD0, 0x01, 0x12, 0x00}) // draw forever
+
+	for i := 0; i < 3; i++ {
+		emu.Frame()
+		if emu.frameDraws != 1 {
+			t.Errorf("Expected one draw per frame on the VIP, got %d", emu.frameDraws)
+		}
+	}
+
+	emu.SetProfile(platform.Modern)
+	emu.Frame()
+	if emu.frameDraws < 2 {
+		t.Errorf("Expected several draws per frame without display wait, got %d", emu.frameDraws)
+	}
+}
+
+func TestFrameCycleAccurate(t *testing.T) {
+	emu := NewEmulator()
+	emu.SetProfile(platform.VIP)
+	emu.CycleAccurate = true
+	emu.RAM.LoadROM([]byte{0x60, 0x01, 0x12, 0x00}) // V0 = 1, jump back
+
+	frames := 60
+	for i := 0; i < frames; i++ {
+		emu.Frame()
+	}
+
+	// Each loop costs 46 + 52 machine cycles on the VIP.
+	expected := frames * cpu.VIPCyclesForInterpreter / 98 * 2
+	if diff := emu.CPU.CycleCount - expected; diff < -2 || diff > 2 {
+		t.Errorf("Expected about %d instructions in a second, got %d", expected, emu.CPU.CycleCount)
+	}
+}
+
+func TestLoadROMDetectsPlatform(t *testing.T) {
+	emu := NewEmulator()
+	emu.LoadROMFile("../../roms/PONG.ch8")
+	if emu.Profile.ID != platform.VIP.ID || emu.Speed.InstructionsPerFrame != 15 {
+		t.Errorf("Expected Pong to run as on the VIP, got %s at %d", emu.Profile.ID, emu.Speed.InstructionsPerFrame)
+	}
+
+	emu = NewEmulator()
+	emu.DetectPlatform = false
+	emu.LoadROMFile("../../roms/PONG.ch8")
+	if emu.Profile.ID != platform.Modern.ID {
+		t.Errorf("Expected detection to be skipped, got %s", emu.Profile.ID)
+	}
+}
+
+func TestLoadROMFromArchive(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range []string{"a.ch8", "b.ch8"} {
+		f, _ := w.Create(name)
+		f.Write([]byte{0x60, name[0], 0x12, 0x02})
+	}
+	w.Close()
+	archive := buf.Bytes()
+
+	emu := NewEmulator()
+	if err := emu.LoadROM(bytes.NewReader(archive), "games.zip"); err == nil {
+		t.Errorf("Expected an archive of two ROMs to need ChooseROM")
+	}
+	emu.ChooseROM = func(roms []romfile.ROM) (int, error) {
+		if len(roms) != 2 {
+			t.Errorf("Expected to choose from 2 ROMs, got %d", len(roms))
+		}
+		return 1, nil
+	}
+	if err := emu.LoadROM(bytes.NewReader(archive), "games.zip"); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := emu.RAM.ReadByte(0x201); b != 'b' {
+		t.Errorf("Expected b.ch8 to be loaded, got 0x%02X", b)
+	}
+}
+
+func TestLoadROMFromHex(t *testing.T) {
+	emu := NewEmulator()
+	if err := emu.LoadROM(strings.NewReader("00FF 1202\n"), "stdin"); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := emu.RAM.ReadByte(0x201); b != 0xFF || emu.Profile.ID != platform.SCHIP.ID {
+		t.Errorf("Expected the hex dump to load as a SUPER-CHIP ROM, got %s", emu.Profile.ID)
+	}
+}
+
+func TestSetProfileMovesROM(t *testing.T) {
+	emu := NewEmulator()
+	emu.DetectPlatform = false
+	if err := emu.LoadROM(bytes.NewReader([]byte{0x12, 0x34}), "rom"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	emu.SetProfile(platform.ETI660)
+	if emu.CPU.PC != 0x600 {
+		t.Errorf("Expected PC at the ETI-660 entry point, got 0x%X", emu.CPU.PC)
+	}
+	if hi, _ := emu.RAM.ReadByte(0x600); hi != 0x12 {
+		t.Errorf("Expected the ROM to move to 0x600, got 0x%X", hi)
+	}
+	if hi, _ := emu.RAM.ReadByte(0x200); hi != 0 {
+		t.Errorf("Expected the old copy at 0x200 to be cleared, got 0x%X", hi)
+	}
+
+	emu.SetProfile(platform.XOCHIP)
+	if emu.RAM.Size() != memory.MaxSize {
+		t.Errorf("Expected 64kb of RAM for XO-CHIP, got %d", emu.RAM.Size())
+	}
+	if hi, _ := emu.RAM.ReadByte(0x200); hi != 0x12 || emu.CPU.PC != 0x200 {
+		t.Errorf("Expected the ROM back at 0x200, got 0x%X and PC 0x%X", hi, emu.CPU.PC)
+	}
 }
@@ -0,0 +1,95 @@
+package emulator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/jsutcodes/chip8-goemu/internal/cpu"
+)
+
+// DefaultFlagsDir is where the command line keeps RPL user flags, which
+// are saved data such as high scores: chip8/flags under the user's data
+// directory, or "" if there is none.
+func DefaultFlagsDir() string {
+	dir := userDataDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "chip8", "flags")
+}
+
+// userDataDir returns where programs keep the user's data: $XDG_DATA_HOME
+// or ~/.local/share on Unix, %LocalAppData% on Windows and Application
+// Support on macOS.
+func userDataDir() string {
+	switch runtime.GOOS {
+	case "windows":
+		return os.Getenv("LocalAppData")
+	case "darwin", "ios":
+		// The same directory holds configuration and data.
+		dir, _ := os.UserConfigDir()
+		return dir
+	case "plan9":
+		if home := os.Getenv("home"); home != "" {
+			return filepath.Join(home, "lib")
+		}
+		return ""
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "share")
+}
+
+// flagsPath is the file holding the user flags of the loaded ROM, or "" if
+// they are not persisted.
+func (emu *Emulator) flagsPath() string {
+	if emu.FlagsDir == "" || emu.romID == "" {
+		return ""
+	}
+	return filepath.Join(emu.FlagsDir, emu.romID+".flags")
+}
+
+// loadFlags restores the user flags the loaded ROM saved in an earlier run.
+// A ROM that never saved any starts with them cleared.
+func (emu *Emulator) loadFlags() {
+	emu.CPU.Flags = [16]byte{}
+	emu.savedFlags = emu.CPU.Flags
+	path := emu.flagsPath()
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Failed to read user flags: %v\n", err)
+		}
+		return
+	}
+	copy(emu.CPU.Flags[:], data)
+	emu.savedFlags = emu.CPU.Flags
+}
+
+// saveFlags writes the user flags to disk. It is called whenever FX75
+// changes them and again when Run returns, if they changed since.
+func (emu *Emulator) saveFlags(c *cpu.CPU) {
+	path := emu.flagsPath()
+	if path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		fmt.Printf("Failed to save user flags: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(path, c.Flags[:], 0o644); err != nil {
+		fmt.Printf("Failed to save user flags: %v\n", err)
+		return
+	}
+	emu.savedFlags = c.Flags
+}
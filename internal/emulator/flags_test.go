@@ -0,0 +1,67 @@
+package emulator
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jsutcodes/chip8-goemu/internal/platform"
+	"github.com/jsutcodes/chip8-goemu/internal/romdb"
+)
+
+func TestUserFlagsPersist(t *testing.T) {
+	rom := []byte{
+		0xF1, 0x85, // V0-V1 = flags
+		0x70, 0x01, // V0 += 1
+		0xF1, 0x75, // flags = V0-V1
+		0x12, 0x06, // jump to self
+	}
+	path := filepath.Join(t.TempDir(), "score.ch8")
+	if err := os.WriteFile(path, rom, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	for run := 1; run <= 2; run++ {
+		emu := NewEmulator()
+		emu.SetProfile(platform.SCHIP)
+		emu.FlagsDir = dir
+		emu.LoadROMFile(path)
+		emu.CPU.Run(3)
+		if emu.CPU.Flags[0] != byte(run) {
+			t.Errorf("run %d: expected flag 0 to be %d, got %d", run, run, emu.CPU.Flags[0])
+		}
+	}
+
+	saved, err := os.ReadFile(filepath.Join(dir, romdb.Hash(rom)+".flags"))
+	if err != nil || len(saved) != 16 || saved[0] != 2 {
+		t.Errorf("Expected the flags file to hold 16 flags starting with 2, got %v (%v)", saved, err)
+	}
+
+	other := NewEmulator()
+	other.FlagsDir = dir
+	other.RAM.LoadROM([]byte{0x00, 0xE0})
+	other.romID = romdb.Hash([]byte{0x00, 0xE0})
+	other.loadFlags()
+	if other.CPU.Flags[0] != 0 {
+		t.Errorf("Expected another ROM to start with clear flags")
+	}
+}
+
+func TestDefaultFlagsDir(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" || runtime.GOOS == "ios" || runtime.GOOS == "plan9" {
+		t.Skip("XDG directories are only used on Unix")
+	}
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+	if got, want := DefaultFlagsDir(), filepath.Join(data, "chip8", "flags"); got != want {
+		t.Errorf("Expected flags under $XDG_DATA_HOME, %s, got %s", want, got)
+	}
+	home := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("HOME", home)
+	if got, want := DefaultFlagsDir(), filepath.Join(home, ".local", "share", "chip8", "flags"); got != want {
+		t.Errorf("Expected flags under ~/.local/share, %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,135 @@
+package emulator
+
+import (
+	"fmt"
+
+	"github.com/jsutcodes/chip8-goemu/internal/cdp1802"
+	"github.com/jsutcodes/chip8-goemu/internal/cpu"
+	"github.com/jsutcodes/chip8-goemu/internal/memory"
+)
+
+// Where the original VIP interpreter keeps its state in a 4 KB machine. A
+// machine code routine called by 0NNN finds everything where it expects.
+const (
+	vipWorkArea  = 0x0EA0 // reserved for the interpreter from here up
+	vipStack     = 0x0ECF // R2 while the interpreter runs, growing down
+	vipVariables = 0x0EF0 // V0-VF
+	vipDisplay   = 0x0F00 // 64x32 pixels, one bit each
+
+	// machineCodeCycleLimit stops a routine that never returns after about
+	// a second of VIP time.
+	machineCodeCycleLimit = 60 * cdp1802.CyclesPerFrame
+)
+
+// machineBus is what a machine code routine sees: program memory, with the
+// interpreter's work area, registers and display page laid over the top of
+// it, and the keypad.
+type machineBus struct {
+	ram    *memory.Memory
+	work   [memory.MemorySize - vipWorkArea]byte
+	keypad cdp1802.Keypad
+	key    byte
+}
+
+func (b *machineBus) Read(address uint16) byte {
+	if address >= cdp1802.MonitorBase {
+		return 0
+	}
+	address %= memory.MemorySize
+	if address >= vipWorkArea {
+		return b.work[address-vipWorkArea]
+	}
+	v, _ := b.ram.ReadByte(address)
+	return v
+}
+
+func (b *machineBus) Write(address uint16, value byte) {
+	if address >= cdp1802.MonitorBase {
+		return
+	}
+	address %= memory.MemorySize
+	if address >= vipWorkArea {
+		b.work[address-vipWorkArea] = value
+		return
+	}
+	b.ram.WriteByte(address, value)
+}
+
+func (b *machineBus) Output(port byte, value byte) {
+	if port == 2 {
+		b.key = value & 0x0F
+	}
+}
+
+func (b *machineBus) Input(port byte) byte { return 0 }
+
+func (b *machineBus) EF(n int) bool {
+	return n == 3 && b.keypad.IsKeyPressed(b.key)
+}
+
+// runMachineCode runs the 1802 routine at address for 0NNN, set up the way
+// the VIP interpreter calls it: R3 is the program counter, R2 the stack, R5
+// the CHIP-8 program counter, R6 and R7 point at VX and VY, R8 holds the
+// delay and sound timers, RA is I and RB.1 the display page. The routine
+// returns to the interpreter with SEP R4, after which any changes it made to
+// those are copied back.
+func (emu *Emulator) runMachineCode(c *cpu.CPU, address uint16) {
+	bus := &machineBus{ram: emu.RAM, keypad: emu.Input}
+	copy(bus.work[vipVariables-vipWorkArea:], c.V[:])
+	page := bus.work[vipDisplay-vipWorkArea:]
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			if emu.Display.IsPixelOn(x, y) {
+				page[y*8+x/8] |= 0x80 >> (x % 8)
+			}
+		}
+	}
+
+	m := cdp1802.New(bus)
+	m.IE = false
+	m.R[2], m.X = vipStack, 2
+	m.R[3], m.P = address, 3
+	m.R[5] = c.PC
+	m.R[6] = vipVariables + address>>8&0xF
+	m.R[7] = vipVariables + address>>4&0xF
+	m.R[8] = uint16(c.DT)<<8 | uint16(c.ST)
+	m.R[10] = c.I
+	m.R[11] = vipDisplay
+	for m.P != 4 && m.Cycles < machineCodeCycleLimit {
+		m.Step()
+	}
+	if m.P != 4 {
+		fmt.Printf("Machine code at 0x%03X did not return\n", address)
+	}
+
+	copy(c.V[:], bus.work[vipVariables-vipWorkArea:])
+	c.I = m.R[10] % memory.MemorySize
+	c.PC = m.R[5] % memory.MemorySize
+	c.DT, c.ST = byte(m.R[8]>>8), byte(m.R[8])
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			emu.Display.SetPixel(x, y, page[y*8+x/8]&(0x80>>(x%8)) != 0)
+		}
+	}
+}
+
+// BootInterpreter switches the emulator to running the loaded program under
+// an interpreter image, such as the original CHIP-8 interpreter, on an
+// emulated COSMAC VIP. Each frame then runs the 1802 instead of the CPU,
+// and RAM is the VIP's memory, interpreter and all. Call it after LoadROM.
+func (emu *Emulator) BootInterpreter(image []byte) error {
+	if emu.RAM.Size() != cdp1802.RAMSize {
+		return fmt.Errorf("the interpreter needs a machine with %d bytes of memory, not %d", cdp1802.RAMSize, emu.RAM.Size())
+	}
+	vip := cdp1802.NewVIP(emu.Input)
+	if err := vip.LoadInterpreter(image); err != nil {
+		return err
+	}
+	program := emu.RAM.Snapshot()
+	if err := vip.LoadProgram(program[cdp1802.ProgramStart:]); err != nil {
+		return err
+	}
+	emu.RAM.Share(vip.RAM[:])
+	emu.vip = vip
+	return nil
+}
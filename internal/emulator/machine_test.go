@@ -0,0 +1,96 @@
+package emulator
+
+import (
+	"testing"
+
+	"github.com/jsutcodes/chip8-goemu/internal/platform"
+)
+
+// machineCodeROM calls an 1802 routine at 0x300 that adds one to V1 and sets
+// the top left pixel.
+func machineCodeROM() []byte {
+	rom := make([]byte, 0x120)
+	copy(rom, []byte{
+		0x61, 0x05, // V1 = 5
+		0x03, 0x00, // call machine code at 0x300
+		0x12, 0x04, // jump to self
+	})
+	copy(rom[0x100:], []byte{
+		0xF8, 0x0E, 0xB7, 0xF8, 0xF1, 0xA7, // R7 = 0EF1, V1
+		0x07,       // LDN R7
+		0xFC, 0x01, // ADI 01
+		0x57,                         // STR R7
+		0x9B, 0xB7, 0xF8, 0x00, 0xA7, // R7 = display page
+		0xF8, 0x80, // LDI 80
+		0x57, // STR R7
+		0xD4, // SEP R4, back to the interpreter
+	})
+	return rom
+}
+
+func TestMachineCode(t *testing.T) {
+	emu := NewEmulator()
+	emu.SetProfile(platform.VIP)
+	emu.RAM.LoadROM(machineCodeROM())
+	emu.CPU.Run(2)
+
+	if emu.CPU.V[1] != 6 {
+		t.Errorf("Expected the routine to increment V1 to 6, got %d", emu.CPU.V[1])
+	}
+	if !emu.Display.IsPixelOn(0, 0) {
+		t.Errorf("Expected the routine to draw to the display page")
+	}
+	if emu.CPU.PC != 0x204 {
+		t.Errorf("Expected to carry on after the call, got PC 0x%X", emu.CPU.PC)
+	}
+
+	emu = NewEmulator()
+	emu.RAM.LoadROM(machineCodeROM())
+	emu.CPU.Run(2)
+	if emu.CPU.V[1] != 5 || emu.CPU.PC != 0x202 {
+		t.Errorf("Expected 0NNN to be ignored outside the VIP profile")
+	}
+}
+
+func TestBootInterpreter(t *testing.T) {
+	emu := NewEmulator()
+	emu.RAM.LoadROM([]byte{0x12, 0x00})
+	if err := emu.BootInterpreter(make([]byte, 0x201)); err == nil {
+		t.Errorf("Expected an interpreter image over 512 bytes to be rejected")
+	}
+	// An interpreter that turns the display on over a page holding a
+	// program byte, then idles.
+	image := []byte{
+		0xF8, 0x02, 0xBB, // RB.1 = 02
+		0xF8, 0x81, 0xB1, 0xF8, 0x46, 0xA1, // R1 = 8146
+		0xF8, 0x0E, 0xB2, // R2.1 = 0E
+		0xE2, 0x69, // SEX 2, INP 1
+		0xF8, 0x12, 0xA3, 0xD3, // SEP R3 with R3 = 12
+		0x30, 0x12, // 12: BR 12
+	}
+	if err := emu.BootInterpreter(image); err != nil {
+		t.Fatal(err)
+	}
+	cycles := emu.CPU.CycleCount
+	emu.Frame()
+	if emu.CPU.CycleCount != cycles {
+		t.Errorf("Expected the CHIP-8 CPU to be idle under the interpreter")
+	}
+	// 0x12 at 0x200 is the pixels ...X..X. on the first row.
+	if !emu.Display.IsPixelOn(3, 0) || !emu.Display.IsPixelOn(6, 0) || emu.Display.IsPixelOn(0, 0) {
+		t.Errorf("Expected the VIP display to be drawn to the screen")
+	}
+	// RAM is the VIP's memory, so debuggers and dumps see the interpreter
+	// and what it does to the program.
+	if b, _ := emu.RAM.ReadByte(0x00); b != 0xF8 {
+		t.Errorf("Expected RAM to hold the interpreter at 0x000, got 0x%02X", b)
+	}
+	emu.vip.RAM[0x201] = 0x34
+	if b, _ := emu.RAM.ReadByte(0x201); b != 0x34 {
+		t.Errorf("Expected RAM to follow VIP memory, got 0x%02X", b)
+	}
+	emu.RAM.WriteByte(0x202, 0x56)
+	if emu.vip.RAM[0x202] != 0x56 {
+		t.Errorf("Expected writes to RAM to reach VIP memory")
+	}
+}
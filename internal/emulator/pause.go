@@ -0,0 +1,46 @@
+package emulator
+
+// Pause stops Run from running frames until Resume. The screen is still
+// redrawn and Poll still called, so a front end can carry on talking to the
+// emulator.
+func (emu *Emulator) Pause() {
+	emu.paused = true
+}
+
+// Resume lets Run carry on after Pause or a breakpoint.
+func (emu *Emulator) Resume() {
+	emu.paused = false
+}
+
+// Paused reports whether emulation is paused.
+func (emu *Emulator) Paused() bool {
+	return emu.paused
+}
+
+// StepInstruction runs one instruction, even if emulation is paused, and
+// reports whether the CPU stopped at a breakpoint afterwards. Timers do not
+// tick, since no frame passes, but a draw held back by the DisplayWait
+// quirk goes ahead as if the frame had ended, so stepping never stalls.
+func (emu *Emulator) StepInstruction() bool {
+	if emu.CPU.Quirks.DisplayWait {
+		if op, _ := emu.RAM.ReadByte(emu.CPU.PC & emu.RAM.Mask()); op>>4 == 0xD {
+			emu.CPU.VBlank()
+		}
+	}
+	emu.CPU.Run(1)
+	return emu.CPU.AtBreakpoint()
+}
+
+// atBreakpoint is called whenever the CPU stops at a breakpoint during a
+// frame. The frame carries on afterwards unless PauseAtBreakpoints is set.
+func (emu *Emulator) atBreakpoint() {
+	if emu.Dump.OnBreakpoint {
+		emu.refreshMemoryDump()
+	}
+	if emu.PauseAtBreakpoints {
+		emu.paused = true
+	}
+	if emu.OnBreakpoint != nil {
+		emu.OnBreakpoint(emu.CPU.PC)
+	}
+}
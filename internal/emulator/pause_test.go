@@ -0,0 +1,53 @@
+package emulator
+
+import (
+	"testing"
+
+	"github.com/jsutcodes/chip8-goemu/internal/platform"
+)
+
+func TestPauseAtBreakpoint(t *testing.T) {
+	emu := NewEmulator()
+	emu.SetInstructionsPerFrame(10)
+	emu.RAM.LoadROM([]byte{
+		0x70, 0x01, // V0 += 1
+		0x12, 0x00, // jump 0x200
+	})
+	var stops []uint16
+	emu.OnBreakpoint = func(pc uint16) { stops = append(stops, pc) }
+	emu.PauseAtBreakpoints = true
+	emu.CPU.SetBreakpoint(0x202)
+
+	emu.Frame()
+	if !emu.Paused() || emu.CPU.CycleCount != 1 || len(stops) != 1 || stops[0] != 0x202 {
+		t.Fatalf("Expected to pause at 0x202 after one instruction, got %d instructions, stops %v", emu.CPU.CycleCount, stops)
+	}
+	if emu.StepInstruction() || emu.CPU.PC != 0x200 {
+		t.Errorf("Expected a step to run the jump, PC is 0x%X", emu.CPU.PC)
+	}
+	if !emu.StepInstruction() {
+		t.Errorf("Expected stepping onto the breakpoint to report it")
+	}
+	emu.Resume()
+	if emu.Paused() {
+		t.Errorf("Expected Resume to unpause")
+	}
+}
+
+func TestStepInstructionDisplayWait(t *testing.T) {
+	emu := NewEmulator()
+	emu.SetProfile(platform.VIP)
+	emu.RAM.LoadROM([]byte{
+		0xD0, 0x05, // draw
+		0xD0, 0x05, // draw
+		0xD0, 0x05, // draw
+		0x12, 0x06, // jump 0x206
+	})
+	emu.Pause()
+	for range 3 {
+		emu.StepInstruction()
+	}
+	if emu.CPU.PC != 0x206 || emu.CPU.DrawCount != 3 {
+		t.Errorf("Expected three steps to draw three times despite DisplayWait, PC is 0x%X after %d draws", emu.CPU.PC, emu.CPU.DrawCount)
+	}
+}
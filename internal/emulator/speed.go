@@ -0,0 +1,107 @@
+package emulator
+
+import (
+	"time"
+
+	"github.com/jsutcodes/chip8-goemu/internal/input"
+)
+
+// FRAME_RATE is the rate at which the timers tick and the screen is redrawn.
+const FRAME_RATE = 60
+
+const frameDuration = time.Second / FRAME_RATE
+
+// Speed controls how fast the emulated machine runs compared to real time.
+type Speed struct {
+	InstructionsPerFrame int  // CPU instructions executed per 60 Hz frame
+	FastForward          int  // emulated frames per real frame while fast-forwarding
+	SlowMotion           int  // real frames per emulated frame in slow motion
+	Uncapped             bool // run as fast as possible, without frame pacing
+}
+
+// DefaultSpeed runs CLOCK_SPEED instructions per second in real time.
+func DefaultSpeed() Speed {
+	return Speed{
+		InstructionsPerFrame: CLOCK_SPEED / FRAME_RATE,
+		FastForward:          4,
+		SlowMotion:           4,
+	}
+}
+
+// SetInstructionsPerFrame changes the emulated clock speed. Values below one
+// are clamped to one.
+func (emu *Emulator) SetInstructionsPerFrame(n int) {
+	if n < 1 {
+		n = 1
+	}
+	emu.Speed.InstructionsPerFrame = n
+}
+
+// SetFastForward turns fast-forward on or off.
+func (emu *Emulator) SetFastForward(on bool) {
+	emu.fastForward = on
+}
+
+// ToggleSlowMotion turns slow motion on or off.
+func (emu *Emulator) ToggleSlowMotion() {
+	emu.slowMotion = !emu.slowMotion
+}
+
+// FastForwarding reports whether fast-forward is on.
+func (emu *Emulator) FastForwarding() bool {
+	return emu.fastForward
+}
+
+// InSlowMotion reports whether slow motion is on.
+func (emu *Emulator) InSlowMotion() bool {
+	return emu.slowMotion
+}
+
+// HandleHotkey applies a speed hotkey. pressed is false when the key is
+// released, which only matters for keys that are held.
+func (emu *Emulator) HandleHotkey(hotkey input.Hotkey, pressed bool) {
+	switch hotkey {
+	case input.FastForward:
+		emu.SetFastForward(pressed)
+	case input.SlowMotion:
+		if pressed {
+			emu.ToggleSlowMotion()
+		}
+	case input.Uncapped:
+		if pressed {
+			emu.Speed.Uncapped = !emu.Speed.Uncapped
+		}
+	case input.SpeedUp:
+		if pressed {
+			emu.SetInstructionsPerFrame(emu.Speed.InstructionsPerFrame + 1)
+		}
+	case input.SpeedDown:
+		if pressed {
+			emu.SetInstructionsPerFrame(emu.Speed.InstructionsPerFrame - 1)
+		}
+	}
+}
+
+// instructionsPerFrame treats an unset Speed as the default clock speed.
+func (emu *Emulator) instructionsPerFrame() int {
+	if emu.Speed.InstructionsPerFrame < 1 {
+		return DefaultSpeed().InstructionsPerFrame
+	}
+	return emu.Speed.InstructionsPerFrame
+}
+
+// framesPerTick is how many emulated frames run before the next redraw.
+func (emu *Emulator) framesPerTick() int {
+	if emu.fastForward && emu.Speed.FastForward > 1 {
+		return emu.Speed.FastForward
+	}
+	return 1
+}
+
+// tickDuration is how long one redraw should take in real time.
+func (emu *Emulator) tickDuration() time.Duration {
+	if emu.slowMotion && emu.Speed.SlowMotion > 1 {
+		return frameDuration * time.Duration(emu.Speed.SlowMotion)
+	}
+	return frameDuration
+}
@@ -0,0 +1,76 @@
+package emulator
+
+import (
+	"testing"
+
+	"github.com/jsutcodes/chip8-goemu/internal/input"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestHandleHotkey(t *testing.T) {
+	emu := NewEmulator()
+	ipf := emu.Speed.InstructionsPerFrame
+
+	emu.HandleHotkey(input.SpeedUp, true)
+	if emu.Speed.InstructionsPerFrame != ipf+1 {
+		t.Errorf("Expected %d instructions per frame, got %d", ipf+1, emu.Speed.InstructionsPerFrame)
+	}
+
+	emu.HandleHotkey(input.FastForward, true)
+	if emu.framesPerTick() != emu.Speed.FastForward {
+		t.Errorf("Expected %d frames per tick while fast-forwarding, got %d", emu.Speed.FastForward, emu.framesPerTick())
+	}
+	emu.HandleHotkey(input.FastForward, false)
+	if emu.framesPerTick() != 1 {
+		t.Errorf("Expected 1 frame per tick after releasing fast-forward, got %d", emu.framesPerTick())
+	}
+
+	emu.HandleHotkey(input.SlowMotion, true)
+	if emu.tickDuration() != frameDuration*4 {
+		t.Errorf("Expected slow motion to stretch ticks to %v, got %v", frameDuration*4, emu.tickDuration())
+	}
+	emu.HandleHotkey(input.SlowMotion, false)
+	if !emu.slowMotion {
+		t.Errorf("Expected releasing the slow motion key to leave it on")
+	}
+}
+
+func TestSetInstructionsPerFrameClamps(t *testing.T) {
+	emu := NewEmulator()
+	emu.SetInstructionsPerFrame(0)
+	if emu.Speed.InstructionsPerFrame != 1 {
+		t.Errorf("Expected 1 instruction per frame, got %d", emu.Speed.InstructionsPerFrame)
+	}
+}
+
+func TestRunPollsEvents(t *testing.T) {
+	emu := NewEmulator()
+	emu.Dump.Path = ""
+	ipf := emu.Speed.InstructionsPerFrame
+	events := []sdl.Event{
+		&sdl.KeyboardEvent{Type: sdl.KEYDOWN, Keysym: sdl.Keysym{Sym: sdl.K_TAB}},
+		&sdl.KeyboardEvent{Type: sdl.KEYDOWN, Keysym: sdl.Keysym{Sym: sdl.K_EQUALS}},
+		&sdl.KeyboardEvent{Type: sdl.KEYDOWN, Keysym: sdl.Keysym{Sym: sdl.K_w}},
+	}
+	emu.Events = func() sdl.Event {
+		if len(events) == 0 {
+			return nil
+		}
+		event := events[0]
+		events = events[1:]
+		return event
+	}
+	emu.Poll = emu.Stop // one tick
+	emu.running.Store(true)
+	emu.Run()
+
+	if len(events) != 0 {
+		t.Fatalf("Expected every pending event to be read, %d left", len(events))
+	}
+	if !emu.FastForwarding() || emu.Speed.InstructionsPerFrame != ipf+1 {
+		t.Errorf("Expected Tab and = to fast-forward and speed up")
+	}
+	if !emu.Input.IsKeyPressed(0x5) {
+		t.Errorf("Expected W to press key 5")
+	}
+}
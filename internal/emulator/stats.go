@@ -0,0 +1,136 @@
+package emulator
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// statsWindow is how often Stats is recalculated.
+const statsWindow = time.Second
+
+// Stats describes how the emulator performed over the last second of
+// running. Durations are averages per tick, where a tick is one pass of the
+// Run loop: input, one or more frames of CPU, then a redraw.
+type Stats struct {
+	IPS           float64       // instructions executed per second
+	FPS           float64       // emulated frames per second
+	FrameTime     time.Duration // mean real time between ticks
+	FrameJitter   time.Duration // standard deviation of the time between ticks
+	CPUTime       time.Duration // time spent executing instructions
+	RenderTime    time.Duration // time spent drawing the screen
+	InputTime     time.Duration // time spent polling input
+	DrawsPerFrame float64       // DXYN instructions per emulated frame
+	MaxDraws      int           // most DXYN instructions in a single frame
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("IPS %.0f | FPS %.1f | frame %v ±%v | cpu %v render %v input %v | draws/frame %.1f (max %d)",
+		s.IPS, s.FPS,
+		s.FrameTime.Round(10*time.Microsecond), s.FrameJitter.Round(10*time.Microsecond),
+		s.CPUTime.Round(time.Microsecond), s.RenderTime.Round(time.Microsecond), s.InputTime.Round(time.Microsecond),
+		s.DrawsPerFrame, s.MaxDraws)
+}
+
+// statsCollector accumulates tick measurements and turns them into a Stats
+// once per window. It is safe to read from another goroutine while Run is
+// recording.
+type statsCollector struct {
+	mu      sync.Mutex
+	current Stats
+
+	windowStart  time.Time
+	lastTick     time.Time
+	ticks        int
+	intervals    int
+	frames       int
+	instructions int
+	draws        int
+	maxDraws     int
+	sum, sumSq   float64 // of tick intervals in seconds
+	cpu          time.Duration
+	render       time.Duration
+	input        time.Duration
+}
+
+// tickSample is what the Run loop measures for one tick.
+type tickSample struct {
+	start        time.Time
+	frames       int
+	instructions int
+	draws        int
+	maxDraws     int // most draws in one of the frames
+	cpu          time.Duration
+	render       time.Duration
+	input        time.Duration
+}
+
+// record adds one tick and reports whether a new Stats was published.
+func (sc *statsCollector) record(t tickSample) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.windowStart.IsZero() {
+		sc.windowStart = t.start
+	}
+	if !sc.lastTick.IsZero() {
+		interval := t.start.Sub(sc.lastTick).Seconds()
+		sc.sum += interval
+		sc.sumSq += interval * interval
+		sc.intervals++
+	}
+	sc.lastTick = t.start
+	sc.ticks++
+	sc.frames += t.frames
+	sc.instructions += t.instructions
+	sc.draws += t.draws
+	if t.maxDraws > sc.maxDraws {
+		sc.maxDraws = t.maxDraws
+	}
+	sc.cpu += t.cpu
+	sc.render += t.render
+	sc.input += t.input
+
+	elapsed := t.start.Sub(sc.windowStart)
+	if elapsed < statsWindow {
+		return false
+	}
+
+	seconds := elapsed.Seconds()
+	s := Stats{
+		IPS:        float64(sc.instructions) / seconds,
+		FPS:        float64(sc.frames) / seconds,
+		CPUTime:    sc.cpu / time.Duration(sc.ticks),
+		RenderTime: sc.render / time.Duration(sc.ticks),
+		InputTime:  sc.input / time.Duration(sc.ticks),
+		MaxDraws:   sc.maxDraws,
+	}
+	if sc.frames > 0 {
+		s.DrawsPerFrame = float64(sc.draws) / float64(sc.frames)
+	}
+	if sc.intervals > 0 {
+		mean := sc.sum / float64(sc.intervals)
+		variance := math.Max(0, sc.sumSq/float64(sc.intervals)-mean*mean)
+		s.FrameTime = time.Duration(mean * float64(time.Second))
+		s.FrameJitter = time.Duration(math.Sqrt(variance) * float64(time.Second))
+	}
+	sc.current = s
+
+	sc.windowStart = t.start
+	sc.ticks, sc.intervals, sc.frames, sc.instructions, sc.draws, sc.maxDraws = 0, 0, 0, 0, 0, 0
+	sc.sum, sc.sumSq = 0, 0
+	sc.cpu, sc.render, sc.input = 0, 0, 0
+	return true
+}
+
+func (sc *statsCollector) snapshot() Stats {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.current
+}
+
+// Stats returns the performance figures for the most recent second of Run.
+func (emu *Emulator) Stats() Stats {
+	return emu.stats.snapshot()
+}
@@ -0,0 +1,74 @@
+package emulator
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsCollector(t *testing.T) {
+	var sc statsCollector
+	start := time.Unix(0, 0)
+
+	// Ten ticks per window, alternating 90ms and 110ms apart.
+	at := start
+	for i := 0; i <= 10; i++ {
+		published := sc.record(tickSample{
+			start:        at,
+			frames:       6,
+			instructions: 70,
+			draws:        3,
+			maxDraws:     i % 3,
+			cpu:          2 * time.Millisecond,
+			render:       time.Millisecond,
+		})
+		if published != (i == 10) {
+			t.Fatalf("tick %d: expected published %v", i, i == 10)
+		}
+		if i%2 == 0 {
+			at = at.Add(90 * time.Millisecond)
+		} else {
+			at = at.Add(110 * time.Millisecond)
+		}
+	}
+
+	s := sc.snapshot()
+	if s.FPS < 65 || s.FPS > 67 {
+		t.Errorf("Expected about 66 FPS, got %.1f", s.FPS)
+	}
+	if s.IPS < 769 || s.IPS > 771 {
+		t.Errorf("Expected 770 IPS, got %.1f", s.IPS)
+	}
+	if s.FrameTime.Round(time.Microsecond) != 100*time.Millisecond {
+		t.Errorf("Expected 100ms frame time, got %v", s.FrameTime)
+	}
+	if s.FrameJitter < 9*time.Millisecond || s.FrameJitter > 11*time.Millisecond {
+		t.Errorf("Expected about 10ms jitter, got %v", s.FrameJitter)
+	}
+	if s.DrawsPerFrame != 0.5 {
+		t.Errorf("Expected 0.5 draws per frame, got %v", s.DrawsPerFrame)
+	}
+	if s.MaxDraws != 2 {
+		t.Errorf("Expected at most 2 draws in a frame, got %d", s.MaxDraws)
+	}
+	if s.CPUTime != 2*time.Millisecond || s.RenderTime != time.Millisecond {
+		t.Errorf("Expected 2ms cpu and 1ms render, got %v and %v", s.CPUTime, s.RenderTime)
+	}
+	if !strings.Contains(s.String(), "FPS 66") {
+		t.Errorf("Expected FPS in %q", s.String())
+	}
+}
+
+func TestStatsDuringRun(t *testing.T) {
+	emu := NewEmulator()
+	emu.RAM.LoadROM([]byte{0x12, 0x00}) // jump to self
+	emu.running.Store(true)
+	go emu.Run()
+	time.Sleep(1200 * time.Millisecond)
+	emu.Stop()
+
+	s := emu.Stats()
+	if s.FPS < 30 || s.IPS == 0 {
+		t.Errorf("Expected stats from a running emulator, got %+v", s)
+	}
+}
@@ -0,0 +1,37 @@
+package emulator
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jsutcodes/chip8-goemu/internal/cpu"
+	"github.com/jsutcodes/chip8-goemu/internal/disasm"
+)
+
+// TraceTo writes a line to w for every instruction run from now on, with
+// addresses named from Symbols. A nil w stops tracing.
+func (emu *Emulator) TraceTo(w io.Writer) {
+	emu.trace = w
+	if w != nil && !emu.traceHook {
+		emu.CPU.OnExecute(emu.traced)
+		emu.traceHook = true
+	}
+}
+
+func (emu *Emulator) traced(c *cpu.CPU, pc uint16) {
+	if emu.trace == nil {
+		return
+	}
+	// The instruction and the long address of an F000 NNNN.
+	var code [4]byte
+	for i := range code {
+		code[i], _ = c.RAM.ReadByte((pc + uint16(i)) & c.RAM.Mask())
+	}
+	line := disasm.Disassemble(code[:], pc, pc, 1)[0]
+	where := emu.Symbols.Format(pc)
+	if strings.HasPrefix(where, "0x") {
+		where = ""
+	}
+	fmt.Fprintf(emu.trace, "0x%03X %-20s %04X  %-20s I=%03X\n", pc, where, line.Opcode, line.Named(emu.Symbols.Format), c.I)
+}
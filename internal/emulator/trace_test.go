@@ -0,0 +1,36 @@
+package emulator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jsutcodes/chip8-goemu/internal/symbols"
+)
+
+func TestTraceTo(t *testing.T) {
+	emu := NewEmulator()
+	emu.RAM.LoadROM([]byte{
+		0xA2, 0x04, // 200: I = data
+		0x12, 0x00, // 202: jump 0x200
+		0xFF, 0xFF, // 204: data
+	})
+	emu.Symbols = symbols.New()
+	emu.Symbols.Add("loop", 0x202)
+	emu.Symbols.Add("data", 0x204)
+	var out bytes.Buffer
+	emu.TraceTo(&out)
+	emu.StepInstruction()
+	emu.StepInstruction()
+	emu.TraceTo(nil)
+	emu.StepInstruction()
+
+	want := "0x200                      A204  LD I, data           I=204\n" +
+		"0x202 loop                 1200  JP 0x200             I=204\n"
+	if out.String() != want {
+		t.Errorf("Expected trace\n%s\ngot\n%s", want, out.String())
+	}
+	if strings.Count(out.String(), "\n") != 2 {
+		t.Errorf("Expected tracing to stop")
+	}
+}
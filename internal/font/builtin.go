@@ -0,0 +1,155 @@
+package font
+
+var (
+	// VIP is the font in the COSMAC VIP's CHIP-8 interpreter.
+	VIP = Font{
+		ID:   "vip",
+		Name: "COSMAC VIP",
+		Small: []byte{
+			0xF0, 0x90, 0x90, 0x90, 0xF0, // 0
+			0x60, 0x20, 0x20, 0x20, 0x70, // 1
+			0xF0, 0x10, 0xF0, 0x80, 0xF0, // 2
+			0xF0, 0x10, 0xF0, 0x10, 0xF0, // 3
+			0xA0, 0xA0, 0xF0, 0x20, 0x20, // 4
+			0xF0, 0x80, 0xF0, 0x10, 0xF0, // 5
+			0xF0, 0x80, 0xF0, 0x90, 0xF0, // 6
+			0xF0, 0x10, 0x10, 0x10, 0x10, // 7
+			0xF0, 0x90, 0xF0, 0x90, 0xF0, // 8
+			0xF0, 0x90, 0xF0, 0x10, 0xF0, // 9
+			0xF0, 0x90, 0xF0, 0x90, 0x90, // A
+			0xF0, 0x50, 0x70, 0x50, 0xF0, // B
+			0xF0, 0x80, 0x80, 0x80, 0xF0, // C
+			0xF0, 0x50, 0x50, 0x50, 0xF0, // D
+			0xF0, 0x80, 0xF0, 0x80, 0xF0, // E
+			0xF0, 0x80, 0xF0, 0x80, 0x80, // F
+		},
+	}
+
+	// DREAM6800 is the font of CHIPOS on the DREAM 6800, three pixels wide.
+	DREAM6800 = Font{
+		ID:   "dream6800",
+		Name: "DREAM 6800",
+		Small: []byte{
+			0xE0, 0xA0, 0xA0, 0xA0, 0xE0, // 0
+			0x40, 0x40, 0x40, 0x40, 0x40, // 1
+			0xE0, 0x20, 0xE0, 0x80, 0xE0, // 2
+			0xE0, 0x20, 0xE0, 0x20, 0xE0, // 3
+			0x80, 0xA0, 0xA0, 0xE0, 0x20, // 4
+			0xE0, 0x80, 0xE0, 0x20, 0xE0, // 5
+			0xE0, 0x80, 0xE0, 0xA0, 0xE0, // 6
+			0xE0, 0x20, 0x20, 0x20, 0x20, // 7
+			0xE0, 0xA0, 0xE0, 0xA0, 0xE0, // 8
+			0xE0, 0xA0, 0xE0, 0x20, 0xE0, // 9
+			0xE0, 0xA0, 0xE0, 0xA0, 0xA0, // A
+			0xC0, 0xA0, 0xE0, 0xA0, 0xC0, // B
+			0xE0, 0x80, 0x80, 0x80, 0xE0, // C
+			0xC0, 0xA0, 0xA0, 0xA0, 0xC0, // D
+			0xE0, 0x80, 0xE0, 0x80, 0xE0, // E
+			0xE0, 0x80, 0xC0, 0x80, 0x80, // F
+		},
+	}
+
+	// ETI660 is the font of the ETI-660 interpreter.
+	ETI660 = Font{
+		ID:   "eti660",
+		Name: "ETI-660",
+		Small: []byte{
+			0xE0, 0xA0, 0xA0, 0xA0, 0xE0, // 0
+			0x20, 0x20, 0x20, 0x20, 0x20, // 1
+			0xE0, 0x20, 0xE0, 0x80, 0xE0, // 2
+			0xE0, 0x20, 0xE0, 0x20, 0xE0, // 3
+			0xA0, 0xA0, 0xE0, 0x20, 0x20, // 4
+			0xE0, 0x80, 0xE0, 0x20, 0xE0, // 5
+			0xE0, 0x80, 0xE0, 0xA0, 0xE0, // 6
+			0xE0, 0x20, 0x20, 0x20, 0x20, // 7
+			0xE0, 0xA0, 0xE0, 0xA0, 0xE0, // 8
+			0xE0, 0xA0, 0xE0, 0x20, 0xE0, // 9
+			0xE0, 0xA0, 0xE0, 0xA0, 0xA0, // A
+			0x80, 0x80, 0xE0, 0xA0, 0xE0, // B
+			0xE0, 0x80, 0x80, 0x80, 0xE0, // C
+			0x20, 0x20, 0xE0, 0xA0, 0xE0, // D
+			0xE0, 0x80, 0xE0, 0x80, 0xE0, // E
+			0xE0, 0x80, 0xC0, 0x80, 0x80, // F
+		},
+	}
+
+	// SCHIP is the font of SUPER-CHIP 1.1 on the HP48, whose large font
+	// only has the digits 0-9.
+	SCHIP = Font{
+		ID:   "schip",
+		Name: "SUPER-CHIP",
+		Small: []byte{
+			0x60, 0xA0, 0xA0, 0xA0, 0xC0, // 0
+			0x40, 0xC0, 0x40, 0x40, 0xE0, // 1
+			0xC0, 0x20, 0x40, 0x80, 0xE0, // 2
+			0xC0, 0x20, 0x40, 0x20, 0xC0, // 3
+			0x20, 0xA0, 0xE0, 0x20, 0x20, // 4
+			0xE0, 0x80, 0xC0, 0x20, 0xC0, // 5
+			0x40, 0x80, 0xC0, 0xA0, 0x40, // 6
+			0xE0, 0x20, 0x60, 0x40, 0x40, // 7
+			0x40, 0xA0, 0x40, 0xA0, 0x40, // 8
+			0x40, 0xA0, 0x60, 0x20, 0x40, // 9
+			0x40, 0xA0, 0xE0, 0xA0, 0xA0, // A
+			0xC0, 0xA0, 0xC0, 0xA0, 0xC0, // B
+			0x60, 0x80, 0x80, 0x80, 0x60, // C
+			0xC0, 0xA0, 0xA0, 0xA0, 0xC0, // D
+			0xE0, 0x80, 0xC0, 0x80, 0xE0, // E
+			0xE0, 0x80, 0xC0, 0x80, 0x80, // F
+		},
+		Large: []byte{
+			0x3C, 0x7E, 0xE7, 0xC3, 0xC3, 0xC3, 0xC3, 0xE7, 0x7E, 0x3C, // 0
+			0x18, 0x38, 0x58, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x3C, // 1
+			0x3E, 0x7F, 0xC3, 0x06, 0x0C, 0x18, 0x30, 0x60, 0xFF, 0xFF, // 2
+			0x3C, 0x7E, 0xC3, 0x03, 0x0E, 0x0E, 0x03, 0xC3, 0x7E, 0x3C, // 3
+			0x06, 0x0E, 0x1E, 0x36, 0x66, 0xC6, 0xFF, 0xFF, 0x06, 0x06, // 4
+			0xFF, 0xFF, 0xC0, 0xC0, 0xFC, 0xFE, 0x03, 0xC3, 0x7E, 0x3C, // 5
+			0x3E, 0x7C, 0xE0, 0xC0, 0xFC, 0xFE, 0xC3, 0xC3, 0x7E, 0x3C, // 6
+			0xFF, 0xFF, 0x03, 0x06, 0x0C, 0x18, 0x30, 0x60, 0x60, 0x60, // 7
+			0x3C, 0x7E, 0xC3, 0xC3, 0x7E, 0x7E, 0xC3, 0xC3, 0x7E, 0x3C, // 8
+			0x3C, 0x7E, 0xC3, 0xC3, 0x7F, 0x3F, 0x03, 0x03, 0x3E, 0x7C, // 9
+		},
+	}
+
+	// Octo is the font Octo uses, and most modern interpreters with it. Its
+	// large font covers all 16 digits.
+	Octo = Font{
+		ID:   "octo",
+		Name: "Octo",
+		Small: []byte{
+			0xF0, 0x90, 0x90, 0x90, 0xF0, // 0
+			0x20, 0x60, 0x20, 0x20, 0x70, // 1
+			0xF0, 0x10, 0xF0, 0x80, 0xF0, // 2
+			0xF0, 0x10, 0xF0, 0x10, 0xF0, // 3
+			0x90, 0x90, 0xF0, 0x10, 0x10, // 4
+			0xF0, 0x80, 0xF0, 0x10, 0xF0, // 5
+			0xF0, 0x80, 0xF0, 0x90, 0xF0, // 6
+			0xF0, 0x10, 0x20, 0x40, 0x40, // 7
+			0xF0, 0x90, 0xF0, 0x90, 0xF0, // 8
+			0xF0, 0x90, 0xF0, 0x10, 0xF0, // 9
+			0xF0, 0x90, 0xF0, 0x90, 0x90, // A
+			0xE0, 0x90, 0xE0, 0x90, 0xE0, // B
+			0xF0, 0x80, 0x80, 0x80, 0xF0, // C
+			0xE0, 0x90, 0x90, 0x90, 0xE0, // D
+			0xF0, 0x80, 0xF0, 0x80, 0xF0, // E
+			0xF0, 0x80, 0xF0, 0x80, 0x80, // F
+		},
+		Large: []byte{
+			0xFF, 0xFF, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, // 0
+			0x18, 0x78, 0x78, 0x18, 0x18, 0x18, 0x18, 0x18, 0xFF, 0xFF, // 1
+			0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, // 2
+			0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, // 3
+			0xC3, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, 0x03, 0x03, 0x03, 0x03, // 4
+			0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, // 5
+			0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, // 6
+			0xFF, 0xFF, 0x03, 0x03, 0x06, 0x0C, 0x18, 0x18, 0x18, 0x18, // 7
+			0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, // 8
+			0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, // 9
+			0x7E, 0xFF, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xC3, // A
+			0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, // B
+			0x3C, 0xFF, 0xC3, 0xC0, 0xC0, 0xC0, 0xC0, 0xC3, 0xFF, 0x3C, // C
+			0xFC, 0xFE, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFE, 0xFC, // D
+			0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, // E
+			0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xC0, 0xC0, // F
+		},
+	}
+)
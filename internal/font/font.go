@@ -0,0 +1,96 @@
+package font
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+const (
+	// SmallHeight is how many bytes each 4x5 hex digit takes.
+	SmallHeight = 5
+	// LargeHeight is how many bytes each 8x10 SUPER-CHIP digit takes.
+	LargeHeight = 10
+
+	smallSize = 16 * SmallHeight
+)
+
+// ErrBadSize is returned by Read for a file that is not a small font,
+// optionally followed by a large one.
+var ErrBadSize = errors.New("font file has the wrong size")
+
+// Font is the set of digit sprites an interpreter keeps in memory for FX29
+// and, on SUPER-CHIP and later, FX30.
+type Font struct {
+	ID    string // short name used on the command line
+	Name  string
+	Small []byte // 16 characters, 0-F, of SmallHeight bytes each
+	Large []byte // 10 or 16 characters of LargeHeight bytes each, or nil
+}
+
+// SmallAt returns the offset of character c in Small. Only the low digit of
+// c counts, as on the original interpreters.
+func SmallAt(c byte) uint16 {
+	return uint16(c&0xF) * SmallHeight
+}
+
+// LargeAt returns the offset of character c in Large.
+func LargeAt(c byte) uint16 {
+	return uint16(c&0xF) * LargeHeight
+}
+
+// Read loads a custom font: 80 bytes of small digits, optionally followed
+// by 100 or 160 bytes of large ones. name is only used in messages.
+func Read(r io.Reader, name string) (Font, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Font{}, err
+	}
+	switch len(data) {
+	case smallSize, smallSize + 10*LargeHeight, smallSize + 16*LargeHeight:
+	default:
+		return Font{}, fmt.Errorf("%w: %s is %d bytes, want %d, %d or %d", ErrBadSize, name, len(data),
+			smallSize, smallSize+10*LargeHeight, smallSize+16*LargeHeight)
+	}
+	f := Font{ID: name, Name: name, Small: data[:smallSize]}
+	if len(data) > smallSize {
+		f.Large = data[smallSize:]
+	}
+	return f, nil
+}
+
+// Open loads a custom font from the file at path.
+func Open(path string) (Font, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Font{}, err
+	}
+	defer file.Close()
+	return Read(file, path)
+}
+
+var fonts = map[string]Font{
+	VIP.ID:       VIP,
+	DREAM6800.ID: DREAM6800,
+	ETI660.ID:    ETI660,
+	SCHIP.ID:     SCHIP,
+	Octo.ID:      Octo,
+}
+
+// Lookup finds a built-in font by its ID.
+func Lookup(id string) (Font, bool) {
+	f, ok := fonts[id]
+	return f, ok
+}
+
+// IDs lists the IDs of the built-in fonts in alphabetical order.
+func IDs() []string {
+	ids := make([]string, 0, len(fonts))
+	for id := range fonts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
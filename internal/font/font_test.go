@@ -0,0 +1,39 @@
+package font
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestBuiltins(t *testing.T) {
+	for _, id := range IDs() {
+		f, _ := Lookup(id)
+		if len(f.Small) != 16*SmallHeight {
+			t.Errorf("Expected %s to have 16 small digits, got %d bytes", id, len(f.Small))
+		}
+		if n := len(f.Large); n != 0 && n != 10*LargeHeight && n != 16*LargeHeight {
+			t.Errorf("Expected %s to have 10 or 16 large digits, got %d bytes", id, n)
+		}
+	}
+	if _, ok := Lookup("nope"); ok {
+		t.Errorf("Expected no font called nope")
+	}
+}
+
+func TestRead(t *testing.T) {
+	data := make([]byte, 80+100)
+	data[5] = 0x20
+	data[80] = 0x3C
+	f, err := Read(bytes.NewReader(data), "digits.bin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.Small[SmallAt(1)] != 0x20 || f.Large[LargeAt(0)] != 0x3C {
+		t.Errorf("Expected the file split into small and large digits")
+	}
+
+	if _, err := Read(bytes.NewReader(data[:81]), "short.bin"); !errors.Is(err, ErrBadSize) {
+		t.Errorf("Expected ErrBadSize, got %v", err)
+	}
+}
@@ -0,0 +1,399 @@
+// Package gdbstub lets GDB and other front ends that speak its remote
+// serial protocol debug a CHIP-8 program. The stub serves one connection at
+// a time over TCP and drives the emulator through the debugger core, so it
+// shares breakpoints with the console.
+//
+// The CPU is described to GDB by a target description with these registers,
+// numbered in this order: V0 to VF (8 bits), I and PC (16 bits), and SP, DT
+// and ST (8 bits). Software and hardware breakpoints both set CPU
+// breakpoints; write, read and access watchpoints watch data accesses by
+// instructions.
+package gdbstub
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jsutcodes/chip8-goemu/internal/cpu"
+	"github.com/jsutcodes/chip8-goemu/internal/debugger"
+	"github.com/jsutcodes/chip8-goemu/internal/emulator"
+)
+
+// targetXML is the target description GDB reads with qXfer.
+const targetXML = `<?xml version="1.0"?>
+<!DOCTYPE target SYSTEM "gdb-target.dtd">
+<target version="1.0">
+  <feature name="org.chip8.cpu">
+    <reg name="v0" bitsize="8" type="uint8" regnum="0"/>
+    <reg name="v1" bitsize="8" type="uint8"/>
+    <reg name="v2" bitsize="8" type="uint8"/>
+    <reg name="v3" bitsize="8" type="uint8"/>
+    <reg name="v4" bitsize="8" type="uint8"/>
+    <reg name="v5" bitsize="8" type="uint8"/>
+    <reg name="v6" bitsize="8" type="uint8"/>
+    <reg name="v7" bitsize="8" type="uint8"/>
+    <reg name="v8" bitsize="8" type="uint8"/>
+    <reg name="v9" bitsize="8" type="uint8"/>
+    <reg name="va" bitsize="8" type="uint8"/>
+    <reg name="vb" bitsize="8" type="uint8"/>
+    <reg name="vc" bitsize="8" type="uint8"/>
+    <reg name="vd" bitsize="8" type="uint8"/>
+    <reg name="ve" bitsize="8" type="uint8"/>
+    <reg name="vf" bitsize="8" type="uint8"/>
+    <reg name="i" bitsize="16" type="data_ptr"/>
+    <reg name="pc" bitsize="16" type="code_ptr"/>
+    <reg name="sp" bitsize="8" type="uint8"/>
+    <reg name="dt" bitsize="8" type="uint8"/>
+    <reg name="st" bitsize="8" type="uint8"/>
+  </feature>
+</target>
+`
+
+// Register numbers after the sixteen V registers.
+const (
+	regI = 16 + iota
+	regPC
+	regSP
+	regDT
+	regST
+	numRegs
+)
+
+// Server is a GDB remote stub for one emulator.
+type Server struct {
+	d     *debugger.Debugger
+	emu   *emulator.Emulator
+	stops chan struct{} // signalled when the emulator stops at a breakpoint
+	mu    sync.Mutex    // serialises writes to the connection
+	noAck atomic.Bool   // the client asked for no acknowledgements
+	// watches remembers the kind of each watchpoint, for stop replies.
+	watches map[uint16]string
+}
+
+// New returns a stub for the emulator d debugs. It has to be called before
+// the emulator starts running.
+func New(d *debugger.Debugger) *Server {
+	s := &Server{d: d, emu: d.Emulator(), stops: make(chan struct{}, 1), watches: map[uint16]string{}}
+	d.EnableRemote()
+	d.OnStop(func(pc uint16) {
+		select {
+		case s.stops <- struct{}{}:
+		default:
+		}
+	})
+	return s
+}
+
+// Serve accepts connections on l and serves them one at a time until l is
+// closed. The program is paused while a client is connected and stopped,
+// and carries on when the client detaches or goes away.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		s.serve(conn)
+	}
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer conn.Close()
+	s.noAck.Store(false)
+	packets := make(chan string)
+	interrupts := make(chan struct{}, 1)
+	go s.readPackets(conn, conn, packets, interrupts)
+	s.d.Do(s.emu.Pause)
+	defer s.d.Do(s.emu.Resume)
+
+	for packet := range packets {
+		reply, resume := s.exec(packet)
+		if resume {
+			var ok bool
+			if reply, ok = s.wait(packets, interrupts); !ok {
+				return
+			}
+		}
+		s.send(conn, reply)
+		switch {
+		case packet == "QStartNoAckMode":
+			s.noAck.Store(true)
+		case packet == "k" || packet == "D" || strings.HasPrefix(packet, "D;"):
+			return
+		}
+	}
+}
+
+// wait lets the program run until it stops at a breakpoint or the client
+// interrupts it, and returns the stop reply. It reports false if the client
+// went away meanwhile.
+func (s *Server) wait(packets <-chan string, interrupts <-chan struct{}) (string, bool) {
+	for {
+		select {
+		case <-s.stops:
+			var reply string
+			s.d.Do(func() { reply = s.stopReply() })
+			return reply, true
+		case <-interrupts:
+			s.d.Do(s.emu.Pause)
+			return "T02", true
+		case _, ok := <-packets:
+			if !ok {
+				return "", false
+			}
+			// Anything but an interrupt is ignored while running.
+		}
+	}
+}
+
+// stopReply describes why the program is stopped, naming the address if a
+// watchpoint stopped it.
+func (s *Server) stopReply() string {
+	if address, _, ok := s.emu.CPU.Watchpoint(); ok {
+		return fmt.Sprintf("T05%s:%x;", s.watches[address], address)
+	}
+	return "T05"
+}
+
+// exec carries out one command and returns the reply. For commands that set
+// the program running it reports resume instead, and the reply waits for
+// the program to stop.
+func (s *Server) exec(packet string) (reply string, resume bool) {
+	switch {
+	case packet == "?":
+		return "S05", false
+	case packet == "QStartNoAckMode" || strings.HasPrefix(packet, "H"):
+		return "OK", false
+	case strings.HasPrefix(packet, "qSupported"):
+		return "PacketSize=4000;qXfer:features:read+;QStartNoAckMode+", false
+	case strings.HasPrefix(packet, "qXfer:features:read:target.xml:"):
+		return features(strings.TrimPrefix(packet, "qXfer:features:read:target.xml:")), false
+	case packet == "qAttached":
+		return "1", false
+	case packet == "qC":
+		return "QC1", false
+	case packet == "qfThreadInfo":
+		return "m1", false
+	case packet == "qsThreadInfo":
+		return "l", false
+	case packet == "D" || strings.HasPrefix(packet, "D;"):
+		return "OK", false
+	case packet == "k":
+		return "", false
+	case packet == "s" || packet == "c" || strings.HasPrefix(packet, "s") && isHex(packet[1:]) || strings.HasPrefix(packet, "c") && isHex(packet[1:]):
+		return s.resume(packet)
+	}
+
+	s.d.Do(func() { reply = s.machine(packet) })
+	return reply, false
+}
+
+// resume handles s and c, either of which may give an address to resume
+// from.
+func (s *Server) resume(packet string) (string, bool) {
+	var reply string
+	step := packet[0] == 's'
+	s.d.Do(func() {
+		if len(packet) > 1 {
+			address, _ := strconv.ParseUint(packet[1:], 16, 16)
+			s.emu.CPU.PC = uint16(address) & s.emu.RAM.Mask()
+		}
+		if step {
+			s.d.Step(1)
+			reply = s.stopReply()
+			return
+		}
+		select {
+		case <-s.stops: // a stale stop from before
+		default:
+		}
+		s.emu.Resume()
+	})
+	return reply, !step
+}
+
+// machine handles the commands that read or change the machine. It runs on
+// the emulator's goroutine.
+func (s *Server) machine(packet string) string {
+	c := s.emu.CPU
+	switch packet[0] {
+	case 'g':
+		var b strings.Builder
+		for n := 0; n < numRegs; n++ {
+			b.WriteString(s.register(n))
+		}
+		return b.String()
+	case 'G':
+		data, err := hex.DecodeString(packet[1:])
+		if err != nil || len(data) != 23 {
+			return "E01"
+		}
+		copy(c.V[:], data)
+		c.I = uint16(data[16]) | uint16(data[17])<<8
+		c.PC = (uint16(data[18]) | uint16(data[19])<<8) & s.emu.RAM.Mask()
+		c.SP, c.DT, c.ST = min(data[20], byte(len(c.Stack))), data[21], data[22]
+		return "OK"
+	case 'p':
+		n, err := strconv.ParseUint(packet[1:], 16, 8)
+		if err != nil || n >= numRegs {
+			return "E01"
+		}
+		return s.register(int(n))
+	case 'P':
+		reg, value, ok := strings.Cut(packet[1:], "=")
+		n, err := strconv.ParseUint(reg, 16, 8)
+		data, herr := hex.DecodeString(value)
+		if !ok || err != nil || herr != nil || n >= numRegs || len(data) == 0 {
+			return "E01"
+		}
+		s.setRegister(int(n), data)
+		return "OK"
+	case 'm':
+		address, length, ok := s.span(packet[1:])
+		if !ok {
+			return "E01"
+		}
+		data := make([]byte, length)
+		for i := range data {
+			data[i], _ = s.emu.RAM.ReadByte(address + uint16(i))
+		}
+		return hex.EncodeToString(data)
+	case 'M':
+		span, value, _ := strings.Cut(packet[1:], ":")
+		address, length, ok := s.span(span)
+		data, err := hex.DecodeString(value)
+		if !ok || err != nil || len(data) != length {
+			return "E01"
+		}
+		for i, b := range data {
+			s.emu.RAM.WriteByte(address+uint16(i), b)
+		}
+		return "OK"
+	case 'Z', 'z':
+		return s.point(packet)
+	}
+	return "" // not supported
+}
+
+// register encodes register n as GDB expects, little-endian.
+func (s *Server) register(n int) string {
+	c := s.emu.CPU
+	switch n {
+	case regI:
+		return fmt.Sprintf("%02x%02x", byte(c.I), byte(c.I>>8))
+	case regPC:
+		return fmt.Sprintf("%02x%02x", byte(c.PC), byte(c.PC>>8))
+	case regSP:
+		return fmt.Sprintf("%02x", c.SP)
+	case regDT:
+		return fmt.Sprintf("%02x", c.DT)
+	case regST:
+		return fmt.Sprintf("%02x", c.ST)
+	}
+	return fmt.Sprintf("%02x", c.V[n])
+}
+
+func (s *Server) setRegister(n int, data []byte) {
+	c := s.emu.CPU
+	word := uint16(data[0])
+	if len(data) > 1 {
+		word |= uint16(data[1]) << 8
+	}
+	switch n {
+	case regI:
+		c.I = word
+	case regPC:
+		c.PC = word & s.emu.RAM.Mask()
+	case regSP:
+		c.SP = min(data[0], byte(len(c.Stack)))
+	case regDT:
+		c.DT = data[0]
+	case regST:
+		c.ST = data[0]
+	default:
+		c.V[n] = data[0]
+	}
+}
+
+// span parses "addr,length" and checks it lies in memory.
+func (s *Server) span(text string) (uint16, int, bool) {
+	a, l, ok := strings.Cut(text, ",")
+	address, err1 := strconv.ParseUint(a, 16, 32)
+	length, err2 := strconv.ParseUint(l, 16, 32)
+	if !ok || err1 != nil || err2 != nil || int(address+length) > s.emu.RAM.Size() {
+		return 0, 0, false
+	}
+	return uint16(address), int(length), true
+}
+
+// point handles Z and z, which insert and remove breakpoints (types 0 and
+// 1) and write, read and access watchpoints (types 2, 3 and 4) over the
+// given number of bytes.
+func (s *Server) point(packet string) string {
+	fields := strings.Split(packet[1:], ",")
+	if len(fields) < 3 {
+		return "E01"
+	}
+	address, err := strconv.ParseUint(fields[1], 16, 16)
+	if err != nil || int(address) >= s.emu.RAM.Size() {
+		return "E01"
+	}
+	length, err := strconv.ParseUint(fields[2], 16, 16)
+	if err != nil {
+		return "E01"
+	}
+	insert := packet[0] == 'Z'
+	c := s.emu.CPU
+	switch fields[0] {
+	case "0", "1":
+		if insert {
+			c.SetBreakpoint(uint16(address))
+		} else {
+			c.ClearBreakpoint(uint16(address))
+		}
+		return "OK"
+	case "2", "3", "4":
+		kind := map[string]string{"2": "watch", "3": "rwatch", "4": "awatch"}[fields[0]]
+		accesses := map[string][]cpu.Access{"2": {cpu.Store}, "3": {cpu.Load}, "4": {cpu.Load, cpu.Store}}[fields[0]]
+		for i := uint64(0); i < max(length, 1) && int(address+i) < s.emu.RAM.Size(); i++ {
+			a := uint16(address + i)
+			if insert {
+				c.SetWatchpoint(a, accesses...)
+				s.watches[a] = kind
+			} else {
+				c.ClearWatchpoint(a)
+				delete(s.watches, a)
+			}
+		}
+		return "OK"
+	}
+	return ""
+}
+
+// features answers a read of the target description at "offset,length".
+func features(span string) string {
+	o, l, _ := strings.Cut(span, ",")
+	offset, err1 := strconv.ParseUint(o, 16, 32)
+	length, err2 := strconv.ParseUint(l, 16, 32)
+	if err1 != nil || err2 != nil {
+		return "E01"
+	}
+	if offset >= uint64(len(targetXML)) {
+		return "l"
+	}
+	end := min(offset+length, uint64(len(targetXML)))
+	if end == uint64(len(targetXML)) {
+		return "l" + targetXML[offset:end]
+	}
+	return "m" + targetXML[offset:end]
+}
+
+func isHex(s string) bool {
+	_, err := strconv.ParseUint(s, 16, 32)
+	return err == nil
+}
@@ -0,0 +1,170 @@
+package gdbstub
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jsutcodes/chip8-goemu/internal/debugger"
+	"github.com/jsutcodes/chip8-goemu/internal/emulator"
+)
+
+// client is a scripted GDB talking to the stub.
+type client struct {
+	t     *testing.T
+	conn  net.Conn
+	r     *bufio.Reader
+	noAck bool
+}
+
+func (c *client) send(packet string) {
+	c.t.Helper()
+	fmt.Fprintf(c.conn, "$%s#%02x", packet, checksum(packet))
+	if !c.noAck {
+		if b, err := c.r.ReadByte(); err != nil || b != '+' {
+			c.t.Fatalf("%s: expected an acknowledgement, got %q %v", packet, b, err)
+		}
+	}
+}
+
+func (c *client) receive() string {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := c.r.ReadString('$'); err != nil {
+		c.t.Fatalf("no reply: %v", err)
+	}
+	body, err := c.r.ReadString('#')
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	sum := make([]byte, 2)
+	io.ReadFull(c.r, sum)
+	body = body[:len(body)-1]
+	if string(sum) != fmt.Sprintf("%02x", checksum(body)) {
+		c.t.Fatalf("bad checksum on %q", body)
+	}
+	if !c.noAck {
+		c.conn.Write([]byte("+"))
+	}
+	return body
+}
+
+// ask sends a packet and checks the reply.
+func (c *client) ask(packet, want string) {
+	c.t.Helper()
+	c.send(packet)
+	if got := c.receive(); got != want {
+		c.t.Errorf("%s: expected %q, got %q", packet, want, got)
+	}
+}
+
+func start(t *testing.T) *client {
+	emu := emulator.NewEmulator()
+	emu.RAM.LoadROM([]byte{
+		0x60, 0x01, // 200: V0 = 1
+		0xA3, 0x00, // 202: I = 0x300
+		0x70, 0x01, // 204: V0 += 1
+		0xF0, 0x55, // 206: save V0 at 0x300
+		0x12, 0x02, // 208: jump 0x202
+	})
+	s := New(debugger.New(emu))
+	emu.Pause() // as chip8 -gdb does, to wait for the client
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go s.Serve(l)
+	// Stand in for Run.
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			emu.Poll()
+			if !emu.Paused() {
+				emu.Frame()
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	t.Cleanup(func() {
+		l.Close()
+		close(done)
+	})
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &client{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+func TestSession(t *testing.T) {
+	c := start(t)
+	c.send("qSupported:swbreak+;hwbreak+")
+	if reply := c.receive(); !strings.Contains(reply, "qXfer:features:read+") {
+		t.Errorf("Expected target descriptions to be offered, got %q", reply)
+	}
+	c.send("qXfer:features:read:target.xml:0,fff")
+	if reply := c.receive(); !strings.HasPrefix(reply, "l<?xml") || !strings.Contains(reply, `<reg name="pc" bitsize="16" type="code_ptr"/>`) {
+		t.Errorf("Unexpected target description %q", reply)
+	}
+	c.ask("?", "S05")
+
+	c.send("g")
+	regs := c.receive()
+	if len(regs) != 46 || regs[36:40] != "0002" {
+		t.Errorf("Expected 23 bytes of registers with PC 0x200, got %q", regs)
+	}
+	c.ask("m200,4", "6001a300")
+	c.ask("M300,2:abcd", "OK")
+	c.ask("m300,2", "abcd")
+	c.ask("mfff,2", "E01")
+	c.ask("P3=2a", "OK")
+	c.ask("p3", "2a")
+
+	c.ask("Z0,206,2", "OK")
+	c.ask("c", "T05")
+	c.ask("p11", "0602")
+	c.ask("p0", "02")
+	c.ask("z0,206,2", "OK")
+
+	c.ask("Z2,300,1", "OK")
+	c.ask("c", "T05watch:300;")
+	c.ask("p11", "0802")
+	c.ask("m300,1", "02")
+	c.ask("z2,300,1", "OK")
+
+	c.ask("s", "T05")
+	c.ask("p11", "0202")
+
+	c.ask("QStartNoAckMode", "OK")
+	c.noAck = true
+	c.send("c")
+	time.Sleep(20 * time.Millisecond)
+	c.conn.Write([]byte{interrupt})
+	if reply := c.receive(); reply != "T02" {
+		t.Errorf("Expected an interrupt to stop the program, got %q", reply)
+	}
+	c.ask("D", "OK")
+}
+
+func TestEscape(t *testing.T) {
+	for _, s := range []string{"plain", "a#b$c}d*e"} {
+		if got := unescape(escape(s)); got != s {
+			t.Errorf("Expected %q to survive escaping, got %q", s, got)
+		}
+	}
+	if escape("}") != "}]" {
+		t.Errorf("Expected } to be escaped as }], got %q", escape("}"))
+	}
+}
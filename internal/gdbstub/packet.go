@@ -0,0 +1,110 @@
+package gdbstub
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// interrupt is the byte GDB sends outside any packet to stop the target.
+const interrupt = 0x03
+
+// readPackets reads packets from r, acknowledging each unless noAck says
+// not to, until r fails. Packets go to packets, which is closed at the end,
+// and interrupts to interrupts.
+func (s *Server) readPackets(r io.Reader, w io.Writer, packets chan<- string, interrupts chan<- struct{}) {
+	defer close(packets)
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return
+		}
+		switch b {
+		case interrupt:
+			select {
+			case interrupts <- struct{}{}:
+			default:
+			}
+			continue
+		case '$':
+		default:
+			continue // acknowledgements and noise
+		}
+		body, err := br.ReadString('#')
+		if err != nil {
+			return
+		}
+		body = body[:len(body)-1]
+		sum := make([]byte, 2)
+		if _, err := io.ReadFull(br, sum); err != nil {
+			return
+		}
+		want, err := strconv.ParseUint(string(sum), 16, 8)
+		if !s.noAck.Load() {
+			if err != nil || byte(want) != checksum(body) {
+				s.write(w, "-")
+				continue
+			}
+			s.write(w, "+")
+		}
+		packets <- unescape(body)
+	}
+}
+
+// send writes a reply packet.
+func (s *Server) send(w io.Writer, reply string) {
+	body := escape(reply)
+	s.write(w, fmt.Sprintf("$%s#%02x", body, checksum(body)))
+}
+
+// write writes to the connection, which the reader also acknowledges on.
+func (s *Server) write(w io.Writer, data string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	io.WriteString(w, data)
+}
+
+func checksum(body string) byte {
+	var sum byte
+	for i := 0; i < len(body); i++ {
+		sum += body[i]
+	}
+	return sum
+}
+
+// escape protects the bytes that frame packets.
+func escape(s string) string {
+	if !strings.ContainsAny(s, "#$}*") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '#', '$', '}', '*':
+			b.WriteByte('}')
+			b.WriteByte(c ^ 0x20)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, "}") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '}' && i+1 < len(s) {
+			i++
+			b.WriteByte(s[i] ^ 0x20)
+		} else {
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
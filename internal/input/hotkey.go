@@ -0,0 +1,21 @@
+package input
+
+// Hotkey is an emulator control bound to a key outside the CHIP-8 keypad.
+//
+// Hotkey       | SDL Key   | Behaviour
+// -------------|-----------|-------------------------------------
+// FastForward  | Tab       | held down to fast-forward
+// SlowMotion   | Backquote | toggles slow motion
+// Uncapped     | F1        | toggles running without frame pacing
+// SpeedUp      | =         | one more instruction per frame
+// SpeedDown    | -         | one fewer instruction per frame
+type Hotkey int
+
+const (
+	NoHotkey Hotkey = iota
+	FastForward
+	SlowMotion
+	Uncapped
+	SpeedUp
+	SpeedDown
+)
@@ -56,6 +56,16 @@ func (k *Keypad) SetKeyPressed(key uint8, pressed bool) {
 	}
 }
 
+// PressedKey returns the lowest numbered key currently held down, if any.
+func (k *Keypad) PressedKey() (byte, bool) {
+	for i, pressed := range k.keys {
+		if pressed {
+			return byte(i), true
+		}
+	}
+	return 0, false
+}
+
 func (k *Keypad) WaitForKeyPress() byte {
 	for {
 		for i, pressed := range k.keys {
@@ -108,3 +118,36 @@ func (k *Keypad) HandleEvent(event sdl.Event) {
 		}
 	}
 }
+
+// StartEvents starts SDL's event subsystem and returns a function giving
+// the next pending event, or nil if there is none, for Emulator.Events. Call
+// stop once the emulator is done with it.
+func StartEvents() (next func() sdl.Event, stop func(), err error) {
+	if err := sdl.Init(sdl.INIT_EVENTS); err != nil {
+		return nil, nil, err
+	}
+	return sdl.PollEvent, sdl.Quit, nil
+}
+
+// HotkeyFor reports which hotkey, if any, a keyboard event is for and whether
+// the key went down or up.
+func HotkeyFor(event sdl.Event) (Hotkey, bool) {
+	e, ok := event.(*sdl.KeyboardEvent)
+	if !ok || (e.Type != sdl.KEYDOWN && e.Type != sdl.KEYUP) {
+		return NoHotkey, false
+	}
+	pressed := e.Type == sdl.KEYDOWN
+	switch e.Keysym.Sym {
+	case sdl.K_TAB:
+		return FastForward, pressed
+	case sdl.K_BACKQUOTE:
+		return SlowMotion, pressed
+	case sdl.K_F1:
+		return Uncapped, pressed
+	case sdl.K_EQUALS:
+		return SpeedUp, pressed
+	case sdl.K_MINUS:
+		return SpeedDown, pressed
+	}
+	return NoHotkey, false
+}
@@ -56,3 +56,46 @@ func TestKeypad_HandleEvent(t *testing.T) {
 		t.Errorf("Expected key 0x2 to be not pressed after KEYUP event")
 	}
 }
+
+func TestKeypad_PressedKey(t *testing.T) {
+	keypad := NewKeypad()
+
+	if _, ok := keypad.PressedKey(); ok {
+		t.Errorf("Expected no key to be pressed")
+	}
+
+	keypad.SetKeyPressed(0xB, true)
+	keypad.SetKeyPressed(0x4, true)
+
+	if key, ok := keypad.PressedKey(); !ok || key != 0x4 {
+		t.Errorf("Expected key 0x4 to be reported, got 0x%X (%v)", key, ok)
+	}
+}
+
+func TestHotkeyFor(t *testing.T) {
+	event := sdl.KeyboardEvent{
+		Type: sdl.KEYDOWN,
+		Keysym: sdl.Keysym{
+			Sym: sdl.K_TAB,
+		},
+	}
+	if hotkey, pressed := HotkeyFor(&event); hotkey != FastForward || !pressed {
+		t.Errorf("Expected Tab down to be FastForward pressed, got %v %v", hotkey, pressed)
+	}
+
+	event.Keysym.Sym = sdl.K_2
+	if hotkey, _ := HotkeyFor(&event); hotkey != NoHotkey {
+		t.Errorf("Expected keypad key 2 not to be a hotkey, got %v", hotkey)
+	}
+}
+
+func TestKeyForRune(t *testing.T) {
+	for r, want := range map[rune]byte{'1': 0x1, '4': 0xC, 'Q': 0x4, 'x': 0x0, 'v': 0xF} {
+		if key, ok := KeyForRune(r); !ok || key != want {
+			t.Errorf("%q: expected key %X, got %X", r, want, key)
+		}
+	}
+	if _, ok := KeyForRune('p'); ok {
+		t.Errorf("Expected p not to be a key")
+	}
+}
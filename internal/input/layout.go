@@ -0,0 +1,20 @@
+package input
+
+import "unicode"
+
+// layout maps the characters on the 4x4 block of keys under 1 2 3 4 on a
+// QWERTY keyboard to the CHIP-8 keys they stand for, as in the SDL mapping.
+var layout = map[rune]byte{
+	'1': 0x1, '2': 0x2, '3': 0x3, '4': 0xC,
+	'q': 0x4, 'w': 0x5, 'e': 0x6, 'r': 0xD,
+	'a': 0x7, 's': 0x8, 'd': 0x9, 'f': 0xE,
+	'z': 0xA, 'x': 0x0, 'c': 0xB, 'v': 0xF,
+}
+
+// KeyForRune returns the CHIP-8 key a typed character stands for, for front
+// ends such as terminals that see characters rather than keys. Letters
+// count whatever their case.
+func KeyForRune(r rune) (byte, bool) {
+	key, ok := layout[unicode.ToLower(r)]
+	return key, ok
+}
@@ -0,0 +1,309 @@
+package memory
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Format is a way of writing memory out as a file.
+type Format int
+
+const (
+	// Binary is the raw bytes.
+	Binary Format = iota
+	// IntelHex is Intel HEX records, as read by EPROM programmers.
+	IntelHex
+	// Xxd is the text "xxd" prints, which "xxd -r" turns back into bytes.
+	Xxd
+	// JSON is the bytes as hex together with the regions they fall in.
+	JSON
+)
+
+var formatNames = []string{"bin", "ihex", "xxd", "json"}
+
+func (f Format) String() string {
+	if int(f) < len(formatNames) {
+		return formatNames[f]
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// ParseFormat finds a format by the name String gives it.
+func ParseFormat(name string) (Format, error) {
+	for i, n := range formatNames {
+		if n == name {
+			return Format(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown dump format %q, want one of %s", name, strings.Join(formatNames, ", "))
+}
+
+// ErrOutOfRange is returned for dumps and loads that reach past the end of
+// memory.
+var ErrOutOfRange = errors.New("address out of range")
+
+// Range is a span of addresses, from Start up to but not including End. The
+// zero Range stands for all of memory.
+type Range struct {
+	Start int
+	End   int
+}
+
+// ParseRange reads a range written as two hex addresses, "200-3ff", the
+// second being the last byte included.
+func ParseRange(s string) (Range, error) {
+	first, last, ok := strings.Cut(s, "-")
+	if !ok {
+		return Range{}, fmt.Errorf("range %q is not start-end", s)
+	}
+	start, err := strconv.ParseUint(strings.TrimPrefix(first, "0x"), 16, 16)
+	if err != nil {
+		return Range{}, fmt.Errorf("range %q: %w", s, err)
+	}
+	end, err := strconv.ParseUint(strings.TrimPrefix(last, "0x"), 16, 16)
+	if err != nil {
+		return Range{}, fmt.Errorf("range %q: %w", s, err)
+	}
+	if end < start {
+		return Range{}, fmt.Errorf("range %q ends before it starts", s)
+	}
+	return Range{int(start), int(end) + 1}, nil
+}
+
+// bounds resolves the zero Range and checks r fits in memory.
+func (m *Memory) bounds(r Range) (Range, error) {
+	if r == (Range{}) {
+		return Range{0, m.size}, nil
+	}
+	if r.Start < 0 || r.End > m.size || r.Start > r.End {
+		return r, fmt.Errorf("%w: 0x%X-0x%X in %d bytes", ErrOutOfRange, r.Start, r.End, m.size)
+	}
+	return r, nil
+}
+
+// Dump writes the bytes in r to w in the given format.
+func (m *Memory) Dump(w io.Writer, f Format, r Range) error {
+	r, err := m.bounds(r)
+	if err != nil {
+		return err
+	}
+	data := m.bytes[r.Start:r.End]
+	bw := bufio.NewWriter(w)
+	switch f {
+	case Binary:
+		bw.Write(data)
+	case IntelHex:
+		writeIntelHex(bw, data, r.Start)
+	case Xxd:
+		writeXxd(bw, data, r.Start)
+	case JSON:
+		if err := m.writeJSON(bw, r); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown dump format %v", f)
+	}
+	return bw.Flush()
+}
+
+// Load reads a dump in the given format back into memory. Binary dumps hold
+// no addresses, so they are loaded at at; the other formats say where each
+// byte goes and at is ignored.
+func (m *Memory) Load(r io.Reader, f Format, at int) error {
+	var chunks []chunk
+	var err error
+	switch f {
+	case Binary:
+		var data []byte
+		data, err = io.ReadAll(r)
+		chunks = []chunk{{at, data}}
+	case IntelHex:
+		chunks, err = readIntelHex(r)
+	case Xxd:
+		chunks, err = readXxd(r)
+	case JSON:
+		chunks, err = readJSON(r)
+	default:
+		err = fmt.Errorf("unknown dump format %v", f)
+	}
+	if err != nil {
+		return err
+	}
+	for _, c := range chunks {
+		if _, err := m.bounds(Range{c.address, c.address + len(c.data)}); err != nil {
+			return err
+		}
+	}
+	for _, c := range chunks {
+		for i, b := range c.data {
+			m.WriteByte(uint16(c.address+i), b)
+		}
+	}
+	return nil
+}
+
+// chunk is a run of bytes read from a dump and where they go.
+type chunk struct {
+	address int
+	data    []byte
+}
+
+func writeIntelHex(w io.Writer, data []byte, address int) {
+	record := func(kind byte, address int, data []byte) {
+		sum := byte(len(data)) + byte(address>>8) + byte(address) + kind
+		for _, b := range data {
+			sum += b
+		}
+		fmt.Fprintf(w, ":%02X%04X%02X%X%02X\n", len(data), address&0xFFFF, kind, data, -sum)
+	}
+	for i := 0; i < len(data); i += 16 {
+		end := i + 16
+		if end > len(data) {
+			end = len(data)
+		}
+		record(0x00, address+i, data[i:end])
+	}
+	record(0x01, 0, nil)
+}
+
+func readIntelHex(r io.Reader) ([]chunk, error) {
+	var chunks []chunk
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if line[0] != ':' {
+			return nil, fmt.Errorf("line %d: Intel HEX records start with a colon", n)
+		}
+		record, err := hex.DecodeString(line[1:])
+		if err != nil || len(record) < 5 || len(record) != int(record[0])+5 {
+			return nil, fmt.Errorf("line %d: malformed record", n)
+		}
+		var sum byte
+		for _, b := range record {
+			sum += b
+		}
+		if sum != 0 {
+			return nil, fmt.Errorf("line %d: bad checksum", n)
+		}
+		address := int(record[1])<<8 | int(record[2])
+		switch kind := record[3]; kind {
+		case 0x00:
+			chunks = append(chunks, chunk{address, record[4 : len(record)-1]})
+		case 0x01:
+			return chunks, nil
+		default:
+			return nil, fmt.Errorf("line %d: unsupported record type %02X", n, kind)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("Intel HEX has no end-of-file record")
+}
+
+func writeXxd(w io.Writer, data []byte, address int) {
+	for i := 0; i < len(data); i += 16 {
+		fmt.Fprintf(w, "%08x: ", address+i)
+		for j := 0; j < 16; j++ {
+			if i+j < len(data) {
+				fmt.Fprintf(w, "%02x", data[i+j])
+			} else {
+				fmt.Fprint(w, "  ")
+			}
+			if j%2 == 1 {
+				fmt.Fprint(w, " ")
+			}
+		}
+		fmt.Fprint(w, " ")
+		for j := i; j < i+16 && j < len(data); j++ {
+			if b := data[j]; b >= 32 && b <= 126 {
+				fmt.Fprintf(w, "%c", b)
+			} else {
+				fmt.Fprint(w, ".")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func readXxd(r io.Reader) ([]chunk, error) {
+	var chunks []chunk
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		address, rest, ok := strings.Cut(line, ":")
+		value, err := strconv.ParseUint(strings.TrimSpace(address), 16, 32)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("line %d: bad address %q", n, address)
+		}
+		// The bytes end where two spaces set off the text column.
+		rest = strings.TrimLeft(rest, " ")
+		if i := strings.Index(rest, "  "); i >= 0 {
+			rest = rest[:i]
+		}
+		data, err := hex.DecodeString(strings.ReplaceAll(rest, " ", ""))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		chunks = append(chunks, chunk{int(value), data})
+	}
+	return chunks, scanner.Err()
+}
+
+// jsonDump is the layout of a JSON dump.
+type jsonDump struct {
+	Platform string       `json:"platform"`
+	Start    int          `json:"start"`
+	End      int          `json:"end"`
+	Regions  []jsonRegion `json:"regions"`
+	Data     string       `json:"data"`
+}
+
+type jsonRegion struct {
+	Name  string `json:"name"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+func (m *Memory) writeJSON(w io.Writer, r Range) error {
+	d := jsonDump{
+		Platform: m.layout.Name,
+		Start:    r.Start,
+		End:      r.End,
+		Regions:  []jsonRegion{},
+		Data:     hex.EncodeToString(m.bytes[r.Start:r.End]),
+	}
+	for _, region := range m.layout.Regions() {
+		start, end := max(region.Start, r.Start), min(region.End, r.End)
+		if start < end {
+			d.Regions = append(d.Regions, jsonRegion{region.Name, start, end})
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+func readJSON(r io.Reader) ([]chunk, error) {
+	var d jsonDump
+	if err := json.NewDecoder(r).Decode(&d); err != nil {
+		return nil, err
+	}
+	data, err := hex.DecodeString(d.Data)
+	if err != nil {
+		return nil, fmt.Errorf("data: %w", err)
+	}
+	return []chunk{{d.Start, data}}, nil
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDumpRoundTrip(t *testing.T) {
+	for _, f := range []Format{Binary, IntelHex, Xxd, JSON} {
+		mem := NewMemory()
+		mem.LoadROM([]byte("\x00\xE0HELLO, CHIP-8\x12\x00 and a partial line"))
+		var buf bytes.Buffer
+		if err := mem.Dump(&buf, f, Range{0x200, 0x22F}); err != nil {
+			t.Fatalf("%v: unexpected error: %v", f, err)
+		}
+
+		loaded := NewMemory()
+		if err := loaded.Load(&buf, f, 0x200); err != nil {
+			t.Fatalf("%v: unexpected error loading: %v", f, err)
+		}
+		want, got := mem.Snapshot(), loaded.Snapshot()
+		if !bytes.Equal(want, got) {
+			t.Errorf("%v: expected memory to round-trip, got %x", f, got[0x200:0x230])
+		}
+	}
+}
+
+func TestDumpXxd(t *testing.T) {
+	mem := NewMemory()
+	mem.LoadROM([]byte("\x00\xE0AB"))
+	var buf bytes.Buffer
+	mem.Dump(&buf, Xxd, Range{0x200, 0x204})
+	want := "00000200: 00e0 4142                                ..AB\n"
+	if buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestDumpIntelHex(t *testing.T) {
+	mem := NewMemory()
+	mem.LoadROM([]byte{0x00, 0xE0})
+	var buf bytes.Buffer
+	mem.Dump(&buf, IntelHex, Range{0x200, 0x202})
+	want := ":0202000000E01C\n:00000001FF\n"
+	if buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+
+	bad := strings.Replace(want, "1C", "1D", 1)
+	if err := mem.Load(strings.NewReader(bad), IntelHex, 0); err == nil {
+		t.Errorf("expected a checksum error")
+	}
+}
+
+func TestDumpJSONRegions(t *testing.T) {
+	mem := NewMemoryWithMap(VIPMap)
+	var buf bytes.Buffer
+	if err := mem.Dump(&buf, JSON, Range{0x1F0, 0x210}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var d jsonDump
+	if err := json.Unmarshal(buf.Bytes(), &d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, r := range d.Regions {
+		names = append(names, r.Name)
+	}
+	if got := strings.Join(names, ","); got != "interpreter,program" {
+		t.Errorf("expected the interpreter and program regions, got %s", got)
+	}
+	if d.Regions[0].Start != 0x1F0 || d.Regions[0].End != 0x200 {
+		t.Errorf("expected regions clipped to the range, got %+v", d.Regions[0])
+	}
+}
+
+func TestDumpOutOfRange(t *testing.T) {
+	mem := NewMemory()
+	if err := mem.Dump(&bytes.Buffer{}, Binary, Range{0xF00, 0x1001}); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %v", err)
+	}
+	if err := mem.Load(bytes.NewReader([]byte{1, 2}), Binary, 0xFFF); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %v", err)
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	r, err := ParseRange("200-3ff")
+	if err != nil || r != (Range{0x200, 0x400}) {
+		t.Errorf("expected 0x200 up to 0x400, got %+v, %v", r, err)
+	}
+	for _, s := range []string{"200", "3ff-200", "x-1"} {
+		if _, err := ParseRange(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range []Format{Binary, IntelHex, Xxd, JSON} {
+		if got, err := ParseFormat(f.String()); err != nil || got != f {
+			t.Errorf("expected %v to parse back, got %v, %v", f, got, err)
+		}
+	}
+	if _, err := ParseFormat("srec"); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,116 @@
+package memory
+
+// MaxSize is the most RAM any platform has: XO-CHIP's 64kb.
+const MaxSize = 0x10000
+
+// Region is a block of memory the interpreter keeps for itself. Programs
+// that write there may break the machine they run on.
+type Region struct {
+	Name  string
+	Start int
+	End   int // one past the last byte
+}
+
+// Contains reports whether address lies in the region.
+func (r Region) Contains(address int) bool {
+	return address >= r.Start && address < r.End
+}
+
+// Map describes how a platform lays out its memory.
+type Map struct {
+	Name      string
+	Load      uint16 // where ROMs are loaded
+	Entry     uint16 // where execution starts
+	Font      uint16 // the 4x5 hex digits FX29 points into
+	LargeFont uint16 // the SUPER-CHIP 8x10 digits for FX30, 0 if none
+	Size      int    // bytes of RAM, a power of two
+	Reserved  []Region
+}
+
+// MaxROM is the largest ROM that fits between Load and the end of RAM.
+func (m Map) MaxROM() int {
+	return m.Size - int(m.Load)
+}
+
+// ReservedAt returns the reserved region containing address, if any.
+func (m Map) ReservedAt(address int) (Region, bool) {
+	for _, r := range m.Reserved {
+		if r.Contains(address) {
+			return r, true
+		}
+	}
+	return Region{}, false
+}
+
+// Regions lists the reserved regions together with where the fonts and
+// programs go, for annotating dumps. Regions may overlap.
+func (m Map) Regions() []Region {
+	regions := append([]Region(nil), m.Reserved...)
+	regions = append(regions, Region{"font", int(m.Font), int(m.Font) + 16*5})
+	if m.LargeFont != 0 {
+		regions = append(regions, Region{"large font", int(m.LargeFont), int(m.LargeFont) + 16*10})
+	}
+	return append(regions, Region{"program", int(m.Load), m.Size})
+}
+
+var (
+	// DefaultMap is the usual layout: 4kb, with programs at 0x200 and the
+	// fonts in the space the interpreter once took.
+	DefaultMap = Map{
+		Name:      "CHIP-8",
+		Load:      0x200,
+		Entry:     0x200,
+		Font:      0x000,
+		LargeFont: 0x050,
+		Size:      MemorySize,
+		Reserved:  []Region{{"interpreter", 0x000, 0x200}},
+	}
+
+	// VIPMap is the 4kb COSMAC VIP. The interpreter sits below 0x200 and
+	// keeps its stack, variables and display buffer at the top of RAM.
+	VIPMap = Map{
+		Name:  "COSMAC VIP",
+		Load:  0x200,
+		Entry: 0x200,
+		Font:  0x000,
+		Size:  MemorySize,
+		Reserved: []Region{
+			{"interpreter", 0x000, 0x200},
+			{"stack and work area", 0xEA0, 0xF00},
+			{"display", 0xF00, 0x1000},
+		},
+	}
+
+	// ETI660Map is the ETI-660, whose larger interpreter pushes programs up
+	// to 0x600.
+	ETI660Map = Map{
+		Name:     "ETI-660",
+		Load:     0x600,
+		Entry:    0x600,
+		Font:     0x000,
+		Size:     MemorySize,
+		Reserved: []Region{{"interpreter", 0x000, 0x600}},
+	}
+
+	// SCHIPMap is SUPER-CHIP on the HP48, which adds the large font.
+	SCHIPMap = Map{
+		Name:      "HP48 SUPER-CHIP",
+		Load:      0x200,
+		Entry:     0x200,
+		Font:      0x000,
+		LargeFont: 0x050,
+		Size:      MemorySize,
+		Reserved:  []Region{{"interpreter", 0x000, 0x200}},
+	}
+
+	// XOCHIPMap is XO-CHIP, with 64kb of RAM.
+	XOCHIPMap = Map{
+		Name:      "XO-CHIP",
+		Load:      0x200,
+		Entry:     0x200,
+		Font:      0x000,
+		LargeFont: 0x050,
+		Size:      MaxSize,
+		Reserved:  []Region{{"interpreter", 0x000, 0x200}},
+	}
+)
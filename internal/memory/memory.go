@@ -1,104 +1,151 @@
 package memory
 
 import (
+	"errors"
 	"fmt"
 	"os"
 )
 
+// ErrROMTooLarge is returned by LoadROM when the program does not fit
+// between the load address and the end of memory.
+var ErrROMTooLarge = errors.New("ROM too large")
+
 // 4kb of RAM
 const MemorySize = 4096
 
 type Memory struct {
-	size  int
-	bytes [MemorySize]byte
+	size     int
+	layout   Map
+	bytes    []byte
+	watchers []func(address uint16)
+	frozen   map[uint16]byte // addresses held at a value by Freeze
+}
+
+// Map returns the layout the memory was created with.
+func (m *Memory) Map() Map {
+	return m.layout
 }
 
+// Size returns how many bytes of RAM there are.
+func (m *Memory) Size() int {
+	return m.size
+}
+
+// Mask wraps an address into the address space. Sizes are powers of two, so
+// this is the highest address.
+func (m *Memory) Mask() uint16 {
+	return uint16(m.size - 1)
+}
+
+// OnWrite registers fn to be called after any byte of memory changes,
+// whether through WriteByte, LoadROM or Restore.
+func (m *Memory) OnWrite(fn func(address uint16)) {
+	m.watchers = append(m.watchers, fn)
+}
+
+func (m *Memory) notify(address uint16) {
+	for _, fn := range m.watchers {
+		fn(address)
+	}
+}
+
+// PrintMemoryToFile writes all of memory to filename as an xxd dump.
 func (m *Memory) PrintMemoryToFile(filename string) error {
 	file, err := os.Create(filename) // os.Create will always overwrite the file
 	if err != nil {
 		return err
 	}
 	defer file.Close()
-
-	// Print memory contents to file in the specified format
-	for i := 0; i < MemorySize; i += 16 {
-		_, err := fmt.Fprintf(file, "%04x: ", i)
-		if err != nil {
-			return err
-		}
-		for j := 0; j < 16; j++ {
-			if i+j < MemorySize {
-				_, err := fmt.Fprintf(file, "%02x", m.bytes[i+j])
-				if err != nil {
-					return err
-				}
-				if j%2 == 1 {
-					_, err := fmt.Fprint(file, " ")
-					if err != nil {
-						return err
-					}
-				}
-			} else {
-				_, err := fmt.Fprint(file, "   ")
-				if err != nil {
-					return err
-				}
-			}
-		}
-		_, err = fmt.Fprint(file, " ")
-		if err != nil {
-			return err
-		}
-		for j := 0; j < 16; j++ {
-			if i+j < MemorySize {
-				b := m.bytes[i+j]
-				if b >= 32 && b <= 126 {
-					_, err := fmt.Fprintf(file, "%c", b)
-					if err != nil {
-						return err
-					}
-				} else {
-					_, err := fmt.Fprint(file, ".")
-					if err != nil {
-						return err
-					}
-				}
-			}
-		}
-		_, err = fmt.Fprintln(file)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return m.Dump(file, Xxd, Range{})
 }
 
 func (m *Memory) ReadByte(address uint16) (byte, error) {
-	if address >= MemorySize {
+	if int(address) >= m.size {
 		panic("address out of bounds")
 	}
 	return m.bytes[address], nil
 }
 
 func (m *Memory) WriteByte(address uint16, value byte) {
-	if address >= MemorySize {
+	if int(address) >= m.size {
 		panic("address out of bounds")
 	}
+	if v, ok := m.frozen[address]; ok {
+		value = v
+	}
 	m.bytes[address] = value
+	m.notify(address)
 }
-func (m *Memory) LoadROM(data []byte) {
+
+// LoadROM copies a program to the load address of the memory map.
+func (m *Memory) LoadROM(data []byte) error {
 	fmt.Printf("ROM size: %d\n", len(data))
-	if len(data) > MemorySize-0x200 {
-		panic("ROM too large")
+	if len(data) > m.layout.MaxROM() {
+		return fmt.Errorf("%w: %d bytes, %d fit at 0x%X", ErrROMTooLarge, len(data), m.layout.MaxROM(), m.layout.Load)
 	}
+	load := int(m.layout.Load)
 	for i, b := range data {
-		m.bytes[i+0x200] = b
+		m.bytes[load+i] = b
+		m.notify(uint16(load + i))
+	}
+	return nil
+}
+
+// Snapshot returns a copy of the whole address space.
+func (m *Memory) Snapshot() []byte {
+	return append([]byte(nil), m.bytes...)
+}
+
+// Restore overwrites the whole address space with a previous Snapshot. It
+// panics if the snapshot is of a different size.
+func (m *Memory) Restore(bytes []byte) {
+	if len(bytes) != m.size {
+		panic("snapshot size does not match memory")
+	}
+	for i, b := range bytes {
+		if m.bytes[i] != b {
+			m.bytes[i] = b
+			m.notify(uint16(i))
+		}
 	}
 }
 
+// Share makes m read and write bytes in place of its own storage, so that
+// memory belonging to something else, such as an emulated VIP, can be looked
+// at and changed through m. bytes must be the size of m. Writes made to
+// bytes directly are not seen by watchers.
+func (m *Memory) Share(bytes []byte) {
+	if len(bytes) != m.size {
+		panic("shared memory size does not match")
+	}
+	m.bytes = bytes
+}
+
+// NewMemory returns 4kb of RAM laid out like a usual CHIP-8 machine.
 func NewMemory() *Memory {
+	return NewMemoryWithMap(DefaultMap)
+}
+
+// NewMemoryWithMap returns RAM laid out as layout describes.
+func NewMemoryWithMap(layout Map) *Memory {
 	return &Memory{
-		size:  MemorySize,
-		bytes: [MemorySize]byte{},
+		size:   layout.Size,
+		layout: layout,
+		bytes:  make([]byte, layout.Size),
+	}
+}
+
+// SetMap switches to another layout, clearing memory if the size changes.
+// Watchers and frozen addresses that still fit are kept.
+func (m *Memory) SetMap(layout Map) {
+	if layout.Size != m.size {
+		m.size = layout.Size
+		m.bytes = make([]byte, layout.Size)
+		for address := range m.frozen {
+			if int(address) >= m.size {
+				delete(m.frozen, address)
+			}
+		}
 	}
+	m.layout = layout
 }
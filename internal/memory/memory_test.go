@@ -1,6 +1,7 @@
 package memory
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -54,7 +55,9 @@ func TestLoadROM(t *testing.T) {
 	mem := NewMemory()
 	rom := []byte{0x01, 0x02, 0x03, 0x04}
 
-	mem.LoadROM(rom)
+	if err := mem.LoadROM(rom); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 
 	for i, b := range rom {
 		address := uint16(i + 0x200)
@@ -69,10 +72,113 @@ func TestLoadROM(t *testing.T) {
 
 	// Test ROM too large
 	largeROM := make([]byte, MemorySize-0x200+1)
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("expected panic for ROM too large")
+	if err := mem.LoadROM(largeROM); !errors.Is(err, ErrROMTooLarge) {
+		t.Errorf("expected ErrROMTooLarge, got %v", err)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	mem := NewMemory()
+	mem.WriteByte(0x200, 0x12)
+	snap := mem.Snapshot()
+
+	mem.WriteByte(0x200, 0x34)
+	mem.Restore(snap)
+
+	if value, _ := mem.ReadByte(0x200); value != 0x12 {
+		t.Errorf("expected 0x12 after restore, got 0x%X", value)
+	}
+}
+
+func TestShare(t *testing.T) {
+	mem := NewMemory()
+	shared := make([]byte, MemorySize)
+	mem.Share(shared)
+	shared[0x200] = 0x12
+	if value, _ := mem.ReadByte(0x200); value != 0x12 {
+		t.Errorf("expected 0x12 from shared memory, got 0x%X", value)
+	}
+	mem.WriteByte(0x201, 0x34)
+	if shared[0x201] != 0x34 {
+		t.Errorf("expected writes to reach shared memory")
+	}
+}
+
+func TestOnWrite(t *testing.T) {
+	mem := NewMemory()
+	var written []uint16
+	mem.OnWrite(func(address uint16) { written = append(written, address) })
+
+	mem.WriteByte(0x300, 0x01)
+	mem.LoadROM([]byte{0xAA, 0xBB})
+	snap := mem.Snapshot()
+	snap[0x400] = 0xCC
+	mem.Restore(snap)
+
+	expected := []uint16{0x300, 0x200, 0x201, 0x400}
+	if len(written) != len(expected) {
+		t.Fatalf("expected writes %v, got %v", expected, written)
+	}
+	for i := range expected {
+		if written[i] != expected[i] {
+			t.Errorf("expected writes %v, got %v", expected, written)
+			break
 		}
-	}()
-	mem.LoadROM(largeROM)
+	}
+}
+
+func FuzzLoadROM(f *testing.F) {
+	f.Add([]byte{0x00, 0xE0, 0x12, 0x00})
+	f.Add(make([]byte, MemorySize-0x200))
+	f.Add(make([]byte, MemorySize-0x200+1))
+	f.Fuzz(func(t *testing.T, rom []byte) {
+		mem := NewMemory()
+		err := mem.LoadROM(rom)
+		if len(rom) > MemorySize-0x200 {
+			if err == nil {
+				t.Fatalf("expected error for %d byte ROM", len(rom))
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for i, b := range rom {
+			if value, _ := mem.ReadByte(uint16(i + 0x200)); value != b {
+				t.Fatalf("at address 0x%X, expected 0x%X, got 0x%X", i+0x200, b, value)
+			}
+		}
+	})
+}
+
+func TestLoadROMAtMapAddress(t *testing.T) {
+	mem := NewMemoryWithMap(ETI660Map)
+	if err := mem.LoadROM([]byte{0xAA}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value, _ := mem.ReadByte(0x600); value != 0xAA {
+		t.Errorf("expected ROM at 0x600, got 0x%X", value)
+	}
+	if err := mem.LoadROM(make([]byte, MemorySize-0x600+1)); !errors.Is(err, ErrROMTooLarge) {
+		t.Errorf("expected ErrROMTooLarge, got %v", err)
+	}
+	if r, ok := mem.Map().ReservedAt(0x5FF); !ok || r.Name != "interpreter" {
+		t.Errorf("expected 0x5FF to be reserved for the interpreter, got %v %v", r, ok)
+	}
+}
+
+func TestSetMap(t *testing.T) {
+	mem := NewMemory()
+	mem.WriteByte(0x200, 0x12)
+	mem.SetMap(XOCHIPMap)
+	if mem.Size() != MaxSize || mem.Mask() != 0xFFFF {
+		t.Fatalf("expected 64kb after switching to XO-CHIP, got %d bytes", mem.Size())
+	}
+	if value, _ := mem.ReadByte(0x200); value != 0 {
+		t.Errorf("expected memory to be cleared on resize, got 0x%X", value)
+	}
+	mem.WriteByte(0xFFFF, 0x34)
+	if len(mem.Snapshot()) != MaxSize {
+		t.Errorf("expected a 64kb snapshot")
+	}
 }
@@ -0,0 +1,110 @@
+package memory
+
+import "sort"
+
+// Condition compares the byte at an address in the previous snapshot of a
+// Search with its value now.
+type Condition func(old, now byte) bool
+
+var (
+	// Changed keeps addresses whose value is different.
+	Changed Condition = func(old, now byte) bool { return now != old }
+	// Unchanged keeps addresses whose value is the same.
+	Unchanged Condition = func(old, now byte) bool { return now == old }
+	// Increased keeps addresses whose value went up.
+	Increased Condition = func(old, now byte) bool { return now > old }
+	// Decreased keeps addresses whose value went down.
+	Decreased Condition = func(old, now byte) bool { return now < old }
+)
+
+// Equals keeps addresses that now hold value.
+func Equals(value byte) Condition {
+	return func(old, now byte) bool { return now == value }
+}
+
+// Candidate is an address a Search has not ruled out, with its value as of
+// the latest snapshot.
+type Candidate struct {
+	Address uint16
+	Value   byte
+}
+
+// Search finds where a program keeps a value, the way cheat finders do:
+// start with every address in a range, then after each change in the game
+// keep only the addresses that changed as the value did.
+type Search struct {
+	mem        *Memory
+	snapshot   []byte
+	candidates []uint16
+}
+
+// NewSearch starts a search over the addresses in r, taking a snapshot of
+// memory to compare against. The zero Range searches all of memory.
+func (m *Memory) NewSearch(r Range) (*Search, error) {
+	r, err := m.bounds(r)
+	if err != nil {
+		return nil, err
+	}
+	s := &Search{mem: m, snapshot: m.Snapshot()}
+	for address := r.Start; address < r.End; address++ {
+		s.candidates = append(s.candidates, uint16(address))
+	}
+	return s, nil
+}
+
+// Filter keeps the candidates whose value meets c compared with the previous
+// snapshot, takes a new snapshot and returns how many are left.
+func (s *Search) Filter(c Condition) int {
+	now := s.mem.Snapshot()
+	kept := s.candidates[:0]
+	for _, address := range s.candidates {
+		if int(address) < len(now) && int(address) < len(s.snapshot) && c(s.snapshot[address], now[address]) {
+			kept = append(kept, address)
+		}
+	}
+	s.candidates = kept
+	s.snapshot = now
+	return len(kept)
+}
+
+// Len returns how many candidates are left.
+func (s *Search) Len() int {
+	return len(s.candidates)
+}
+
+// Candidates lists the addresses left, in ascending order, with their values
+// as of the latest snapshot.
+func (s *Search) Candidates() []Candidate {
+	list := make([]Candidate, len(s.candidates))
+	for i, address := range s.candidates {
+		list[i] = Candidate{address, s.snapshot[address]}
+	}
+	return list
+}
+
+// Freeze makes address hold value: it is written now, and every later
+// WriteByte to it writes value instead. LoadROM and Restore are not held
+// back.
+func (m *Memory) Freeze(address uint16, value byte) {
+	if m.frozen == nil {
+		m.frozen = make(map[uint16]byte)
+	}
+	m.frozen[address] = value
+	m.WriteByte(address, value)
+}
+
+// Unfreeze lets programs write to address again.
+func (m *Memory) Unfreeze(address uint16) {
+	delete(m.frozen, address)
+}
+
+// Frozen lists the frozen addresses in ascending order with the values they
+// are held at.
+func (m *Memory) Frozen() []Candidate {
+	list := make([]Candidate, 0, len(m.frozen))
+	for address, value := range m.frozen {
+		list = append(list, Candidate{address, value})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Address < list[j].Address })
+	return list
+}
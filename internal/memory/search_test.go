@@ -0,0 +1,58 @@
+package memory
+
+import "testing"
+
+func TestSearch(t *testing.T) {
+	mem := NewMemory()
+	mem.WriteByte(0x300, 3) // lives
+	mem.WriteByte(0x301, 3) // something else that starts at 3
+	s, err := mem.NewSearch(Range{0x300, 0x310})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := s.Filter(Equals(3)); n != 2 {
+		t.Fatalf("expected 2 addresses holding 3, got %d", n)
+	}
+
+	mem.WriteByte(0x300, 2) // lose a life
+	mem.WriteByte(0x301, 4)
+	if n := s.Filter(Changed); n != 2 {
+		t.Fatalf("expected both to have changed, got %d", n)
+	}
+	mem.WriteByte(0x300, 1)
+	mem.WriteByte(0x301, 5)
+	if n := s.Filter(Decreased); n != 1 {
+		t.Fatalf("expected one to have gone down, got %d", n)
+	}
+	if c := s.Candidates(); c[0] != (Candidate{0x300, 1}) {
+		t.Errorf("expected 0x300 holding 1, got %+v", c[0])
+	}
+	if n := s.Filter(Unchanged); n != 1 {
+		t.Errorf("expected 0x300 to be unchanged since, got %d", n)
+	}
+	if n := s.Filter(Increased); n != 0 {
+		t.Errorf("expected nothing to have gone up, got %d", n)
+	}
+
+	if _, err := mem.NewSearch(Range{0, MemorySize + 1}); err == nil {
+		t.Errorf("expected an error for a range past the end of memory")
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	mem := NewMemory()
+	mem.Freeze(0x300, 9)
+	mem.WriteByte(0x300, 1)
+	if v, _ := mem.ReadByte(0x300); v != 9 {
+		t.Errorf("expected a frozen address to keep 9, got %d", v)
+	}
+	if f := mem.Frozen(); len(f) != 1 || f[0] != (Candidate{0x300, 9}) {
+		t.Errorf("expected 0x300 frozen at 9, got %v", f)
+	}
+
+	mem.Unfreeze(0x300)
+	mem.WriteByte(0x300, 1)
+	if v, _ := mem.ReadByte(0x300); v != 1 {
+		t.Errorf("expected writes to go through after unfreezing, got %d", v)
+	}
+}
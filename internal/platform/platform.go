@@ -0,0 +1,142 @@
+package platform
+
+import (
+	"sort"
+
+	"github.com/jsutcodes/chip8-goemu/internal/cpu"
+	"github.com/jsutcodes/chip8-goemu/internal/display"
+	"github.com/jsutcodes/chip8-goemu/internal/font"
+	"github.com/jsutcodes/chip8-goemu/internal/memory"
+)
+
+// Profile bundles the settings that make the emulator behave like one
+// particular CHIP-8 implementation.
+type Profile struct {
+	ID                   string // short name used on the command line
+	Name                 string
+	Quirks               cpu.Quirks
+	Edge                 display.EdgeMode
+	InstructionsPerFrame int
+	// Memory is where programs load and start, where the fonts live and how
+	// much RAM there is.
+	Memory memory.Map
+	// Font is the set of digits the interpreter keeps in memory.
+	Font font.Font
+	// MachineCode runs 0NNN as a call to native machine code.
+	MachineCode bool
+
+	// Timing, when set, costs each instruction in machine cycles of the
+	// original hardware, and CyclesPerFrame is how many of those the
+	// interpreter gets per 60 Hz frame. Used in cycle-accurate mode.
+	Timing         cpu.TimingModel
+	CyclesPerFrame int
+}
+
+var (
+	// Modern is what most CHIP-8 emulators and recent ROMs assume.
+	Modern = Profile{
+		ID:                   "chip8",
+		Name:                 "Modern CHIP-8",
+		Edge:                 display.Clip,
+		InstructionsPerFrame: 11,
+		Memory:               memory.DefaultMap,
+		Font:                 font.Octo,
+	}
+
+	// VIP is the original interpreter on the RCA COSMAC VIP.
+	VIP = Profile{
+		ID:                   "vip",
+		Name:                 "COSMAC VIP",
+		Quirks:               cpu.Quirks{DisplayWait: true, ShiftVY: true, MemoryIncrement: true, Logic: true},
+		Edge:                 display.Clip,
+		InstructionsPerFrame: 15,
+		Memory:               memory.VIPMap,
+		Font:                 font.VIP,
+		MachineCode:          true,
+		Timing:               cpu.VIPTiming,
+		CyclesPerFrame:       cpu.VIPCyclesForInterpreter,
+	}
+
+	// ETI660 is the interpreter of the ETI-660 kit computer, which loads
+	// programs at 0x600.
+	ETI660 = Profile{
+		ID:                   "eti660",
+		Name:                 "ETI-660",
+		Quirks:               cpu.Quirks{ShiftVY: true, MemoryIncrement: true, Logic: true},
+		Edge:                 display.Clip,
+		InstructionsPerFrame: 15,
+		Memory:               memory.ETI660Map,
+		Font:                 font.ETI660,
+	}
+
+	// SCHIP is SUPER-CHIP 1.1 on the HP48 calculators.
+	SCHIP = Profile{
+		ID:   "schip",
+		Name: "SUPER-CHIP 1.1",
+		Quirks: cpu.Quirks{
+			UserFlags:             8,
+			MemoryLeaveIUnchanged: true,
+			Jump:                  true,
+		},
+		Edge:                 display.Clip,
+		InstructionsPerFrame: 30,
+		Memory:               memory.SCHIPMap,
+		Font:                 font.SCHIP,
+	}
+
+	// XOCHIP is Octo's XO-CHIP extension.
+	XOCHIP = Profile{
+		ID:                   "xochip",
+		Name:                 "XO-CHIP",
+		Quirks:               cpu.Quirks{UserFlags: 16, ShiftVY: true, MemoryIncrement: true},
+		Edge:                 display.Wrap,
+		InstructionsPerFrame: 1000,
+		Memory:               memory.XOCHIPMap,
+		Font:                 font.Octo,
+	}
+)
+
+var profiles = map[string]Profile{
+	Modern.ID: Modern,
+	VIP.ID:    VIP,
+	ETI660.ID: ETI660,
+	SCHIP.ID:  SCHIP,
+	XOCHIP.ID: XOCHIP,
+}
+
+// Lookup finds a profile by its ID.
+func Lookup(id string) (Profile, bool) {
+	p, ok := profiles[id]
+	return p, ok
+}
+
+// IDs lists the IDs of all known profiles in alphabetical order.
+func IDs() []string {
+	ids := make([]string, 0, len(profiles))
+	for id := range profiles {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// QuirkNames lists the quirks the profile turns on, using the names from
+// the CHIP-8 community ROM database. Shifting VX in place is its shift
+// quirk. FX55 leaving I alone while FX65 moves it has no name there, so it
+// is not listed.
+func (p Profile) QuirkNames() []string {
+	var names []string
+	add := func(on bool, name string) {
+		if on {
+			names = append(names, name)
+		}
+	}
+	add(!p.Quirks.ShiftVY, "shift")
+	add(p.Quirks.MemoryIncrementByX, "memoryIncrementByX")
+	add(p.Quirks.MemoryLeaveIUnchanged, "memoryLeaveIUnchanged")
+	add(p.Edge == display.Wrap, "wrap")
+	add(p.Quirks.Jump, "jump")
+	add(p.Quirks.DisplayWait, "vblank")
+	add(p.Quirks.Logic, "logic")
+	return names
+}
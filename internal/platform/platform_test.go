@@ -0,0 +1,60 @@
+package platform
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	p, ok := Lookup("vip")
+	if !ok {
+		t.Fatalf("Expected to find the vip profile")
+	}
+	if !p.Quirks.DisplayWait {
+		t.Errorf("Expected the VIP profile to wait for vertical blank")
+	}
+
+	if _, ok := Lookup("nope"); ok {
+		t.Errorf("Expected no profile called nope")
+	}
+}
+
+func TestIDs(t *testing.T) {
+	ids := IDs()
+	if len(ids) != 5 || ids[0] != "chip8" || ids[4] != "xochip" {
+		t.Errorf("Unexpected profile IDs %v", ids)
+	}
+	for _, id := range ids {
+		p, _ := Lookup(id)
+		if p.InstructionsPerFrame < 1 {
+			t.Errorf("Expected profile %s to set instructions per frame", id)
+		}
+		if p.Memory.Size == 0 || p.Memory.MaxROM() <= 0 {
+			t.Errorf("Expected profile %s to have a memory map, got %+v", id, p.Memory)
+		}
+	}
+}
+
+func TestUserFlags(t *testing.T) {
+	for _, tt := range []struct {
+		p    Profile
+		want int
+	}{{Modern, 0}, {VIP, 0}, {SCHIP, 8}, {XOCHIP, 16}} {
+		if tt.p.Quirks.UserFlags != tt.want {
+			t.Errorf("Expected %s to have %d user flags, got %d", tt.p.ID, tt.want, tt.p.Quirks.UserFlags)
+		}
+	}
+}
+
+func TestQuirkNames(t *testing.T) {
+	names := strings.Join(SCHIP.QuirkNames(), ",")
+	if names != "shift,memoryLeaveIUnchanged,jump" {
+		t.Errorf("Unexpected SCHIP quirks %s", names)
+	}
+	if names := XOCHIP.QuirkNames(); len(names) != 1 || names[0] != "wrap" {
+		t.Errorf("Unexpected XO-CHIP quirks %v", names)
+	}
+	if names := Modern.QuirkNames(); len(names) != 1 || names[0] != "shift" {
+		t.Errorf("Expected only the shift quirk for modern CHIP-8, got %v", names)
+	}
+}
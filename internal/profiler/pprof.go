@@ -0,0 +1,151 @@
+package profiler
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// WritePprof writes the profile in the gzipped protocol buffer format of
+// github.com/google/pprof, for "go tool pprof". Each subroutine becomes a
+// function, named as in the text report, with the CHIP-8 addresses in it as
+// line numbers of the file name, which is usually the ROM's.
+func (p *Profiler) WritePprof(w io.Writer, name string) error {
+	b := &pprofBuilder{strings: map[string]int64{"": 0}, table: []string{""}, functions: map[uint16]uint64{}, locations: map[[2]uint16]uint64{}, file: name}
+
+	var profile message
+	for _, t := range [][2]string{{"instructions", "count"}, {"cycles", "count"}} {
+		var vt message
+		vt.int(1, b.str(t[0]))
+		vt.int(2, b.str(t[1]))
+		profile.bytes(1, vt)
+	}
+	p.walk(p.root, func(n *Node) {
+		// The stack from the subroutine out to the program: each call
+		// site lies in the caller's function.
+		var stack []uint64
+		for a := n; a.Parent != nil; a = a.Parent {
+			stack = append(stack, b.location(a.CallSite, a.Parent.Address, p))
+		}
+		for _, l := range sorted(n.Self) {
+			var sample message
+			sample.packed(1, append([]uint64{b.location(l.Address, n.Address, p)}, stack...))
+			sample.packed(2, []uint64{l.Instructions, l.Cycles})
+			profile.bytes(2, sample)
+		}
+	})
+	var mapping message
+	mapping.int(1, 1)
+	mapping.int(3, int64(p.cpu.RAM.Size()))
+	mapping.int(5, b.str(name))
+	mapping.int(7, 1) // has_functions
+	mapping.int(8, 1) // has_filenames
+	mapping.int(9, 1) // has_line_numbers
+	profile.bytes(3, mapping)
+	var period message
+	period.int(1, b.str("cycles"))
+	period.int(2, b.str("count"))
+	profile.bytes(11, period)
+	profile.int(12, 1)
+	profile = append(profile, b.locs...)
+	profile = append(profile, b.funcs...)
+	for _, s := range b.table {
+		profile.bytes(6, message(s))
+	}
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(profile); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// pprofBuilder numbers the strings, functions and locations of a profile.
+type pprofBuilder struct {
+	strings   map[string]int64
+	table     []string
+	functions map[uint16]uint64
+	locations map[[2]uint16]uint64
+	funcs     message // encoded Function fields of the profile
+	locs      message // encoded Location fields of the profile
+	file      string
+}
+
+func (b *pprofBuilder) str(s string) int64 {
+	if i, ok := b.strings[s]; ok {
+		return i
+	}
+	b.strings[s] = int64(len(b.table))
+	b.table = append(b.table, s)
+	return b.strings[s]
+}
+
+func (b *pprofBuilder) function(address uint16, p *Profiler) uint64 {
+	if id, ok := b.functions[address]; ok {
+		return id
+	}
+	id := uint64(len(b.functions) + 1)
+	b.functions[address] = id
+	var f message
+	f.int(1, int64(id))
+	f.int(2, b.str(p.name(address)))
+	f.int(3, b.str(fmt.Sprintf("0x%03X", address)))
+	f.int(4, b.str(b.file))
+	f.int(5, int64(address))
+	b.funcs.bytes(5, f)
+	return id
+}
+
+func (b *pprofBuilder) location(address, function uint16, p *Profiler) uint64 {
+	key := [2]uint16{address, function}
+	if id, ok := b.locations[key]; ok {
+		return id
+	}
+	id := uint64(len(b.locations) + 1)
+	b.locations[key] = id
+	var line, l message
+	line.int(1, int64(b.function(function, p)))
+	line.int(2, int64(address))
+	l.int(1, int64(id))
+	l.int(2, 1)
+	l.int(3, int64(address))
+	l.bytes(4, line)
+	b.locs.bytes(4, l)
+	return id
+}
+
+// message is an encoded protocol buffer message, built field by field.
+type message []byte
+
+func (m *message) varint(v uint64) {
+	for v >= 0x80 {
+		*m = append(*m, byte(v)|0x80)
+		v >>= 7
+	}
+	*m = append(*m, byte(v))
+}
+
+// int appends a varint field, leaving out zeros as proto3 does.
+func (m *message) int(field int, v int64) {
+	if v == 0 {
+		return
+	}
+	m.varint(uint64(field) << 3)
+	m.varint(uint64(v))
+}
+
+// bytes appends a length-delimited field: a string or nested message.
+func (m *message) bytes(field int, v message) {
+	m.varint(uint64(field)<<3 | 2)
+	m.varint(uint64(len(v)))
+	*m = append(*m, v...)
+}
+
+// packed appends a packed repeated varint field.
+func (m *message) packed(field int, values []uint64) {
+	var v message
+	for _, x := range values {
+		v.varint(x)
+	}
+	m.bytes(field, v)
+}
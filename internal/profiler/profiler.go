@@ -0,0 +1,274 @@
+// Package profiler measures where a CHIP-8 program spends its time. It
+// counts instructions and machine cycles per address, follows 2NNN calls and
+// 00EE returns through the CPU's stack to build a call tree, and reports the
+// result as text or as a pprof profile for "go tool pprof".
+package profiler
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/jsutcodes/chip8-goemu/internal/cpu"
+	"github.com/jsutcodes/chip8-goemu/internal/disasm"
+	"github.com/jsutcodes/chip8-goemu/internal/symbols"
+)
+
+// Count is what was spent at an address or in a subroutine.
+type Count struct {
+	Instructions uint64
+	Cycles       uint64
+}
+
+func (c *Count) add(o Count) {
+	c.Instructions += o.Instructions
+	c.Cycles += o.Cycles
+}
+
+// Node is a subroutine as called along one path of the call tree. The root
+// stands for the program itself, from its entry point.
+type Node struct {
+	// Address is where the subroutine starts.
+	Address uint16
+	// CallSite is the address of the 2NNN that called it.
+	CallSite uint16
+	// Calls is how many times it was called from the call site.
+	Calls    uint64
+	Parent   *Node
+	Children []*Node
+	// Self is what was spent in the subroutine itself, by address.
+	Self map[uint16]Count
+}
+
+// Exclusive returns what was spent in the subroutine itself.
+func (n *Node) Exclusive() Count {
+	var total Count
+	for _, c := range n.Self {
+		total.add(c)
+	}
+	return total
+}
+
+// Inclusive returns what was spent in the subroutine and everything it
+// called.
+func (n *Node) Inclusive() Count {
+	total := n.Exclusive()
+	for _, child := range n.Children {
+		total.add(child.Inclusive())
+	}
+	return total
+}
+
+func (n *Node) child(address, site uint16) *Node {
+	for _, child := range n.Children {
+		if child.Address == address && child.CallSite == site {
+			return child
+		}
+	}
+	child := &Node{Address: address, CallSite: site, Parent: n, Self: map[uint16]Count{}}
+	n.Children = append(n.Children, child)
+	return child
+}
+
+// Profiler records the instructions a CPU runs.
+type Profiler struct {
+	// Symbols, if set, names subroutines in reports.
+	Symbols *symbols.Table
+
+	cpu     *cpu.CPU
+	timing  cpu.TimingModel
+	root    *Node
+	current *Node
+	depth   byte
+}
+
+// Attach starts profiling c. Each instruction is costed by timing, or as one
+// cycle if timing is nil. Costs that depend on the machine's state are taken
+// after the instruction has run, which only matters for a sprite drawn with
+// VF as a coordinate. Profiling makes Run interpret.
+func Attach(c *cpu.CPU, timing cpu.TimingModel) *Profiler {
+	p := &Profiler{cpu: c, timing: timing}
+	p.Reset()
+	c.OnExecute(p.executed)
+	return p
+}
+
+// Reset throws away what has been recorded, starting a new call tree from
+// the current PC and stack depth.
+func (p *Profiler) Reset() {
+	p.root = &Node{Address: p.cpu.PC, Self: map[uint16]Count{}}
+	p.current = p.root
+	p.depth = p.cpu.SP
+}
+
+// Root returns the top of the call tree.
+func (p *Profiler) Root() *Node {
+	return p.root
+}
+
+func (p *Profiler) executed(c *cpu.CPU, pc uint16) {
+	cost := 1
+	if p.timing != nil {
+		hi, _ := c.RAM.ReadByte(pc)
+		lo, _ := c.RAM.ReadByte((pc + 1) & c.RAM.Mask())
+		cost = p.timing(c, uint16(hi)<<8|uint16(lo))
+	}
+	count := p.current.Self[pc]
+	count.add(Count{1, uint64(cost)})
+	p.current.Self[pc] = count
+
+	// Follow the stack rather than decoding calls, so returns that never
+	// come back or stacks restored from a save state are tracked too.
+	for p.depth < c.SP {
+		p.current = p.current.child(c.PC, pc)
+		p.current.Calls++
+		p.depth++
+	}
+	for p.depth > c.SP {
+		if p.current.Parent != nil {
+			p.current = p.current.Parent
+		}
+		p.depth--
+	}
+}
+
+// Line is one row of a flat profile.
+type Line struct {
+	Address uint16
+	Count
+}
+
+// Flat returns what was spent at each address, most cycles first.
+func (p *Profiler) Flat() []Line {
+	totals := map[uint16]Count{}
+	p.walk(p.root, func(n *Node) {
+		for address, c := range n.Self {
+			total := totals[address]
+			total.add(c)
+			totals[address] = total
+		}
+	})
+	return sorted(totals)
+}
+
+// Function is a subroutine's totals over all the places it was called from.
+type Function struct {
+	Address   uint16
+	Calls     uint64
+	Exclusive Count
+	// Inclusive counts recursive calls once.
+	Inclusive Count
+}
+
+// Functions returns the totals of each subroutine, most inclusive cycles
+// first. The program itself is listed at its entry point.
+func (p *Profiler) Functions() []Function {
+	byAddress := map[uint16]*Function{}
+	p.walk(p.root, func(n *Node) {
+		f := byAddress[n.Address]
+		if f == nil {
+			f = &Function{Address: n.Address}
+			byAddress[n.Address] = f
+		}
+		f.Calls += n.Calls
+		f.Exclusive.add(n.Exclusive())
+		recursive := false
+		for a := n.Parent; a != nil; a = a.Parent {
+			recursive = recursive || a.Address == n.Address
+		}
+		if !recursive {
+			f.Inclusive.add(n.Inclusive())
+		}
+	})
+	list := make([]Function, 0, len(byAddress))
+	for _, f := range byAddress {
+		list = append(list, *f)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Inclusive.Cycles != list[j].Inclusive.Cycles {
+			return list[i].Inclusive.Cycles > list[j].Inclusive.Cycles
+		}
+		return list[i].Address < list[j].Address
+	})
+	return list
+}
+
+func (p *Profiler) walk(n *Node, fn func(n *Node)) {
+	fn(n)
+	for _, child := range n.Children {
+		p.walk(child, fn)
+	}
+}
+
+func sorted(counts map[uint16]Count) []Line {
+	lines := make([]Line, 0, len(counts))
+	for address, c := range counts {
+		lines = append(lines, Line{address, c})
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Cycles != lines[j].Cycles {
+			return lines[i].Cycles > lines[j].Cycles
+		}
+		return lines[i].Address < lines[j].Address
+	})
+	return lines
+}
+
+// WriteReport writes a text report: the hottest top addresses (all of them
+// if top is 0), every subroutine, and the call tree.
+func (p *Profiler) WriteReport(w io.Writer, top int) {
+	total := p.root.Inclusive()
+	percent := func(c Count) float64 {
+		if total.Cycles == 0 {
+			return 0
+		}
+		return 100 * float64(c.Cycles) / float64(total.Cycles)
+	}
+	memory := p.cpu.RAM.Snapshot()
+
+	fmt.Fprintf(w, "%d instructions, %d cycles\n\n", total.Instructions, total.Cycles)
+	fmt.Fprintln(w, "Flat profile:")
+	fmt.Fprintf(w, "%12s %6s %12s  address\n", "cycles", "%", "instructions")
+	for i, l := range p.Flat() {
+		if top > 0 && i == top {
+			break
+		}
+		text := ""
+		if d := disasm.Disassemble(memory, 0, l.Address, 1); len(d) == 1 {
+			text = d[0].Text
+		}
+		fmt.Fprintf(w, "%12d %5.1f%% %12d  0x%03X  %s\n", l.Cycles, percent(l.Count), l.Instructions, l.Address, text)
+	}
+
+	fmt.Fprintln(w, "\nSubroutines:")
+	fmt.Fprintf(w, "%12s %6s %12s %6s %8s  address\n", "inclusive", "%", "exclusive", "%", "calls")
+	for _, f := range p.Functions() {
+		fmt.Fprintf(w, "%12d %5.1f%% %12d %5.1f%% %8d  %s\n", f.Inclusive.Cycles, percent(f.Inclusive), f.Exclusive.Cycles, percent(f.Exclusive), f.Calls, p.name(f.Address))
+	}
+
+	fmt.Fprintln(w, "\nCall tree (inclusive / exclusive cycles):")
+	var tree func(n *Node, indent string)
+	tree = func(n *Node, indent string) {
+		in, ex := n.Inclusive(), n.Exclusive()
+		fmt.Fprintf(w, "%s%s  %d / %d  (%.1f%%)", indent, p.name(n.Address), in.Cycles, ex.Cycles, percent(in))
+		if n.Parent != nil {
+			fmt.Fprintf(w, "  %d calls from 0x%03X", n.Calls, n.CallSite)
+		}
+		fmt.Fprintln(w)
+		for _, child := range n.Children {
+			tree(child, indent+"  ")
+		}
+	}
+	tree(p.root, "")
+}
+
+// name is how a subroutine is shown in reports.
+func (p *Profiler) name(address uint16) string {
+	if name, ok := p.Symbols.Name(address); ok {
+		return name
+	}
+	if address == p.root.Address {
+		return fmt.Sprintf("main (0x%03X)", address)
+	}
+	return fmt.Sprintf("sub_%03X", address)
+}
@@ -0,0 +1,122 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jsutcodes/chip8-goemu/internal/cpu"
+	"github.com/jsutcodes/chip8-goemu/internal/display"
+	"github.com/jsutcodes/chip8-goemu/internal/input"
+	"github.com/jsutcodes/chip8-goemu/internal/memory"
+)
+
+var rom = []byte{
+	0x22, 0x06, // 200: call 0x206
+	0x22, 0x0C, // 202: call 0x20C
+	0x12, 0x00, // 204: jump 0x200
+	0x60, 0x01, // 206: V0 = 1
+	0x22, 0x0C, // 208: call 0x20C
+	0x00, 0xEE, // 20A: return
+	0x70, 0x01, // 20C: V0 += 1
+	0x00, 0xEE, // 20E: return
+}
+
+// run profiles n instructions of rom, which runs 10 instructions a loop.
+func run(t *testing.T, n int, timing cpu.TimingModel) *Profiler {
+	t.Helper()
+	c := cpu.NewCPU(memory.NewMemory(), display.NewDisplay(), input.NewKeypad())
+	c.RAM.LoadROM(rom)
+	p := Attach(c, timing)
+	c.Run(n)
+	return p
+}
+
+func TestCallTree(t *testing.T) {
+	p := run(t, 20, nil)
+	root := p.Root()
+	if root.Address != 0x200 || len(root.Children) != 2 {
+		t.Fatalf("Expected main to call two subroutines, got %+v", root)
+	}
+	a, b := root.Children[0], root.Children[1]
+	if a.Address != 0x206 || a.CallSite != 0x200 || a.Calls != 2 || b.Address != 0x20C || b.CallSite != 0x202 || b.Calls != 2 {
+		t.Errorf("Unexpected children %+v and %+v", a, b)
+	}
+	if len(a.Children) != 1 || a.Children[0].Address != 0x20C || a.Children[0].CallSite != 0x208 {
+		t.Errorf("Expected 0x206 to call 0x20C from 0x208")
+	}
+	if got := root.Exclusive(); got.Instructions != 6 {
+		t.Errorf("Expected main to run 6 instructions itself, got %d", got.Instructions)
+	}
+	if got := a.Inclusive(); got.Instructions != 10 {
+		t.Errorf("Expected 0x206 and its callee to run 10 instructions, got %d", got.Instructions)
+	}
+	if got := root.Inclusive(); got.Instructions != 20 {
+		t.Errorf("Expected 20 instructions in all, got %d", got.Instructions)
+	}
+}
+
+func TestFlatAndFunctions(t *testing.T) {
+	p := run(t, 20, cpu.VIPTiming)
+	flat := p.Flat()
+	if flat[0].Address != 0x20C || flat[0].Instructions != 4 {
+		t.Errorf("Expected the shared subroutine to be hottest, got %+v", flat[0])
+	}
+	// 2NNN costs 66 cycles on the VIP.
+	for _, l := range flat {
+		if l.Address == 0x200 && l.Cycles != 2*66 {
+			t.Errorf("Expected two calls at 0x200 to cost 132 cycles, got %d", l.Cycles)
+		}
+	}
+
+	functions := p.Functions()
+	if functions[0].Address != 0x200 {
+		t.Errorf("Expected main first by inclusive cycles, got 0x%X", functions[0].Address)
+	}
+	for _, f := range functions {
+		if f.Address == 0x20C && (f.Calls != 4 || f.Exclusive.Instructions != 8 || f.Inclusive != f.Exclusive) {
+			t.Errorf("Unexpected totals for 0x20C: %+v", f)
+		}
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	var out bytes.Buffer
+	run(t, 20, nil).WriteReport(&out, 3)
+	report := out.String()
+	for _, want := range []string{
+		"20 instructions, 20 cycles",
+		"           4  20.0%            4  0x20C  ADD V0, 0x01",
+		"main (0x200)  20 / 6  (100.0%)",
+		"    sub_20C  4 / 4  (20.0%)  2 calls from 0x208",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected %q in the report:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "0x202  CALL") {
+		t.Errorf("Expected only the top 3 addresses in the flat profile")
+	}
+}
+
+func TestWritePprof(t *testing.T) {
+	var out bytes.Buffer
+	if err := run(t, 20, nil).WritePprof(&out, "test.ch8"); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"instructions", "cycles", "main (0x200)", "sub_206", "sub_20C", "test.ch8"} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("Expected %q in the string table", want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+//go:build ignore
+
+// Fetch copies the database files and licence of the CHIP-8 community ROM
+// database at one revision over the embedded ones, and records where they
+// came from in UPSTREAM. Run it from this directory with the commit to copy:
+//
+//	go run fetch.go -rev 0123abc
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+const repo = "chip-8/chip-8-database"
+
+// files maps paths in the upstream repository to the files they replace.
+var files = [][2]string{
+	{"database/programs.json", "programs.json"},
+	{"database/sha1-hashes.json", "sha1-hashes.json"},
+	{"database/platforms.json", "platforms.json"},
+	{"LICENSE", "LICENSE"},
+}
+
+func main() {
+	rev := flag.String("rev", "", "the upstream commit to copy")
+	flag.Parse()
+	if *rev == "" {
+		fmt.Fprintln(os.Stderr, "fetch: -rev is required so the copy can be traced back")
+		os.Exit(2)
+	}
+	for _, f := range files {
+		if err := fetch(*rev, f[0], f[1]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	source := fmt.Sprintf("https://github.com/%s at %s\n", repo, *rev)
+	if err := os.WriteFile("UPSTREAM", []byte(source), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// fetch downloads path at rev into dst.
+func fetch(rev, path, dst string) error {
+	url := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", repo, rev, path)
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("fetch %s: %w", url, err)
+	}
+	return os.WriteFile(dst, data, 0o644)
+}
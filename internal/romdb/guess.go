@@ -0,0 +1,163 @@
+package romdb
+
+import (
+	"fmt"
+
+	"github.com/jsutcodes/chip8-goemu/internal/disasm"
+	"github.com/jsutcodes/chip8-goemu/internal/platform"
+)
+
+// maxCHIP8ROM is the most program a 4 KB machine has room for above 0x200.
+const maxCHIP8ROM = 4096 - 0x200
+
+// Guess is a best guess at how to run a ROM that is not in the database.
+type Guess struct {
+	Profile    platform.Profile
+	Confidence float64  // 0 to 1
+	Reasons    []string // the evidence, most telling first
+}
+
+// extension is a family of instructions only one platform has.
+type extension struct {
+	profile platform.Profile
+	matches func(opcode uint16) bool
+}
+
+var extensions = []extension{
+	{platform.XOCHIP, func(op uint16) bool {
+		return op == 0xF000 || op == 0xF002 || op&0xF00E == 0x5002 ||
+			op&0xF0FF == 0xF001 || op&0xF0FF == 0xF03A || op&0xFFF0 == 0x00D0
+	}},
+	{platform.SCHIP, func(op uint16) bool {
+		return op == 0x00FF || op == 0x00FE || op == 0x00FD || op == 0x00FB ||
+			op == 0x00FC || op&0xFFF0 == 0x00C0 || op&0xF00F == 0xD000 ||
+			op&0xF0FF == 0xF075 || op&0xF0FF == 0xF085 || op&0xF0FF == 0xF030
+	}},
+}
+
+// GuessPlatform scans a ROM that loads at 0x200 for instructions only some
+// platforms have. Only code reachable from the entry point counts, so that
+// sprites and other data are not mistaken for instructions.
+func GuessPlatform(rom []byte) Guess {
+	if len(rom) > maxCHIP8ROM {
+		return Guess{
+			Profile:    platform.XOCHIP,
+			Confidence: 0.95,
+			Reasons:    []string{fmt.Sprintf("%d bytes is too big for 4 KB of memory", len(rom))},
+		}
+	}
+
+	code := disasm.Trace(rom, 0x200)
+	opcodeAt := func(address uint16) uint16 {
+		i := address - 0x200
+		return uint16(rom[i])<<8 | uint16(rom[i+1])
+	}
+
+	for _, ext := range extensions {
+		var reasons []string
+		kinds := map[string]bool{}
+		for _, address := range code {
+			op := opcodeAt(address)
+			if !ext.matches(op) {
+				continue
+			}
+			text := disasm.Mnemonic(op)
+			reasons = append(reasons, fmt.Sprintf("%s at 0x%03X", text, address))
+			kinds[text] = true
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+		confidence := 0.5 + 0.1*float64(len(kinds))
+		if confidence > 0.9 {
+			confidence = 0.9
+		}
+		g := Guess{Profile: ext.profile, Confidence: confidence, Reasons: reasons}
+		g.shiftHint(code, opcodeAt)
+		return g
+	}
+
+	g := Guess{
+		Profile:    platform.Modern,
+		Confidence: 0.5,
+		Reasons:    []string{"no SUPER-CHIP or XO-CHIP instructions"},
+	}
+	for _, address := range code {
+		op := opcodeAt(address)
+		if op>>12 == 0 && op != 0 && op != 0x00E0 && op != 0x00EE {
+			g.Profile = platform.VIP
+			g.Confidence = 0.7
+			g.Reasons = append([]string{fmt.Sprintf("machine code call %s at 0x%03X", disasm.Mnemonic(op), address)}, g.Reasons...)
+			break
+		}
+	}
+	g.shiftHint(code, opcodeAt)
+	return g
+}
+
+// shiftHint looks at how the ROM shifts. 8XY6 and 8XYE with X and Y
+// different shift VX in place with the shift quirk, and VY into VX on
+// platforms without it. If VY is never set the ROM cannot mean to shift it,
+// so it wants the quirk; this was a common habit in CHIP-48 and SUPER-CHIP
+// programs. If VY is set the ROM may mean either, and the platform's own
+// behaviour is kept.
+func (g *Guess) shiftHint(code []uint16, opcodeAt func(uint16) uint16) {
+	var shiftsVY string
+	for _, address := range code {
+		op := opcodeAt(address)
+		x, y := op>>8&0xF, op>>4&0xF
+		if op&0xF00F != 0x8006 && op&0xF00F != 0x800E || x == y {
+			continue
+		}
+		if !setsRegister(code, opcodeAt, y) {
+			g.Profile.Quirks.ShiftVY = false
+			g.Confidence = min(g.Confidence+0.1, 0.95)
+			g.Reasons = append(g.Reasons, fmt.Sprintf("%s at 0x%03X only makes sense shifting V%X in place, since V%X is never set, so the shift quirk", disasm.Mnemonic(op), address, x, y))
+			return
+		}
+		if shiftsVY == "" {
+			shiftsVY = fmt.Sprintf("%s at 0x%03X", disasm.Mnemonic(op), address)
+		}
+	}
+	switch {
+	case shiftsVY == "":
+	case g.Profile.Quirks.ShiftVY:
+		g.Reasons = append(g.Reasons, shiftsVY+" shifts VY, so no shift quirk")
+	default:
+		g.Reasons = append(g.Reasons, shiftsVY+" ignores VY with the shift quirk")
+	}
+}
+
+// setsRegister reports whether any of the code writes register v. VF counts
+// as set, since arithmetic and drawing leave flags in it.
+func setsRegister(code []uint16, opcodeAt func(uint16) uint16, v uint16) bool {
+	if v == 0xF {
+		return true
+	}
+	for _, address := range code {
+		op := opcodeAt(address)
+		x := op >> 8 & 0xF
+		switch op >> 12 {
+		case 0x6, 0x7, 0xC:
+			if x == v {
+				return true
+			}
+		case 0x8:
+			if x == v && (op&0xF <= 0x7 || op&0xF == 0xE) {
+				return true
+			}
+		case 0xF:
+			switch op & 0xFF {
+			case 0x07, 0x0A:
+				if x == v {
+					return true
+				}
+			case 0x65, 0x85: // load V0 to VX from memory or flags
+				if x >= v {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package romdb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jsutcodes/chip8-goemu/internal/platform"
+)
+
+func TestGuessPlatform(t *testing.T) {
+	tests := []struct {
+		name   string
+		rom    []byte
+		want   string
+		reason string
+	}{
+		{"plain", []byte{0x60, 0x01, 0x12, 0x02}, platform.Modern.ID, "no SUPER-CHIP"},
+		{"hires", []byte{0x00, 0xFF, 0xD0, 0x10, 0x12, 0x04}, platform.SCHIP.ID, "HIGH at 0x200"},
+		{"xo", []byte{0xF0, 0x00, 0x03, 0x00, 0x51, 0x22, 0x12, 0x06}, platform.XOCHIP.ID, "SAVE V1-V2 at 0x204"},
+		{"big", make([]byte, 3585), platform.XOCHIP.ID, "too big"},
+		{"machine code", []byte{0x03, 0x00, 0x12, 0x02}, platform.VIP.ID, "SYS 0x300"},
+		// 00FF only appears as sprite data after the jump.
+		{"data", []byte{0x12, 0x00, 0x00, 0xFF}, platform.Modern.ID, "no SUPER-CHIP"},
+	}
+	for _, tt := range tests {
+		g := GuessPlatform(tt.rom)
+		if g.Profile.ID != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, g.Profile.ID)
+		}
+		if !strings.Contains(strings.Join(g.Reasons, "\n"), tt.reason) {
+			t.Errorf("%s: expected a reason mentioning %q, got %v", tt.name, tt.reason, g.Reasons)
+		}
+		if g.Confidence <= 0 || g.Confidence > 1 {
+			t.Errorf("%s: confidence %v out of range", tt.name, g.Confidence)
+		}
+	}
+}
+
+func TestGuessShiftHint(t *testing.T) {
+	// V2 is loaded, so 8126 may well mean to shift it, and the platform
+	// decides.
+	g := GuessPlatform([]byte{0x62, 0x05, 0x81, 0x26, 0x12, 0x04})
+	if g.Profile.Quirks.ShiftVY || !strings.Contains(strings.Join(g.Reasons, "\n"), "ignores VY") {
+		t.Errorf("Expected 8126 to keep shifting in place on modern CHIP-8, got %v", g.Reasons)
+	}
+	g = GuessPlatform([]byte{0xF0, 0x00, 0x03, 0x00, 0x62, 0x05, 0x81, 0x26, 0x12, 0x08})
+	if !g.Profile.Quirks.ShiftVY || !strings.Contains(strings.Join(g.Reasons, "\n"), "shifts VY") {
+		t.Errorf("Expected an XO-CHIP ROM to keep shifting VY, got %v", g.Reasons)
+	}
+
+	// V3 is never set, so 813E can only mean shifting V1 in place.
+	plain := GuessPlatform([]byte{0x03, 0x00, 0x61, 0x08, 0x12, 0x06})
+	g = GuessPlatform([]byte{0x03, 0x00, 0x61, 0x08, 0x81, 0x3E, 0x12, 0x08})
+	if g.Profile.ID != platform.VIP.ID || !platform.VIP.Quirks.ShiftVY || g.Profile.Quirks.ShiftVY {
+		t.Errorf("Expected 813E with V3 unset to turn the shift quirk on for the VIP")
+	}
+	if !strings.Contains(strings.Join(g.Reasons, "\n"), "V3 is never set") {
+		t.Errorf("Expected a reason naming V3, got %v", g.Reasons)
+	}
+	if g.Confidence <= plain.Confidence {
+		t.Errorf("Expected the shift evidence to raise confidence from %v, got %v", plain.Confidence, g.Confidence)
+	}
+	// VF is always set by something, so it proves nothing.
+	if g := GuessPlatform([]byte{0x03, 0x00, 0x81, 0xF6, 0x12, 0x04}); !g.Profile.Quirks.ShiftVY {
+		t.Errorf("Expected 81F6 to leave the shift quirk off")
+	}
+}
@@ -0,0 +1,244 @@
+// Package romdb looks ROMs up by the SHA-1 of their contents in the CHIP-8
+// community ROM database (github.com/chip-8/chip-8-database).
+//
+// The embedded programs.json, sha1-hashes.json and platforms.json are in the
+// upstream format, but they are not a copy of it: the first two only
+// describe the ROMs shipped in roms/, and platforms.json only holds the
+// quirks of the platforms the emulator supports. To recognise everything
+// the database knows, replace them with the upstream files, licence and
+// revision by running
+//
+//	go run fetch.go -rev <commit>
+//
+// in this directory.
+package romdb
+
+import (
+	"crypto/sha1"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/jsutcodes/chip8-goemu/internal/display"
+	"github.com/jsutcodes/chip8-goemu/internal/platform"
+)
+
+//go:embed programs.json sha1-hashes.json platforms.json
+var files embed.FS
+
+// Program is a game or demo, which may have several ROMs.
+type Program struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Release     string         `json:"release"`
+	Authors     []string       `json:"authors"`
+	ROMs        map[string]ROM `json:"roms"` // keyed by SHA-1
+}
+
+// ROM is one version of a program.
+type ROM struct {
+	File          string   `json:"file"`
+	EmbeddedTitle string   `json:"embeddedTitle"`
+	Description   string   `json:"description"`
+	Platforms     []string `json:"platforms"` // best first
+	// QuirkyPlatforms lists platforms the ROM needs different quirks on.
+	QuirkyPlatforms map[string]Quirks `json:"quirkyPlatforms"`
+	Tickrate        int               `json:"tickrate"` // instructions per frame
+	StartAddress    int               `json:"startAddress"`
+	Keys            map[string]int    `json:"keys"` // what each key does
+	Colors          *Colors           `json:"colors"`
+}
+
+// Quirks are a platform's quirks in platforms.json, or overrides of them for
+// one ROM. Fields that are not set keep the platform's behaviour.
+type Quirks struct {
+	Shift                 *bool `json:"shift"`
+	MemoryIncrementByX    *bool `json:"memoryIncrementByX"`
+	MemoryLeaveIUnchanged *bool `json:"memoryLeaveIUnchanged"`
+	Wrap                  *bool `json:"wrap"`
+	Jump                  *bool `json:"jump"`
+	VBlank                *bool `json:"vblank"`
+	Logic                 *bool `json:"logic"`
+}
+
+// Colors are the colours a ROM was designed for, as #rrggbb strings.
+type Colors struct {
+	Pixels  []string `json:"pixels"` // background first
+	Buzzer  string   `json:"buzzer"`
+	Silence string   `json:"silence"`
+}
+
+// Entry is what the database knows about one ROM.
+type Entry struct {
+	SHA1    string
+	Program *Program
+	ROM     ROM
+}
+
+// platformInfo is what platforms.json says about one platform.
+type platformInfo struct {
+	ID     string `json:"id"`
+	Quirks Quirks `json:"quirks"`
+}
+
+var (
+	loadOnce       sync.Once
+	programs       []Program
+	hashes         map[string]int
+	platformQuirks map[string]Quirks // keyed by platform ID
+)
+
+func load() {
+	data, err := files.ReadFile("programs.json")
+	if err == nil {
+		err = json.Unmarshal(data, &programs)
+	}
+	if err == nil {
+		data, err = files.ReadFile("sha1-hashes.json")
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &hashes)
+	}
+	var platforms []platformInfo
+	if err == nil {
+		data, err = files.ReadFile("platforms.json")
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &platforms)
+	}
+	platformQuirks = make(map[string]Quirks, len(platforms))
+	for _, p := range platforms {
+		platformQuirks[p.ID] = p.Quirks
+	}
+	if err != nil {
+		panic("romdb: bad embedded database: " + err.Error())
+	}
+}
+
+// Hash returns the SHA-1 the database uses to identify a ROM.
+func Hash(data []byte) string {
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Lookup finds the ROM with the given SHA-1.
+func Lookup(sha1 string) (Entry, bool) {
+	loadOnce.Do(load)
+	i, ok := hashes[sha1]
+	if !ok || i < 0 || i >= len(programs) {
+		return Entry{}, false
+	}
+	p := &programs[i]
+	rom, ok := p.ROMs[sha1]
+	if !ok {
+		return Entry{}, false
+	}
+	return Entry{SHA1: sha1, Program: p, ROM: rom}, true
+}
+
+// Profile returns the profile to run the ROM with: the first of its
+// platforms that the emulator supports, adjusted by any quirks, tickrate and
+// start address the database gives for it.
+func (e Entry) Profile() (platform.Profile, bool) {
+	for _, id := range e.ROM.Platforms {
+		p, ok := profileFor(id)
+		if !ok {
+			continue
+		}
+		if q, ok := e.ROM.QuirkyPlatforms[id]; ok {
+			q.apply(&p)
+		}
+		if e.ROM.Tickrate > 0 {
+			p.InstructionsPerFrame = e.ROM.Tickrate
+		}
+		if a := e.ROM.StartAddress; a > 0 && a < p.Memory.Size {
+			p.Memory.Load, p.Memory.Entry = uint16(a), uint16(a)
+		}
+		return p, true
+	}
+	return platform.Profile{}, false
+}
+
+// Palette returns the colours the ROM was designed for, if the database
+// gives a background and a foreground colour.
+func (e Entry) Palette() (display.Palette, bool) {
+	if e.ROM.Colors == nil || len(e.ROM.Colors.Pixels) < 2 {
+		return display.Palette{}, false
+	}
+	off, err := display.ParseRGB(e.ROM.Colors.Pixels[0])
+	if err != nil {
+		return display.Palette{}, false
+	}
+	on, err := display.ParseRGB(e.ROM.Colors.Pixels[1])
+	if err != nil {
+		return display.Palette{}, false
+	}
+	return display.Palette{On: on, Off: off}, true
+}
+
+// KeyHints describes what each key does in the ROM, such as "1 player1Up",
+// sorted by what it does.
+func (e Entry) KeyHints() []string {
+	actions := make([]string, 0, len(e.ROM.Keys))
+	for action := range e.ROM.Keys {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	hints := make([]string, len(actions))
+	for i, action := range actions {
+		hints[i] = fmt.Sprintf("%X %s", e.ROM.Keys[action], action)
+	}
+	return hints
+}
+
+// profileFor maps a database platform ID to the closest profile, with the
+// quirks platforms.json gives the platform.
+func profileFor(id string) (platform.Profile, bool) {
+	var p platform.Profile
+	switch id {
+	case "originalChip8", "hybridVIP":
+		p = platform.VIP
+	case "modernChip8":
+		p = platform.Modern
+	case "chip48", "superchip1", "superchip":
+		p = platform.SCHIP
+	case "xochip":
+		p = platform.XOCHIP
+	default:
+		return platform.Profile{}, false
+	}
+	loadOnce.Do(load)
+	if q, ok := platformQuirks[id]; ok {
+		q.apply(&p)
+	}
+	return p, true
+}
+
+func (q Quirks) apply(p *platform.Profile) {
+	set := func(dst *bool, src *bool) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	if q.Shift != nil {
+		p.Quirks.ShiftVY = !*q.Shift
+	}
+	set(&p.Quirks.MemoryIncrementByX, q.MemoryIncrementByX)
+	set(&p.Quirks.MemoryLeaveIUnchanged, q.MemoryLeaveIUnchanged)
+	// Without either memory quirk the database has FX55 move I as FX65 does.
+	if q.MemoryIncrementByX != nil || q.MemoryLeaveIUnchanged != nil {
+		p.Quirks.MemoryIncrement = !p.Quirks.MemoryIncrementByX && !p.Quirks.MemoryLeaveIUnchanged
+	}
+	set(&p.Quirks.Jump, q.Jump)
+	set(&p.Quirks.DisplayWait, q.VBlank)
+	set(&p.Quirks.Logic, q.Logic)
+	if q.Wrap != nil {
+		p.Edge = display.Clip
+		if *q.Wrap {
+			p.Edge = display.Wrap
+		}
+	}
+}
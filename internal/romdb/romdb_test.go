@@ -0,0 +1,119 @@
+package romdb
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jsutcodes/chip8-goemu/internal/cpu"
+	"github.com/jsutcodes/chip8-goemu/internal/display"
+	"github.com/jsutcodes/chip8-goemu/internal/platform"
+)
+
+func TestLookupBundledROMs(t *testing.T) {
+	for file, title := range map[string]string{
+		"../../roms/PONG.ch8":    "Pong",
+		"../../roms/IBMLogo.ch8": "IBM Logo",
+	} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, ok := Lookup(Hash(data))
+		if !ok {
+			t.Errorf("Expected %s to be in the database", file)
+			continue
+		}
+		if e.Program.Title != title {
+			t.Errorf("Expected %s to be %q, got %q", file, title, e.Program.Title)
+		}
+	}
+
+	if _, ok := Lookup(Hash([]byte{0x12, 0x00})); ok {
+		t.Errorf("Expected an unknown ROM not to be found")
+	}
+}
+
+func TestProfile(t *testing.T) {
+	yes, no := true, false
+	e := Entry{ROM: ROM{
+		Platforms: []string{"megachip8", "superchip", "xochip"},
+		QuirkyPlatforms: map[string]Quirks{
+			"superchip": {Shift: &no, Wrap: &yes, Logic: &yes},
+		},
+		Tickrate:     50,
+		StartAddress: 0x600,
+	}}
+	p, ok := e.Profile()
+	if !ok || p.ID != platform.SCHIP.ID {
+		t.Fatalf("Expected the first supported platform, superchip, got %q", p.ID)
+	}
+	if !p.Quirks.ShiftVY || !p.Quirks.Logic || !p.Quirks.Jump || p.Edge != display.Wrap {
+		t.Errorf("Expected quirk overrides on top of SCHIP, got %+v edge %v", p.Quirks, p.Edge)
+	}
+	if p.InstructionsPerFrame != 50 {
+		t.Errorf("Expected the tickrate to set the speed, got %d", p.InstructionsPerFrame)
+	}
+	if p.Memory.Load != 0x600 || p.Memory.Entry != 0x600 || platform.SCHIP.Memory.Load != 0x200 {
+		t.Errorf("Expected the start address to move where the ROM loads and starts, got 0x%X", p.Memory.Entry)
+	}
+	if platform.SCHIP.Edge == display.Wrap || platform.SCHIP.Quirks.ShiftVY {
+		t.Errorf("Expected the SCHIP preset to be left alone")
+	}
+
+	e = Entry{ROM: ROM{Platforms: []string{"chip48"}}}
+	if p, _ := e.Profile(); !p.Quirks.MemoryIncrementByX || p.Quirks.MemoryLeaveIUnchanged {
+		t.Errorf("Expected CHIP-48 to increment I by X, got %+v", p.Quirks)
+	}
+
+	e = Entry{ROM: ROM{Platforms: []string{"modernChip8"}}}
+	if p, _ := e.Profile(); !p.Quirks.ShiftVY || !p.Quirks.MemoryIncrement || platform.Modern.Quirks != (cpu.Quirks{}) {
+		t.Errorf("Expected modernChip8 to opt in to shifting VY and FX55 moving I, got %+v", p.Quirks)
+	}
+
+	e = Entry{ROM: ROM{Platforms: []string{"megachip8"}}}
+	if _, ok := e.Profile(); ok {
+		t.Errorf("Expected no profile for an unsupported platform")
+	}
+}
+
+func TestPlatformQuirks(t *testing.T) {
+	vip, _ := profileFor("originalChip8")
+	if vip.Quirks != platform.VIP.Quirks || vip.Edge != display.Clip {
+		t.Errorf("Expected originalChip8 to match the VIP preset, got %+v", vip.Quirks)
+	}
+	schip, _ := profileFor("superchip")
+	if schip.Quirks != platform.SCHIP.Quirks {
+		t.Errorf("Expected superchip to match the SCHIP preset, got %+v", schip.Quirks)
+	}
+	xo, _ := profileFor("xochip")
+	if xo.Quirks != platform.XOCHIP.Quirks || xo.Edge != display.Wrap {
+		t.Errorf("Expected xochip to match the XO-CHIP preset, got %+v", xo.Quirks)
+	}
+	for _, id := range []string{"chip48", "superchip1"} {
+		p, _ := profileFor(id)
+		if !p.Quirks.MemoryIncrementByX || p.Quirks.MemoryLeaveIUnchanged || p.Quirks.ShiftVY || !p.Quirks.Jump {
+			t.Errorf("Expected %s to shift in place, jump by VX and increment I by X, got %+v", id, p.Quirks)
+		}
+	}
+}
+
+func TestPaletteAndKeyHints(t *testing.T) {
+	e := Entry{ROM: ROM{
+		Keys:   map[string]int{"up": 5, "down": 8, "fire": 10},
+		Colors: &Colors{Pixels: []string{"#000000", "#ffaa00", "#ff0000"}},
+	}}
+	p, ok := e.Palette()
+	want := display.Palette{On: display.RGB{R: 0xFF, G: 0xAA}, Off: display.RGB{}}
+	if !ok || p != want {
+		t.Errorf("Expected palette %v, got %v", want, p)
+	}
+	hints := e.KeyHints()
+	if len(hints) != 3 || hints[0] != "8 down" || hints[1] != "A fire" || hints[2] != "5 up" {
+		t.Errorf("Expected key hints sorted by action, got %q", hints)
+	}
+
+	e.ROM.Colors.Pixels = []string{"#000000"}
+	if _, ok := e.Palette(); ok {
+		t.Errorf("Expected no palette without a foreground colour")
+	}
+}
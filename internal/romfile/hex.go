@@ -0,0 +1,62 @@
+package romfile
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errEmptyHex = errors.New("no hex bytes")
+
+// parseHex reads a program written out as hex text. It accepts plain runs
+// of digits as from "xxd -p", bytes or words separated by spaces or commas
+// with or without 0x, "#", ";" and "//" comments, and lines starting with
+// an address and a colon as from "xxd", whose ASCII column is ignored.
+func parseHex(text []byte) ([]byte, error) {
+	var program []byte
+	for n, line := range strings.Split(string(text), "\n") {
+		for _, comment := range []string{"#", ";", "//"} {
+			if i := strings.Index(line, comment); i >= 0 {
+				line = line[:i]
+			}
+		}
+		if i := strings.Index(line, ":"); i >= 0 {
+			address := strings.TrimPrefix(strings.TrimSpace(line[:i]), "0x")
+			if _, err := hex.DecodeString(evenDigits(address)); err != nil || address == "" {
+				return nil, fmt.Errorf("line %d: bad address %q", n+1, line[:i])
+			}
+			line = line[i+1:]
+			// xxd puts the bytes as text after two spaces.
+			if j := strings.Index(strings.TrimLeft(line, " "), "  "); j >= 0 {
+				line = strings.TrimLeft(line, " ")[:j]
+			}
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == '\r' || r == ','
+		})
+		for _, field := range fields {
+			digits := strings.TrimPrefix(strings.TrimPrefix(field, "0x"), "0X")
+			if len(digits)%2 != 0 {
+				return nil, fmt.Errorf("line %d: odd number of digits in %q", n+1, field)
+			}
+			b, err := hex.DecodeString(digits)
+			if err != nil || len(b) == 0 {
+				return nil, fmt.Errorf("line %d: %q is not hex", n+1, field)
+			}
+			program = append(program, b...)
+		}
+	}
+	if len(program) == 0 {
+		return nil, errEmptyHex
+	}
+	return program, nil
+}
+
+// evenDigits pads an address such as "200" so it decodes as hex.
+func evenDigits(s string) string {
+	if len(s)%2 != 0 {
+		return "0" + s
+	}
+	return s
+}
@@ -0,0 +1,32 @@
+package romfile
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseHex(t *testing.T) {
+	want := []byte{0x00, 0xE0, 0xA2, 0x2A, 0x60, 0x0C}
+	tests := []string{
+		"00e0a22a600c",
+		"00E0 A22A\n600C\n",
+		"0x00, 0xE0, 0xA2, 0x2A,\r\n0x60, 0x0C",
+		"# IBM logo\n00 e0 a2 2a ; clear, load I\n60 0c // V0 = 12\n",
+		"00000000: 00e0 a22a 600c                           ..\"*`.\n",
+		"0x200: 00E0 A22A\n0x204: 600C\n",
+	}
+	for _, text := range tests {
+		got, err := parseHex([]byte(text))
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("parseHex(%q): expected % X, got % X (%v)", text, want, got, err)
+		}
+	}
+}
+
+func TestParseHexErrors(t *testing.T) {
+	for _, text := range []string{"", "# nothing\n", "00e", "hello", "zz: 00"} {
+		if _, err := parseHex([]byte(text)); err == nil {
+			t.Errorf("parseHex(%q): expected an error", text)
+		}
+	}
+}
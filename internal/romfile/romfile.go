@@ -0,0 +1,144 @@
+// Package romfile reads CHIP-8 programs out of the files they are shared
+// in. The format is sniffed from the content, not the file name: raw
+// binaries (.ch8, .sc8, .xo8), zip archives of ROMs and hex text dumps are
+// all recognised.
+package romfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+)
+
+// Format is a way of packaging a ROM.
+type Format int
+
+const (
+	Raw Format = iota
+	Hex
+	Zip
+)
+
+func (f Format) String() string {
+	switch f {
+	case Hex:
+		return "hex dump"
+	case Zip:
+		return "zip archive"
+	}
+	return "raw"
+}
+
+// ROM is one program found in a file.
+type ROM struct {
+	Name   string // the file name, or the entry name inside an archive
+	Format Format // how Data was stored
+	Data   []byte
+}
+
+// ErrNoROM is returned for archives with nothing in them that looks like a
+// ROM.
+var ErrNoROM = errors.New("no ROM found")
+
+// Sniff works out how data is packaged.
+func Sniff(data []byte) Format {
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")), bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		return Zip
+	}
+	if isText(data) {
+		if _, err := parseHex(data); err == nil {
+			return Hex
+		}
+	}
+	return Raw
+}
+
+// Decode unpacks the ROMs in data, which was read from the named file. An
+// archive yields one ROM per entry that holds a program; anything else
+// yields exactly one.
+func Decode(name string, data []byte) ([]ROM, error) {
+	switch Sniff(data) {
+	case Zip:
+		return decodeZip(name, data)
+	case Hex:
+		program, _ := parseHex(data)
+		return []ROM{{Name: name, Format: Hex, Data: program}}, nil
+	}
+	return []ROM{{Name: name, Format: Raw, Data: data}}, nil
+}
+
+// Read reads everything from r and decodes it.
+func Read(r io.Reader, name string) ([]ROM, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(name, data)
+}
+
+// Open reads and decodes the file at path, or standard input if path is
+// "-".
+func Open(path string) ([]ROM, error) {
+	if path == "-" {
+		return Read(os.Stdin, "stdin")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f, path)
+}
+
+// decodeZip returns the ROMs in a zip archive. Entries are decoded in turn,
+// so an archive may hold hex dumps as well as binaries; text
+// that is not a hex dump, such as a README, is skipped.
+func decodeZip(name string, data []byte) ([]ROM, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	var roms []ROM
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || path.Base(f.Name)[0] == '.' {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		entry, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", name, f.Name, err)
+		}
+		if len(entry) == 0 || Sniff(entry) == Raw && isText(entry) {
+			continue
+		}
+		found, err := Decode(f.Name, entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		roms = append(roms, found...)
+	}
+	if len(roms) == 0 {
+		return nil, fmt.Errorf("%s: %w", name, ErrNoROM)
+	}
+	return roms, nil
+}
+
+// isText reports whether data is all printable ASCII and white space. No
+// real program is, since almost every ROM contains a 00E0 or a zero byte.
+func isText(data []byte) bool {
+	for _, b := range data {
+		if (b < 0x20 || b > 0x7E) && b != '\n' && b != '\r' && b != '\t' {
+			return false
+		}
+	}
+	return len(data) > 0
+}
@@ -0,0 +1,79 @@
+package romfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func makeZip(t *testing.T, files map[string][]byte, order ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range order {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(files[name])
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want Format
+	}{
+		{[]byte{0x00, 0xE0, 0xA2, 0x2A}, Raw},
+		{[]byte("00e0 a22a\n"), Hex},
+		{[]byte("hello world"), Raw},
+		{makeZip(t, nil), Zip},
+	}
+	for _, tt := range tests {
+		if got := Sniff(tt.data); got != tt.want {
+			t.Errorf("Sniff(%q): expected %s, got %s", tt.data, tt.want, got)
+		}
+	}
+}
+
+func TestDecodeZip(t *testing.T) {
+	files := map[string][]byte{
+		"README.txt":  []byte("Two games.\n"),
+		"games/":      nil,
+		"games/a.ch8": {0x00, 0xE0, 0x12, 0x00},
+		"games/b.hex": []byte("0x60 0x01\n"),
+		"games/empty": {},
+		"__MACOSX/.a": {0x00},
+	}
+	data := makeZip(t, files, "README.txt", "games/", "games/a.ch8", "games/b.hex", "games/empty", "__MACOSX/.a")
+	roms, err := Read(bytes.NewReader(data), "games.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ROM{
+		{Name: "games/a.ch8", Format: Raw, Data: files["games/a.ch8"]},
+		{Name: "games/b.hex", Format: Hex, Data: []byte{0x60, 0x01}},
+	}
+	if !reflect.DeepEqual(roms, want) {
+		t.Errorf("Expected %+v, got %+v", want, roms)
+	}
+
+	data = makeZip(t, files, "README.txt")
+	if _, err := Decode("docs.zip", data); !errors.Is(err, ErrNoROM) {
+		t.Errorf("Expected ErrNoROM, got %v", err)
+	}
+}
+
+func TestDecodeRaw(t *testing.T) {
+	roms, err := Read(strings.NewReader("\x00\xE0"), "stdin")
+	if err != nil || len(roms) != 1 || roms[0].Name != "stdin" || !bytes.Equal(roms[0].Data, []byte{0x00, 0xE0}) {
+		t.Errorf("Expected the bytes back as one ROM, got %+v (%v)", roms, err)
+	}
+}
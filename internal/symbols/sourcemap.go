@@ -0,0 +1,186 @@
+package symbols
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Position is a line in a source file.
+type Position struct {
+	File string
+	Line int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%s:%d", p.File, p.Line)
+}
+
+// SourceMap ties the instructions of a ROM to the source lines, such as
+// Octo lines, they were assembled from. A nil SourceMap has no lines.
+type SourceMap struct {
+	positions map[uint16]Position
+	sorted    []uint16                  // mapped addresses in ascending order
+	lines     map[string]map[int]uint16 // base file name to line to first address
+}
+
+// NewSourceMap returns an empty source map.
+func NewSourceMap() *SourceMap {
+	return &SourceMap{positions: map[uint16]Position{}, lines: map[string]map[int]uint16{}}
+}
+
+// Add records that the instruction at address came from p. An address keeps
+// the first position it is given.
+func (m *SourceMap) Add(address uint16, p Position) {
+	if _, ok := m.positions[address]; ok {
+		return
+	}
+	m.positions[address] = p
+	i := sort.Search(len(m.sorted), func(i int) bool { return m.sorted[i] >= address })
+	m.sorted = append(m.sorted, 0)
+	copy(m.sorted[i+1:], m.sorted[i:])
+	m.sorted[i] = address
+
+	file := filepath.Base(p.File)
+	if m.lines[file] == nil {
+		m.lines[file] = map[int]uint16{}
+	}
+	if first, ok := m.lines[file][p.Line]; !ok || address < first {
+		m.lines[file][p.Line] = address
+	}
+}
+
+// Len returns how many addresses are mapped.
+func (m *SourceMap) Len() int {
+	if m == nil {
+		return 0
+	}
+	return len(m.positions)
+}
+
+// Position returns the source line of the instruction at address: the line
+// of the closest mapped address at or below it.
+func (m *SourceMap) Position(address uint16) (Position, bool) {
+	if m == nil {
+		return Position{}, false
+	}
+	i := sort.Search(len(m.sorted), func(i int) bool { return m.sorted[i] > address })
+	if i == 0 {
+		return Position{}, false
+	}
+	return m.positions[m.sorted[i-1]], true
+}
+
+// Address returns the first instruction assembled from line of file, which
+// is matched by base name. If line has no code the next line that does is
+// used; that line is returned too.
+func (m *SourceMap) Address(file string, line int) (address uint16, actual int, ok bool) {
+	if m == nil {
+		return 0, 0, false
+	}
+	lines := m.lines[filepath.Base(file)]
+	for n := range lines {
+		if n >= line && (!ok || n < actual) {
+			actual, ok = n, true
+		}
+	}
+	return lines[actual], actual, ok
+}
+
+// ReadSourceMap parses a source map, telling the formats apart by whether
+// it starts with a JSON object. The text format has lines of a hex address
+// and a file:line position; blank lines and lines starting with # or ; are
+// ignored. The JSON format is an object whose "lines" array holds objects
+// with an address, a file and a line. name is only used in messages.
+func ReadSourceMap(r io.Reader, name string) (*SourceMap, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		m, err := readSourceMapJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return m, nil
+	}
+	return readSourceMapText(data, name)
+}
+
+// OpenSourceMap parses the source map at path.
+func OpenSourceMap(path string) (*SourceMap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSourceMap(f, path)
+}
+
+func readSourceMapJSON(data []byte) (*SourceMap, error) {
+	var top struct {
+		Lines []struct {
+			Address json.RawMessage `json:"address"`
+			File    string          `json:"file"`
+			Line    int             `json:"line"`
+		} `json:"lines"`
+	}
+	if err := json.Unmarshal(data, &top); err != nil {
+		return nil, err
+	}
+	m := NewSourceMap()
+	for i, l := range top.Lines {
+		var n uint16
+		var s string
+		switch {
+		case json.Unmarshal(l.Address, &n) == nil:
+		case json.Unmarshal(l.Address, &s) == nil:
+			v, err := strconv.ParseUint(s, 0, 16)
+			if err != nil {
+				return nil, fmt.Errorf("lines[%d]: bad address %q", i, s)
+			}
+			n = uint16(v)
+		default:
+			return nil, fmt.Errorf("lines[%d]: missing address", i)
+		}
+		if l.File == "" || l.Line < 1 {
+			return nil, fmt.Errorf("lines[%d]: want a file and a line", i)
+		}
+		m.Add(n, Position{File: l.File, Line: l.Line})
+	}
+	return m, nil
+}
+
+func readSourceMapText(data []byte, name string) (*SourceMap, error) {
+	m := NewSourceMap()
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: want an address and a file:line", name, n)
+		}
+		address := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(fields[0]), "0x"), "$")
+		v, err := strconv.ParseUint(address, 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: bad address %q", name, n, fields[0])
+		}
+		i := strings.LastIndex(fields[1], ":")
+		source, err := strconv.Atoi(fields[1][i+1:])
+		if i <= 0 || err != nil || source < 1 {
+			return nil, fmt.Errorf("%s:%d: bad position %q", name, n, fields[1])
+		}
+		m.Add(uint16(v), Position{File: fields[1][:i], Line: source})
+	}
+	return m, scanner.Err()
+}
@@ -0,0 +1,52 @@
+package symbols
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSourceMap(t *testing.T) {
+	m, err := ReadSourceMap(strings.NewReader("# pong\n200 src/pong.8o:3\n202 src/pong.8o:3\n204 src/pong.8o:4\n0x20A src/pong.8o:9\n"), "pong.map")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Len() != 4 {
+		t.Errorf("Expected 4 addresses, got %d", m.Len())
+	}
+	if p, ok := m.Position(0x206); !ok || p.String() != "src/pong.8o:4" {
+		t.Errorf("Expected 0x206 to belong to line 4, got %v", p)
+	}
+	if _, ok := m.Position(0x100); ok {
+		t.Errorf("Expected nothing before the first mapped address")
+	}
+	if a, line, ok := m.Address("/home/me/src/pong.8o", 3); !ok || a != 0x200 || line != 3 {
+		t.Errorf("Expected line 3 at 0x200, got 0x%X line %d", a, line)
+	}
+	if a, line, ok := m.Address("pong.8o", 5); !ok || a != 0x20A || line != 9 {
+		t.Errorf("Expected a blank line to move to line 9 at 0x20A, got 0x%X line %d", a, line)
+	}
+	if _, _, ok := m.Address("pong.8o", 10); ok {
+		t.Errorf("Expected no code after the last line")
+	}
+	if _, err := ReadSourceMap(strings.NewReader("200 pong.8o\n"), "bad.map"); err == nil || !strings.HasPrefix(err.Error(), "bad.map:1:") {
+		t.Errorf("Expected an error naming the line, got %v", err)
+	}
+
+	var none *SourceMap
+	if _, _, ok := none.Address("pong.8o", 1); ok || none.Len() != 0 {
+		t.Errorf("Expected a nil map to be empty")
+	}
+}
+
+func TestSourceMapJSON(t *testing.T) {
+	m, err := ReadSourceMap(strings.NewReader(`{"lines": [{"address": 512, "file": "pong.8o", "line": 1}, {"address": "0x202", "file": "pong.8o", "line": 2}]}`), "pong.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, _, _ := m.Address("pong.8o", 2); a != 0x202 {
+		t.Errorf("Expected line 2 at 0x202, got 0x%X", a)
+	}
+	if _, err := ReadSourceMap(strings.NewReader(`{"lines": [{"address": 512}]}`), "bad.json"); err == nil {
+		t.Errorf("Expected an error for a line with no position")
+	}
+}
@@ -0,0 +1,201 @@
+// Package symbols maps addresses in a ROM to the names its source gave them,
+// read from Octo's exported label JSON or from a text file of "ADDR NAME"
+// lines.
+package symbols
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Table is a set of named addresses. A nil Table has no names, so callers
+// need not check whether symbols were loaded.
+type Table struct {
+	names     map[uint16]string
+	addresses map[string]uint16
+	sorted    []uint16 // named addresses in ascending order
+}
+
+// New returns an empty table.
+func New() *Table {
+	return &Table{names: map[uint16]string{}, addresses: map[string]uint16{}}
+}
+
+// Add names address. An address keeps the first name it is given, but every
+// name can be looked up.
+func (t *Table) Add(name string, address uint16) {
+	t.addresses[name] = address
+	if _, ok := t.names[address]; ok {
+		return
+	}
+	t.names[address] = name
+	i := sort.Search(len(t.sorted), func(i int) bool { return t.sorted[i] >= address })
+	t.sorted = append(t.sorted, 0)
+	copy(t.sorted[i+1:], t.sorted[i:])
+	t.sorted[i] = address
+}
+
+// Len returns how many names there are.
+func (t *Table) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.addresses)
+}
+
+// Name returns the name of address.
+func (t *Table) Name(address uint16) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	name, ok := t.names[address]
+	return name, ok
+}
+
+// Address returns the address called name.
+func (t *Table) Address(name string) (uint16, bool) {
+	if t == nil {
+		return 0, false
+	}
+	address, ok := t.addresses[name]
+	return address, ok
+}
+
+// Nearest returns the closest named address at or below address.
+func (t *Table) Nearest(address uint16) (name string, start uint16, ok bool) {
+	if t == nil {
+		return "", 0, false
+	}
+	i := sort.Search(len(t.sorted), func(i int) bool { return t.sorted[i] > address })
+	if i == 0 {
+		return "", 0, false
+	}
+	start = t.sorted[i-1]
+	return t.names[start], start, true
+}
+
+// Format writes address by name: "draw-paddle", or "draw-paddle+6" for an
+// address 6 bytes past the label, or in hex if no label comes before it.
+func (t *Table) Format(address uint16) string {
+	name, start, ok := t.Nearest(address)
+	switch {
+	case !ok:
+		return fmt.Sprintf("0x%03X", address)
+	case start == address:
+		return name
+	}
+	return fmt.Sprintf("%s+%d", name, address-start)
+}
+
+// Parse reads an address written as a name, a name plus a decimal or 0x
+// offset ("draw-paddle+6"), or hex with or without 0x.
+func (t *Table) Parse(s string) (uint16, bool) {
+	if address, ok := t.Address(s); ok {
+		return address, true
+	}
+	if name, offset, ok := strings.Cut(s, "+"); ok {
+		base, found := t.Address(name)
+		n, err := strconv.ParseUint(offset, 0, 16)
+		if found && err == nil {
+			return base + uint16(n), true
+		}
+	}
+	v, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(s), "0x"), 16, 16)
+	return uint16(v), err == nil
+}
+
+// Read parses a symbol file, telling the formats apart by whether it starts
+// with a JSON object. name is only used in messages.
+func Read(r io.Reader, name string) (*Table, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		t, err := readJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return t, nil
+	}
+	return readText(data, name)
+}
+
+// Open parses the symbol file at path.
+func Open(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f, path)
+}
+
+// readJSON reads Octo's label export: an object mapping label names to
+// addresses, either at the top level or under "labels". Addresses may be
+// numbers or strings such as "0x2A4"; other entries are ignored.
+func readJSON(data []byte) (*Table, error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(data, &top); err != nil {
+		return nil, err
+	}
+	if labels, ok := top["labels"]; ok {
+		if err := json.Unmarshal(labels, &top); err != nil {
+			return nil, fmt.Errorf("labels: %w", err)
+		}
+	}
+	t := New()
+	names := make([]string, 0, len(top))
+	for name := range top {
+		names = append(names, name)
+	}
+	sort.Strings(names) // which of two names for an address wins must not be random
+	for _, name := range names {
+		var n uint16
+		var s string
+		switch {
+		case json.Unmarshal(top[name], &n) == nil:
+		case json.Unmarshal(top[name], &s) == nil:
+			v, err := strconv.ParseUint(s, 0, 16)
+			if err != nil {
+				return nil, fmt.Errorf("label %s: bad address %q", name, s)
+			}
+			n = uint16(v)
+		default:
+			continue
+		}
+		t.Add(name, n)
+	}
+	return t, nil
+}
+
+// readText reads lines of a hex address and a name. Blank lines and lines
+// starting with # or ; are ignored.
+func readText(data []byte, name string) (*Table, error) {
+	t := New()
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: want an address and a name", name, n)
+		}
+		address := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(fields[0]), "0x"), "$")
+		v, err := strconv.ParseUint(address, 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: bad address %q", name, n, fields[0])
+		}
+		t.Add(fields[1], uint16(v))
+	}
+	return t, scanner.Err()
+}
@@ -0,0 +1,75 @@
+package symbols
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadText(t *testing.T) {
+	table, err := Read(strings.NewReader("# PONG\n200 main\n0x2A0 draw-paddle\n$2B0 score\n2A0 paddle\n"), "pong.sym")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Len() != 4 {
+		t.Errorf("Expected 4 names, got %d", table.Len())
+	}
+	if name, ok := table.Name(0x2A0); !ok || name != "draw-paddle" {
+		t.Errorf("Expected 0x2A0 to keep its first name, got %q", name)
+	}
+	if address, ok := table.Address("paddle"); !ok || address != 0x2A0 {
+		t.Errorf("Expected the second name to be found too, got 0x%X", address)
+	}
+	if _, err := Read(strings.NewReader("200\n"), "bad.sym"); err == nil || !strings.HasPrefix(err.Error(), "bad.sym:1:") {
+		t.Errorf("Expected an error naming the line, got %v", err)
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	for _, file := range []string{
+		`{"main": 512, "draw-paddle": "0x2A0", "breakpoints": {}}`,
+		`{"labels": {"main": 512, "draw-paddle": 672}}`,
+	} {
+		table, err := Read(strings.NewReader(file), "labels.json")
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if a, _ := table.Address("main"); a != 0x200 {
+			t.Errorf("%s: expected main at 0x200, got 0x%X", file, a)
+		}
+		if a, _ := table.Address("draw-paddle"); a != 0x2A0 {
+			t.Errorf("%s: expected draw-paddle at 0x2A0, got 0x%X", file, a)
+		}
+	}
+	if _, err := Read(strings.NewReader(`{"main": "here"}`), "bad.json"); err == nil {
+		t.Errorf("Expected an error for an address that is not a number")
+	}
+}
+
+func TestFormatAndParse(t *testing.T) {
+	table := New()
+	table.Add("main", 0x200)
+	table.Add("draw-paddle", 0x2A0)
+	tests := map[uint16]string{0x100: "0x100", 0x200: "main", 0x204: "main+4", 0x2A0: "draw-paddle", 0x2A6: "draw-paddle+6"}
+	for address, want := range tests {
+		if got := table.Format(address); got != want {
+			t.Errorf("Format(0x%X) = %q, want %q", address, got, want)
+		}
+		if got, ok := table.Parse(want); !ok || got != address {
+			t.Errorf("Parse(%q) = 0x%X, %v", want, got, ok)
+		}
+	}
+	if got, ok := table.Parse("draw-paddle+0x10"); !ok || got != 0x2B0 {
+		t.Errorf("Expected a hex offset to be accepted, got 0x%X", got)
+	}
+	if _, ok := table.Parse("nowhere"); ok {
+		t.Errorf("Expected an unknown name to fail")
+	}
+
+	var none *Table
+	if none.Format(0x200) != "0x200" || none.Len() != 0 {
+		t.Errorf("Expected a nil table to format addresses in hex")
+	}
+	if a, ok := none.Parse("2a0"); !ok || a != 0x2A0 {
+		t.Errorf("Expected a nil table to parse hex")
+	}
+}
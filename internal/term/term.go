@@ -0,0 +1,4 @@
+// Package term puts terminals into raw mode and finds their size. It makes
+// the system calls itself, so it only works on Linux and the BSDs,
+// including macOS.
+package term
@@ -0,0 +1,16 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package term
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func MakeRaw(fd uintptr) (restore func() error, err error) {
+	return nil, fmt.Errorf("terminals are not supported on %s", runtime.GOOS)
+}
+
+func Size(fd uintptr) (width, height int, err error) {
+	return 0, 0, fmt.Errorf("terminals are not supported on %s", runtime.GOOS)
+}
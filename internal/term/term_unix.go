@@ -0,0 +1,44 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package term
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// MakeRaw puts the terminal on fd into raw mode, so keys arrive as they are
+// typed without being echoed, and returns a function that restores it.
+func MakeRaw(fd uintptr) (restore func() error, err error) {
+	var old syscall.Termios
+	if err := ioctl(fd, ioctlGetTermios, unsafe.Pointer(&old)); err != nil {
+		return nil, err
+	}
+	raw := old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(fd, ioctlSetTermios, unsafe.Pointer(&raw)); err != nil {
+		return nil, err
+	}
+	return func() error { return ioctl(fd, ioctlSetTermios, unsafe.Pointer(&old)) }, nil
+}
+
+// Size returns the size of the terminal on fd in characters.
+func Size(fd uintptr) (width, height int, err error) {
+	var ws struct{ Row, Col, X, Y uint16 }
+	if err := ioctl(fd, syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil {
+		return 0, 0, err
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
+
+func ioctl(fd, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
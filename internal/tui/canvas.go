@@ -0,0 +1,152 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// style is how a cell is drawn.
+type style uint8
+
+const (
+	plain style = iota
+	bold
+	inverse
+	red
+	dim
+)
+
+// sgr selects each style, starting from the default so styles never mix.
+var sgr = [...]string{
+	plain:   "\x1b[0m",
+	bold:    "\x1b[0;1m",
+	inverse: "\x1b[0;7m",
+	red:     "\x1b[0;31m",
+	dim:     "\x1b[0;2m",
+}
+
+type cell struct {
+	r rune
+	s style
+}
+
+// canvas is a frame of the terminal, drawn in full and then shown.
+type canvas struct {
+	width, height int
+	cells         []cell
+}
+
+func newCanvas(width, height int) *canvas {
+	c := &canvas{width: width, height: height, cells: make([]cell, width*height)}
+	for i := range c.cells {
+		c.cells[i] = cell{r: ' '}
+	}
+	return c
+}
+
+func (c *canvas) set(x, y int, r rune, s style) {
+	if x >= 0 && x < c.width && y >= 0 && y < c.height {
+		c.cells[y*c.width+x] = cell{r, s}
+	}
+}
+
+// text writes s from (x, y), cut off at the edge, and returns the column
+// after it.
+func (c *canvas) text(x, y int, s string, st style) int {
+	for _, r := range s {
+		c.set(x, y, r, st)
+		x++
+	}
+	return x
+}
+
+// fill gives the cells from (x, y) for width columns style s, keeping their
+// characters.
+func (c *canvas) fill(x, y, width int, s style) {
+	for i := x; i < x+width; i++ {
+		if i >= 0 && i < c.width && y >= 0 && y < c.height {
+			c.cells[y*c.width+i].s = s
+		}
+	}
+}
+
+// box draws a frame with its top left corner at (x, y) and a title in the
+// top edge.
+func (c *canvas) box(x, y, width, height int, title string) {
+	for i := x + 1; i < x+width-1; i++ {
+		c.set(i, y, '─', dim)
+		c.set(i, y+height-1, '─', dim)
+	}
+	for j := y + 1; j < y+height-1; j++ {
+		c.set(x, j, '│', dim)
+		c.set(x+width-1, j, '│', dim)
+	}
+	c.set(x, y, '┌', dim)
+	c.set(x+width-1, y, '┐', dim)
+	c.set(x, y+height-1, '└', dim)
+	c.set(x+width-1, y+height-1, '┘', dim)
+	if title != "" {
+		c.text(x+2, y, " "+title+" ", bold)
+	}
+}
+
+// line returns row y with the escape sequences to style it.
+func (c *canvas) line(y int) string {
+	var b strings.Builder
+	current := style(255)
+	for _, cl := range c.cells[y*c.width : (y+1)*c.width] {
+		if cl.s != current {
+			b.WriteString(sgr[cl.s])
+			current = cl.s
+		}
+		b.WriteRune(cl.r)
+	}
+	b.WriteString(sgr[plain])
+	return b.String()
+}
+
+// String returns the text of the canvas without styles, one line per row.
+func (c *canvas) String() string {
+	var b strings.Builder
+	for i, cl := range c.cells {
+		b.WriteRune(cl.r)
+		if i%c.width == c.width-1 {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// screen shows canvases on a terminal, rewriting only the rows that changed
+// since the last one.
+type screen struct {
+	w     io.Writer
+	lines []string
+	width int
+}
+
+func (s *screen) show(c *canvas) error {
+	var b strings.Builder
+	if c.width != s.width || c.height != len(s.lines) {
+		b.WriteString("\x1b[2J")
+		s.lines, s.width = make([]string, c.height), c.width
+	}
+	for y := range s.lines {
+		line := c.line(y)
+		if line != s.lines[y] {
+			fmt.Fprintf(&b, "\x1b[%d;1H%s", y+1, line)
+			s.lines[y] = line
+		}
+	}
+	if b.Len() == 0 {
+		return nil
+	}
+	_, err := io.WriteString(s.w, b.String())
+	return err
+}
+
+// invalidate makes the next show rewrite every row.
+func (s *screen) invalidate() {
+	s.lines = nil
+}
@@ -0,0 +1,41 @@
+package tui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCanvas(t *testing.T) {
+	c := newCanvas(8, 3)
+	c.box(0, 0, 8, 3, "ab")
+	c.text(1, 1, "hello world", inverse)
+	if got := c.String(); got != "┌─ ab ─┐\n│hello w\n└──────┘\n" {
+		t.Errorf("Unexpected canvas %q", got)
+	}
+	if got := c.line(1); !strings.HasPrefix(got, "\x1b[0;2m│\x1b[0;7mhello w\x1b[0m") {
+		t.Errorf("Expected the text in reverse video, got %q", got)
+	}
+}
+
+func TestScreenRedrawsChangedRows(t *testing.T) {
+	var out bytes.Buffer
+	s := &screen{w: &out}
+	c := newCanvas(4, 3)
+	s.show(c)
+	if !strings.HasPrefix(out.String(), "\x1b[2J") || strings.Count(out.String(), "H") != 3 {
+		t.Errorf("Expected the first frame to clear the screen and draw every row, got %q", out.String())
+	}
+
+	out.Reset()
+	s.show(c)
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing written for an unchanged frame, got %q", out.String())
+	}
+
+	c.text(0, 2, "x", plain)
+	s.show(c)
+	if got := out.String(); !strings.HasPrefix(got, "\x1b[3;1H") || strings.Count(got, "H") != 1 {
+		t.Errorf("Expected only row 3 rewritten, got %q", got)
+	}
+}
@@ -0,0 +1,214 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jsutcodes/chip8-goemu/internal/disasm"
+	"github.com/jsutcodes/chip8-goemu/internal/display"
+)
+
+// The layout needs at least this many columns and rows.
+const (
+	minWidth  = 96
+	minHeight = 32
+)
+
+// screenBox is the size of the framed screen: two pixel rows per line.
+const (
+	screenBoxWidth  = display.Width + 2
+	screenBoxHeight = display.Height/2 + 2
+)
+
+// draw lays the whole debugger out on c: a status line, the screen and
+// registers, the disassembly and memory, the console and the command line.
+func (u *UI) draw(c *canvas) {
+	if c.width < minWidth || c.height < minHeight {
+		msg := fmt.Sprintf("The terminal is %dx%d; the debugger needs at least %dx%d.", c.width, c.height, minWidth, minHeight)
+		c.text(max((c.width-len(msg))/2, 0), c.height/2, msg, bold)
+		return
+	}
+	u.drawStatus(c)
+	top := 1
+	u.drawScreen(c, 0, top)
+	u.drawRegisters(c, screenBoxWidth, top, c.width-screenBoxWidth, screenBoxHeight)
+
+	middle := top + screenBoxHeight
+	consoleHeight := max(5, (c.height-middle-1)/3)
+	middleHeight := c.height - middle - 1 - consoleHeight
+	half := c.width / 2
+	u.drawCode(c, 0, middle, half, middleHeight)
+	u.drawMemory(c, half, middle, c.width-half, middleHeight)
+	u.drawConsole(c, 0, middle+middleHeight, c.width, consoleHeight)
+	u.drawCommand(c, c.height-1)
+}
+
+func (u *UI) drawStatus(c *canvas) {
+	state := "running"
+	if u.emu.Paused() {
+		state = "paused at " + u.d.Where(u.emu.CPU.PC)
+	}
+	var keys []string
+	for key := range 16 {
+		if u.emu.Input.IsKeyPressed(byte(key)) {
+			keys = append(keys, fmt.Sprintf("%X", key))
+		}
+	}
+	if len(keys) > 0 {
+		state += "  keys " + strings.Join(keys, " ")
+	}
+	state += fmt.Sprintf("  %d/frame", u.emu.Speed.InstructionsPerFrame)
+	switch {
+	case u.emu.FastForwarding():
+		state += " fast-forward"
+	case u.emu.InSlowMotion():
+		state += " slow motion"
+	}
+	if u.emu.Speed.Uncapped {
+		state += " uncapped"
+	}
+	end := c.text(1, 0, "CHIP-8 "+state, inverse)
+	help := "F5 run/pause  F9 break  F10 next  F11 step  S-F11 finish  ^C quit "
+	if end+2+len(help) <= c.width {
+		c.text(c.width-len(help), 0, help, inverse)
+	}
+	c.fill(0, 0, c.width, inverse)
+}
+
+// drawScreen draws the screen with a half-block character for each pair of
+// pixel rows.
+func (u *UI) drawScreen(c *canvas, x, y int) {
+	c.box(x, y, screenBoxWidth, screenBoxHeight, "Screen")
+	d := u.emu.Display
+	for row := 0; row < display.Height/2; row++ {
+		for col := 0; col < display.Width; col++ {
+			r := ' '
+			switch upper, lower := d.IsPixelOn(col, 2*row), d.IsPixelOn(col, 2*row+1); {
+			case upper && lower:
+				r = '█'
+			case upper:
+				r = '▀'
+			case lower:
+				r = '▄'
+			}
+			c.set(x+1+col, y+1+row, r, plain)
+		}
+	}
+}
+
+func (u *UI) drawRegisters(c *canvas, x, y, width, height int) {
+	c.box(x, y, width, height, "Registers")
+	cpu := u.emu.CPU
+	x, y = x+2, y+1
+	for row := 0; row < 4; row++ {
+		col := x
+		for i := 4 * row; i < 4*row+4; i++ {
+			col = c.text(col, y+row, fmt.Sprintf("V%X ", i), dim)
+			col = c.text(col, y+row, fmt.Sprintf("%02X  ", cpu.V[i]), plain)
+		}
+	}
+	c.text(x, y+5, fmt.Sprintf("I  %03X  PC %03X", cpu.I, cpu.PC), plain)
+	c.text(x, y+6, fmt.Sprintf("DT %02X   ST %02X   SP %X", cpu.DT, cpu.ST, cpu.SP), plain)
+
+	c.text(x, y+8, "Stack", dim)
+	rows := height - 2 - 9
+	for i := 0; i < int(cpu.SP); i++ {
+		if i == rows-1 && int(cpu.SP) > rows {
+			c.text(x, y+9+i, fmt.Sprintf("... %d more", int(cpu.SP)-i), dim)
+			break
+		}
+		// Innermost call first.
+		c.text(x, y+9+i, u.d.Where(cpu.Stack[int(cpu.SP)-1-i]), plain)
+	}
+}
+
+// drawCode disassembles around PC, marking breakpoints and highlighting PC.
+func (u *UI) drawCode(c *canvas, x, y, width, height int) {
+	c.box(x, y, width, height, "Code")
+	rows := height - 2
+	pc := u.emu.CPU.PC
+	start := max(int(pc)-2*(rows/3), 0)
+	memory := u.emu.RAM.Snapshot()
+	breaks := map[uint16]bool{}
+	for _, b := range u.emu.CPU.Breakpoints() {
+		breaks[b] = true
+	}
+	row := 0
+	for _, line := range disasm.Disassemble(memory, 0, uint16(start), rows) {
+		if row >= rows {
+			break
+		}
+		if name, ok := u.emu.Symbols.Name(line.Address); ok && row < rows-1 {
+			c.text(x+2, y+1+row, name+":", bold)
+			row++
+		}
+		if breaks[line.Address] {
+			c.set(x+1, y+1+row, '●', red)
+		}
+		marker := "  "
+		if line.Address == pc {
+			marker = "=>"
+		}
+		c.text(x+2, y+1+row, fmt.Sprintf("%s %03X  %04X  %s", marker, line.Address, line.Opcode, line.Named(u.emu.Symbols.Format)), plain)
+		if line.Address == pc {
+			c.fill(x+2, y+1+row, width-3, inverse)
+		}
+		row++
+	}
+}
+
+// drawMemory shows memory from the view address, or around I, with the
+// byte at I highlighted.
+func (u *UI) drawMemory(c *canvas, x, y, width, height int) {
+	perRow := 8
+	if width-4 >= 7+16*3+1+16 {
+		perRow = 16
+	}
+	i := u.emu.CPU.I
+	start := u.memory
+	title := "Memory"
+	if u.follow {
+		start, title = i-i%uint16(perRow), "Memory at I"
+	}
+	c.box(x, y, width, height, title)
+	for row := 0; row < height-2; row++ {
+		address := int(start) + row*perRow
+		if address >= u.emu.RAM.Size() {
+			break
+		}
+		col := c.text(x+2, y+1+row, fmt.Sprintf("%03X  ", address), dim)
+		var text strings.Builder
+		for n := address; n < address+perRow && n < u.emu.RAM.Size(); n++ {
+			b, _ := u.emu.RAM.ReadByte(uint16(n))
+			c.text(col, y+1+row, fmt.Sprintf("%02X", b), plain)
+			if uint16(n) == i {
+				c.fill(col, y+1+row, 2, inverse)
+			}
+			col += 3
+			if b >= 0x20 && b < 0x7F {
+				text.WriteByte(b)
+			} else {
+				text.WriteByte('.')
+			}
+		}
+		c.text(col+1, y+1+row, text.String(), dim)
+	}
+}
+
+func (u *UI) drawConsole(c *canvas, x, y, width, height int) {
+	c.box(x, y, width, height, "Console")
+	rows := height - 2
+	lines := u.output[max(len(u.output)-rows, 0):]
+	for row, line := range lines {
+		c.text(x+2, y+1+row, line, plain)
+	}
+}
+
+func (u *UI) drawCommand(c *canvas, y int) {
+	if !u.editing {
+		c.text(1, y, "Press : to type a debugger command (help lists them). Keys 1-4, Q-R, A-F and Z-V are the keypad; Tab, `, F1, = and - set the speed.", dim)
+		return
+	}
+	col := c.text(1, y, ":"+u.command, plain)
+	c.set(col, y, ' ', inverse)
+}
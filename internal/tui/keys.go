@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"unicode/utf8"
+
+	"github.com/jsutcodes/chip8-goemu/internal/input"
+)
+
+// Keys are named by the text they type, or otherwise by names such as
+// "Enter", "F5" and "Shift-F11".
+
+// csiKeys names the keys sent as "ESC [" sequences, by the rest of the
+// sequence.
+var csiKeys = map[string]string{
+	"A": "Up", "B": "Down", "C": "Right", "D": "Left", "H": "Home", "F": "End",
+	"11~": "F1", "1~": "Home", "4~": "End", "5~": "PgUp", "6~": "PgDn",
+	"15~": "F5", "17~": "F6", "18~": "F7", "19~": "F8",
+	"20~": "F9", "21~": "F10", "23~": "F11", "24~": "F12",
+	"23;2~": "Shift-F11",
+}
+
+// ss3Keys names the keys sent as "ESC O" sequences, by their last byte.
+var ss3Keys = map[byte]string{'P': "F1", 'Q': "F2", 'R': "F3", 'S': "F4", 'H': "Home", 'F': "End"}
+
+// hotkeys binds the speed controls to the same keys as in SDL. Terminals
+// only report presses, so fast-forward lasts as long as Tab repeats.
+var hotkeys = map[string]input.Hotkey{
+	"Tab": input.FastForward, "`": input.SlowMotion, "F1": input.Uncapped,
+	"=": input.SpeedUp, "-": input.SpeedDown,
+}
+
+var controlKeys = map[byte]string{
+	0x03: "Ctrl-C", 0x08: "Backspace", '\t': "Tab", '\n': "Enter", '\r': "Enter", 0x7F: "Backspace",
+}
+
+// decodeKeys splits what the terminal sent into keys. An escape at the end
+// of the input is taken to be the Escape key, since terminals send each
+// escape sequence in one piece. Sequences that are not known are dropped.
+func decodeKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		switch {
+		case b[0] == 0x1B && len(b) > 2 && b[1] == '[':
+			end := 2
+			for end < len(b) && (b[end] < 0x40 || b[end] > 0x7E) {
+				end++
+			}
+			if end == len(b) {
+				return keys
+			}
+			if key, ok := csiKeys[string(b[2:end+1])]; ok {
+				keys = append(keys, key)
+			}
+			b = b[end+1:]
+		case b[0] == 0x1B && len(b) > 2 && b[1] == 'O':
+			if key, ok := ss3Keys[b[2]]; ok {
+				keys = append(keys, key)
+			}
+			b = b[3:]
+		case b[0] == 0x1B:
+			keys = append(keys, "Esc")
+			b = b[1:]
+		case controlKeys[b[0]] != "":
+			keys = append(keys, controlKeys[b[0]])
+			b = b[1:]
+		case b[0] < 0x20:
+			b = b[1:]
+		default:
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, string(r))
+			b = b[size:]
+		}
+	}
+	return keys
+}
@@ -0,0 +1,24 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeKeys(t *testing.T) {
+	tests := map[string][]string{
+		"q1:":              {"q", "1", ":"},
+		"\x1b[15~\x1b[21~": {"F5", "F10"},
+		"\x1b[23;2~":       {"Shift-F11"},
+		"\x1bOP\x1b[A\r":   {"F1", "Up", "Enter"},
+		"\x7f\x03":         {"Backspace", "Ctrl-C"},
+		"\x1b":             {"Esc"},
+		"\x1b[99~é":        {"é"},
+		"\x1b[1":           nil,
+	}
+	for in, want := range tests {
+		if got := decodeKeys([]byte(in)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: expected %q, got %q", in, want, got)
+		}
+	}
+}
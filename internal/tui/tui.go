@@ -0,0 +1,331 @@
+// Package tui is a full-screen debugger for terminals, for working without a
+// display such as over SSH. It shows the screen in half-block characters,
+// the registers and stack, the disassembly around PC, a memory view and a
+// console for debugger commands, redrawn live as the program runs or steps.
+// Keys not used by the debugger are the keypad.
+//
+// Keys:
+//
+//	1 2 3 4 / Q W E R / A S D F / Z X C V   the keypad, as with SDL
+//	:                                      type a debugger command
+//	F5                                     pause or carry on
+//	F9                                     set or clear a breakpoint at PC
+//	F10, F11, Shift-F11                    next, step, finish
+//	Tab (held), `, F1, =, -                fast-forward, slow motion,
+//	                                       uncapped, faster, slower
+//	PgUp, PgDn, Home                       scroll memory, or follow I again
+//	Ctrl-C                                 quit
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jsutcodes/chip8-goemu/internal/debugger"
+	"github.com/jsutcodes/chip8-goemu/internal/emulator"
+	"github.com/jsutcodes/chip8-goemu/internal/input"
+	"github.com/jsutcodes/chip8-goemu/internal/term"
+)
+
+// holdTime is how long a key counts as held after the terminal sends it.
+// Terminals only report presses, so a key that is held down relies on key
+// repeat to stay pressed.
+const holdTime = 150 * time.Millisecond
+
+// redrawInterval is the least time between redraws while running.
+const redrawInterval = time.Second / 30
+
+// maxOutput is how many lines of console output are kept.
+const maxOutput = 200
+
+// UI is a terminal debugger for one emulator.
+type UI struct {
+	d   *debugger.Debugger
+	emu *emulator.Emulator
+
+	in      *os.File
+	out     *screen
+	keys    chan []byte
+	restore func() error
+	size    func() (width, height int)
+
+	editing bool     // typing a command
+	command string   // the command being typed
+	history []string // commands run, oldest first
+	recall  int      // how far back Up has gone in history
+	output  []string // console output, oldest first
+
+	held     [16]time.Time // when each pressed key is released
+	fastHeld time.Time     // when fast-forward is let go of
+	memory   uint16        // first address of the memory view, unless following
+	follow   bool          // the memory view follows I
+	drawn    time.Time
+	redrawn  bool // something changed that should be shown at once
+	finished bool // standard input ended
+}
+
+// New returns a terminal debugger reading keys from in and drawing on out,
+// which should both be the terminal.
+func New(d *debugger.Debugger, in, out *os.File) *UI {
+	u := newUI(d, out)
+	u.in = in
+	u.size = func() (int, int) {
+		width, height, err := term.Size(out.Fd())
+		if err != nil {
+			return 80, 24
+		}
+		return width, height
+	}
+	return u
+}
+
+func newUI(d *debugger.Debugger, w io.Writer) *UI {
+	u := &UI{d: d, emu: d.Emulator(), out: &screen{w: w}, keys: make(chan []byte, 16), follow: true}
+	u.size = func() (int, int) { return minWidth, minHeight }
+	d.OnStop(func(pc uint16) {
+		u.print(fmt.Sprintf("Breakpoint at %s", d.Where(pc)))
+	})
+	return u
+}
+
+// Start takes over the terminal and chains onto any Poll the emulator
+// already has, to read keys and redraw between frames. Call Close once the
+// emulator stops.
+func (u *UI) Start() error {
+	restore, err := term.MakeRaw(u.in.Fd())
+	if err != nil {
+		return err
+	}
+	u.restore = restore
+	io.WriteString(u.out.w, "\x1b[?1049h\x1b[?25l\x1b[2J")
+	go func() {
+		for {
+			buf := make([]byte, 256)
+			n, err := u.in.Read(buf)
+			if n > 0 {
+				u.keys <- buf[:n]
+			}
+			if err != nil {
+				close(u.keys)
+				return
+			}
+		}
+	}()
+	poll := u.emu.Poll
+	u.emu.Poll = func() {
+		if poll != nil {
+			poll()
+		}
+		u.poll()
+	}
+	return nil
+}
+
+// Close gives the terminal back as it was.
+func (u *UI) Close() error {
+	if u.restore == nil {
+		return nil
+	}
+	io.WriteString(u.out.w, "\x1b[0m\x1b[?25h\x1b[?1049l")
+	err := u.restore()
+	u.restore = nil
+	return err
+}
+
+// poll handles the keys typed since the last frame, lets go of keys held
+// long enough and redraws if it is time to.
+func (u *UI) poll() {
+	for reading := !u.finished; reading; {
+		select {
+		case b, ok := <-u.keys:
+			if !ok {
+				u.finished, reading = true, false
+				continue
+			}
+			for _, key := range decodeKeys(b) {
+				u.key(key)
+			}
+		default:
+			reading = false
+		}
+	}
+	now := time.Now()
+	for key, until := range u.held {
+		if !until.IsZero() && now.After(until) {
+			u.emu.Input.SetKeyPressed(byte(key), false)
+			u.held[key] = time.Time{}
+		}
+	}
+	if !u.fastHeld.IsZero() && now.After(u.fastHeld) {
+		u.emu.HandleHotkey(input.FastForward, false)
+		u.fastHeld = time.Time{}
+	}
+	if u.redrawn || now.Sub(u.drawn) >= redrawInterval {
+		u.redraw()
+	}
+}
+
+func (u *UI) redraw() {
+	width, height := u.size()
+	c := newCanvas(width, height)
+	u.draw(c)
+	u.out.show(c)
+	u.drawn, u.redrawn = time.Now(), false
+}
+
+// key handles one key.
+func (u *UI) key(key string) {
+	u.redrawn = true
+	if key == "Ctrl-C" {
+		u.emu.Stop()
+		return
+	}
+	if u.editing {
+		u.edit(key)
+		return
+	}
+	switch key {
+	case ":":
+		u.editing, u.recall = true, 0
+	case "F5":
+		if u.emu.Paused() {
+			u.emu.Resume()
+		} else {
+			u.emu.Pause()
+		}
+	case "F9":
+		pc := u.emu.CPU.PC
+		if u.breakpoint(pc) {
+			u.emu.CPU.ClearBreakpoint(pc)
+		} else {
+			u.emu.CPU.SetBreakpoint(pc)
+		}
+	case "F10":
+		u.d.StepOver()
+	case "F11":
+		u.d.Step(1)
+	case "Shift-F11":
+		u.d.StepOut()
+	case "PgUp", "PgDn":
+		if u.follow {
+			u.memory, u.follow = u.emu.CPU.I&^0xF, false
+		}
+		step := uint16(0x80)
+		if key == "PgUp" {
+			step = -step
+		}
+		u.memory = (u.memory + step) & u.emu.RAM.Mask()
+	case "Home":
+		u.follow = true
+	default:
+		if hotkey, ok := hotkeys[key]; ok {
+			u.emu.HandleHotkey(hotkey, true)
+			if hotkey == input.FastForward {
+				u.fastHeld = time.Now().Add(holdTime)
+			}
+			return
+		}
+		if r := []rune(key); len(r) == 1 {
+			if k, ok := input.KeyForRune(r[0]); ok {
+				u.emu.Input.SetKeyPressed(k, true)
+				u.held[k] = time.Now().Add(holdTime)
+			}
+		}
+	}
+}
+
+// edit handles a key typed into the command line.
+func (u *UI) edit(key string) {
+	switch key {
+	case "Enter":
+		line := strings.TrimSpace(u.command)
+		if line == "" && len(u.history) > 0 {
+			line = u.history[len(u.history)-1] // repeat the last command
+		}
+		u.command, u.editing = "", false
+		if line != "" {
+			u.history = append(u.history, line)
+			u.run(line)
+		}
+	case "Esc":
+		u.command, u.editing = "", false
+	case "Backspace":
+		if r := []rune(u.command); len(r) > 0 {
+			u.command = string(r[:len(r)-1])
+		}
+	case "Up", "Down":
+		if key == "Up" && u.recall < len(u.history) {
+			u.recall++
+		} else if key == "Down" && u.recall > 0 {
+			u.recall--
+		}
+		u.command = ""
+		if u.recall > 0 {
+			u.command = u.history[len(u.history)-u.recall]
+		}
+	default:
+		if r := []rune(key); len(r) == 1 && r[0] >= ' ' {
+			u.command += key
+		}
+	}
+}
+
+// run runs a command typed into the command line, which is either one of
+// the UI's own or a debugger command.
+func (u *UI) run(line string) {
+	u.print("> " + line)
+	fields := strings.Fields(line)
+	switch fields[0] {
+	case "quit", "q":
+		u.emu.Stop()
+		return
+	case "view":
+		if len(fields) == 1 {
+			u.follow = true
+			return
+		}
+		address, ok := u.emu.Symbols.Parse(fields[1])
+		if !ok || int(address) >= u.emu.RAM.Size() {
+			u.print(fmt.Sprintf("bad address %q", fields[1]))
+			return
+		}
+		u.memory, u.follow = address, false
+		return
+	}
+	var out bytes.Buffer
+	err := u.d.Exec(line, &out)
+	for _, l := range strings.Split(strings.TrimRight(out.String(), "\n"), "\n") {
+		if l != "" {
+			u.print(l)
+		}
+	}
+	if err != nil {
+		u.print(err.Error())
+	}
+	if fields[0] == "help" {
+		u.print(fmt.Sprintf("%-30s %s", "view [address]", "show memory from address, or follow I"))
+		u.print(fmt.Sprintf("%-30s %s", "quit", "stop the emulator"))
+	}
+}
+
+// print adds a line to the console.
+func (u *UI) print(line string) {
+	u.output = append(u.output, line)
+	if len(u.output) > maxOutput {
+		u.output = u.output[len(u.output)-maxOutput:]
+	}
+	u.redrawn = true
+}
+
+func (u *UI) breakpoint(address uint16) bool {
+	for _, b := range u.emu.CPU.Breakpoints() {
+		if b == address {
+			return true
+		}
+	}
+	return false
+}
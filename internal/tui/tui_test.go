@@ -0,0 +1,171 @@
+package tui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jsutcodes/chip8-goemu/internal/debugger"
+	"github.com/jsutcodes/chip8-goemu/internal/emulator"
+	"github.com/jsutcodes/chip8-goemu/internal/symbols"
+)
+
+func setup(t *testing.T) (*emulator.Emulator, *UI) {
+	t.Helper()
+	emu := emulator.NewEmulator()
+	emu.RAM.LoadROM([]byte{
+		0x60, 0x03, // 200: V0 = 3
+		0x22, 0x08, // 202: call 208
+		0x12, 0x02, // 204: jump 202
+		0x00, 0x00, // 206
+		0xA3, 0x00, // 208: I = 0x300
+		0xD0, 0x02, // 20A: draw 2 rows at V0, V0
+		0x00, 0xEE, // 20C: return
+	})
+	emu.RAM.WriteByte(0x300, 0xF0)
+	emu.RAM.WriteByte(0x301, 0x90)
+	emu.Symbols = symbols.New()
+	emu.Symbols.Add("draw", 0x208)
+	return emu, newUI(debugger.New(emu), new(bytes.Buffer))
+}
+
+// typeKeys sends each key in turn, as the terminal would.
+func typeKeys(u *UI, keys ...string) {
+	for _, key := range keys {
+		u.key(key)
+	}
+}
+
+func typeCommand(u *UI, command string) {
+	typeKeys(u, ":")
+	for _, r := range command {
+		typeKeys(u, string(r))
+	}
+	typeKeys(u, "Enter")
+}
+
+func TestKeypad(t *testing.T) {
+	emu, u := setup(t)
+	typeKeys(u, "W", "4")
+	if !emu.Input.IsKeyPressed(0x5) || !emu.Input.IsKeyPressed(0xC) {
+		t.Errorf("Expected W and 4 to press keys 5 and C")
+	}
+	u.held[0x5] = time.Now().Add(-time.Millisecond)
+	u.poll()
+	if emu.Input.IsKeyPressed(0x5) || !emu.Input.IsKeyPressed(0xC) {
+		t.Errorf("Expected only key 5 to be let go of")
+	}
+
+	typeKeys(u, ":", "w")
+	if emu.Input.IsKeyPressed(0x5) || u.command != "w" {
+		t.Errorf("Expected keys typed into the command line not to reach the keypad")
+	}
+}
+
+func TestCommands(t *testing.T) {
+	emu, u := setup(t)
+	typeCommand(u, "break draw")
+	if got := emu.CPU.Breakpoints(); len(got) != 1 || got[0] != 0x208 {
+		t.Errorf("Expected a breakpoint at draw, got %X", got)
+	}
+	typeKeys(u, "F11", "F11")
+	if emu.CPU.PC != 0x208 || !emu.Paused() {
+		t.Errorf("Expected to step into draw, PC is 0x%X", emu.CPU.PC)
+	}
+	typeKeys(u, "Shift-F11")
+	if emu.CPU.PC != 0x204 {
+		t.Errorf("Expected to finish draw, PC is 0x%X", emu.CPU.PC)
+	}
+	typeKeys(u, "F9", "F9", "F9")
+	if got := emu.CPU.Breakpoints(); len(got) != 2 || got[0] != 0x204 {
+		t.Errorf("Expected F9 to toggle a breakpoint at PC, got %X", got)
+	}
+
+	typeCommand(u, "frobnicate")
+	typeKeys(u, ":", "Up", "Up", "Backspace", "Enter")
+	want := []string{"> break draw", "> frobnicate", `unknown command "frobnicate", try help`, "> break dra"}
+	if len(u.output) < 4 || strings.Join(u.output[:4], "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected console %q", u.output)
+	}
+
+	typeKeys(u, ":", "x", "Esc")
+	if u.editing || u.command != "" {
+		t.Errorf("Expected Esc to give up the command")
+	}
+	typeCommand(u, "view 300")
+	if u.follow || u.memory != 0x300 {
+		t.Errorf("Expected the memory view to move to 0x300")
+	}
+	typeCommand(u, "quit")
+	typeKeys(u, "F5")
+	if emu.Paused() {
+		t.Errorf("Expected F5 to carry on")
+	}
+}
+
+func TestDraw(t *testing.T) {
+	_, u := setup(t)
+	u.d.Step(3)
+	c := newCanvas(100, 32)
+	u.draw(c)
+	screen := c.String()
+	for _, want := range []string{
+		"CHIP-8 paused at 0x20A draw+2",
+		"V0 03",
+		"I  300  PC 20A",
+		"Stack",
+		"0x202",
+		"draw:",
+		"   208  A300",
+		"=> 20A  D002  DRW V0, V0, 2",
+		"300  F0 90 00",
+	} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("Expected %q on the screen:\n%s", want, screen)
+		}
+	}
+	if got := c.cells[22*100+2]; got.r != '=' || got.s != inverse {
+		t.Errorf("Expected the line at PC to be highlighted, got %q", got.r)
+	}
+
+	u.d.Step(1)
+	c = newCanvas(100, 32)
+	u.draw(c)
+	rows := strings.Split(c.String(), "\n")
+	// The sprite's rows are pixel rows 3 and 4, which are the lower half
+	// of one line and the upper half of the next.
+	if got := string([]rune(rows[3])[4:8]) + "/" + string([]rune(rows[4])[4:8]); got != "▄▄▄▄/▀  ▀" {
+		t.Errorf("Expected the sprite in half blocks, got %q", got)
+	}
+
+	c = newCanvas(80, 24)
+	u.draw(c)
+	if !strings.Contains(c.String(), "The terminal is 80x24; the debugger needs at least 96x32.") {
+		t.Errorf("Expected a message on a small terminal, got:\n%s", c.String())
+	}
+}
+
+func TestSpeedHotkeys(t *testing.T) {
+	emu, u := setup(t)
+	ipf := emu.Speed.InstructionsPerFrame
+	typeKeys(u, "=", "=", "-", "`", "Tab")
+	if emu.Speed.InstructionsPerFrame != ipf+1 {
+		t.Errorf("Expected = = - to make %d instructions per frame, got %d", ipf+1, emu.Speed.InstructionsPerFrame)
+	}
+	if !emu.InSlowMotion() || !emu.FastForwarding() {
+		t.Errorf("Expected ` and Tab to turn on slow motion and fast-forward")
+	}
+	if emu.Input.IsKeyPressed(0x0) {
+		t.Errorf("Expected hotkeys not to reach the keypad")
+	}
+	u.fastHeld = time.Now().Add(-time.Millisecond)
+	u.poll()
+	if emu.FastForwarding() {
+		t.Errorf("Expected fast-forward to stop once Tab is no longer repeating")
+	}
+	typeKeys(u, "F1")
+	if !emu.Speed.Uncapped {
+		t.Errorf("Expected F1 to uncap the speed")
+	}
+}